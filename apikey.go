@@ -0,0 +1,145 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/jonesrussell/gosources/internal/auth"
+	"github.com/jonesrussell/gosources/internal/config"
+	"github.com/jonesrussell/gosources/internal/database"
+	"github.com/jonesrussell/gosources/internal/logger"
+	"github.com/jonesrussell/gosources/internal/models"
+	"github.com/jonesrussell/gosources/internal/repository"
+)
+
+const apiKeyUsage = `usage: gosources [-config path] apikey create -name name -scopes scope[,scope...] [-user name]
+       gosources [-config path] apikey list
+       gosources [-config path] apikey revoke id`
+
+// runAPIKey implements the apikey subcommand and returns the exit code. It is
+// how the first admin key is issued, before anyone can call the API.
+func runAPIKey(cfg *config.Config, log logger.Logger, args []string) int {
+	if len(args) == 0 {
+		fmt.Fprintln(os.Stderr, apiKeyUsage)
+		return 2
+	}
+
+	if cfg.Database.Driver == config.DriverMemory {
+		log.Error("apikey needs a persistent database driver")
+		return 1
+	}
+	db, err := database.New(cfg, log)
+	if err != nil {
+		log.Error("Failed to connect to database", logger.Error(err))
+		return 1
+	}
+	defer func() {
+		_ = db.Close()
+	}()
+
+	store := repository.NewAPIKeyRepository(db.DB(), log)
+	users := repository.NewUserRepository(db.DB(), log)
+	ctx := context.Background()
+
+	switch args[0] {
+	case "create":
+		return createAPIKey(ctx, store, users, args[1:])
+	case "list":
+		return listAPIKeys(ctx, store, log)
+	case "revoke":
+		if len(args) != 2 {
+			fmt.Fprintln(os.Stderr, apiKeyUsage)
+			return 2
+		}
+		key, revokeErr := store.Revoke(ctx, args[1])
+		if revokeErr != nil {
+			log.Error("Failed to revoke API key", logger.Error(revokeErr))
+			return 1
+		}
+		fmt.Fprintf(os.Stdout, "Revoked %s (%s)\n", key.Name, key.Prefix)
+		return 0
+	default:
+		fmt.Fprintln(os.Stderr, apiKeyUsage)
+		return 2
+	}
+}
+
+func createAPIKey(ctx context.Context, store repository.APIKeyStore, users repository.UserStore, args []string) int {
+	flags := flag.NewFlagSet("apikey create", flag.ContinueOnError)
+	name := flags.String("name", "", "Name of the client the key is for")
+	scopes := flags.String("scopes", "", "Comma-separated scopes: sources:read, sources:write, cities:read, metrics:read, admin")
+	userName := flags.String("user", "", "Name or ID of the user the key acts as")
+	if err := flags.Parse(args); err != nil || flags.NArg() > 0 {
+		fmt.Fprintln(os.Stderr, apiKeyUsage)
+		return 2
+	}
+
+	var user *models.User
+	if *userName != "" {
+		var findErr error
+		if user, findErr = findUser(ctx, users, *userName); findErr != nil {
+			fmt.Fprintf(os.Stderr, "Failed to create API key: %v\n", findErr)
+			return 1
+		}
+	}
+
+	key, err := auth.Issue(ctx, store, *name, models.ParseScopes(*scopes), user)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to create API key: %v\n", err)
+		return 1
+	}
+
+	fmt.Fprintf(os.Stdout, "Created API key %s (%s) with scopes %s\n",
+		key.Name, key.ID, joinScopes(key.Scopes))
+	fmt.Fprintln(os.Stdout, "Store it now; it cannot be shown again:")
+	fmt.Fprintln(os.Stdout, key.Key)
+	return 0
+}
+
+func listAPIKeys(ctx context.Context, store repository.APIKeyStore, log logger.Logger) int {
+	keys, err := store.List(ctx)
+	if err != nil {
+		log.Error("Failed to list API keys", logger.Error(err))
+		return 1
+	}
+
+	printAPIKeys(os.Stdout, keys)
+	return 0
+}
+
+func printAPIKeys(w io.Writer, keys []models.APIKey) {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "ID\tNAME\tPREFIX\tSCOPES\tUSER\tLAST USED\tSTATUS")
+	for i := range keys {
+		key := &keys[i]
+		lastUsed := "never"
+		if key.LastUsedAt != nil {
+			lastUsed = key.LastUsedAt.Format(time.RFC3339)
+		}
+		status := "active"
+		if key.RevokedAt != nil {
+			status = "revoked " + key.RevokedAt.Format(time.RFC3339)
+		}
+		user := "-"
+		if key.UserID != nil {
+			user = *key.UserID
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\t%s\t%s\n",
+			key.ID, key.Name, key.Prefix, joinScopes(key.Scopes), user, lastUsed, status)
+	}
+	_ = tw.Flush()
+}
+
+func joinScopes(scopes []models.Scope) string {
+	parts := make([]string, len(scopes))
+	for i, scope := range scopes {
+		parts[i] = string(scope)
+	}
+	return strings.Join(parts, ",")
+}
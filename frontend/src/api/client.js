@@ -1,6 +1,7 @@
 import axios from 'axios'
 
 const API_BASE_URL = import.meta.env.VITE_API_URL || 'http://192.168.136.97:8050'
+const API_KEY_STORAGE = 'gosources_api_key'
 
 const client = axios.create({
   baseURL: API_BASE_URL,
@@ -9,14 +10,44 @@ const client = axios.create({
   },
 })
 
+// Send the API key saved in this browser, falling back to VITE_API_KEY.
+client.interceptors.request.use((config) => {
+  const key = localStorage.getItem(API_KEY_STORAGE) || import.meta.env.VITE_API_KEY
+  if (key) {
+    config.headers.Authorization = `Bearer ${key}`
+  }
+  return config
+})
+
+// Ask for a key when the API rejects the current one, then retry once.
+client.interceptors.response.use(undefined, (error) => {
+  const config = error.config
+  if (error.response?.status !== 401 || !config || config.retriedWithKey) {
+    return Promise.reject(error)
+  }
+  const key = window.prompt('Enter a GoSources API key')
+  if (!key) {
+    return Promise.reject(error)
+  }
+  localStorage.setItem(API_KEY_STORAGE, key.trim())
+  config.retriedWithKey = true
+  return client(config)
+})
+
 export const sourcesApi = {
   list: () => client.get('/api/v1/sources').then(res => res.data.sources || []),
   get: (id) => client.get(`/api/v1/sources/${id}`).then(res => res.data),
   create: (data) => client.post('/api/v1/sources', data).then(res => res.data),
-  update: (id, data) => client.put(`/api/v1/sources/${id}`, data).then(res => res.data),
+  update: (id, data, version) => client.put(`/api/v1/sources/${id}`, data, {
+    headers: version ? { 'If-Match': `"${version}"` } : {},
+  }).then(res => res.data),
   delete: (id) => client.delete(`/api/v1/sources/${id}`),
 }
 
+export const selectorsApi = {
+  validate: (selectors) => client.post('/api/v1/selectors/validate', { selectors }).then(res => res.data),
+}
+
 export const citiesApi = {
   list: () => client.get('/api/v1/cities').then(res => res.data.cities || []),
 }
@@ -0,0 +1,477 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/jonesrussell/gosources/internal/models"
+	"github.com/jonesrussell/gosources/internal/sourcefile"
+)
+
+// accessFixture is a server with sources in two cities and one without a
+// city, and keys for a viewer and an editor of sudbury_com and an admin.
+type accessFixture struct {
+	*testServer
+	sourceIDs map[string]string // source name -> ID
+	cityIDs   map[string]string // city name -> ID
+	viewer    string
+	editor    string
+	admin     string
+}
+
+// accessSources are seeded by newAccessFixture. Names alternate between
+// cities so that any page of a listing may cross into another city.
+var accessSources = []struct {
+	name string
+	city string
+}{
+	{name: "a-sudbury", city: "sudbury_com"},
+	{name: "b-timmins", city: "timmins"},
+	{name: "c-sudbury", city: "sudbury_com"},
+	{name: "d-none"},
+	{name: "e-timmins", city: "timmins"},
+}
+
+func newAccessFixture(t *testing.T) *accessFixture {
+	t.Helper()
+	ctx := context.Background()
+
+	f := &accessFixture{
+		testServer: newTestServer(t, true),
+		sourceIDs:  make(map[string]string),
+		cityIDs:    make(map[string]string),
+	}
+
+	for _, name := range []string{"sudbury_com", "timmins"} {
+		city := &models.City{Name: name, DisplayName: name}
+		if err := f.cities.Create(ctx, city); err != nil {
+			t.Fatalf("create city %s: %v", name, err)
+		}
+		f.cityIDs[name] = city.ID
+	}
+
+	for _, seed := range accessSources {
+		source := &models.Source{
+			Name:         seed.name,
+			URL:          "https://example.com/" + seed.name,
+			ArticleIndex: "articles",
+			PageIndex:    "pages",
+			Enabled:      true,
+		}
+		if seed.city != "" {
+			city := seed.city
+			source.CityName = &city
+		}
+		if err := f.sources.Create(ctx, source); err != nil {
+			t.Fatalf("create source %s: %v", seed.name, err)
+		}
+		f.sourceIDs[seed.name] = source.ID
+	}
+
+	f.viewer = f.issue(t, "vera-key", f.createUser(t, "vera", models.RoleViewer, "sudbury_com"),
+		models.ScopeSourcesRead, models.ScopeCitiesRead)
+	f.editor = f.issue(t, "eddie-key", f.createUser(t, "eddie", models.RoleEditor, "sudbury_com"),
+		models.ScopeSourcesRead, models.ScopeSourcesWrite, models.ScopeCitiesRead)
+	f.admin = f.issue(t, "ada-key", f.createUser(t, "ada", models.RoleAdmin), models.ScopeAdmin)
+
+	return f
+}
+
+func (f *accessFixture) sourcePath(name string) string {
+	return "/api/v1/sources/" + f.sourceIDs[name]
+}
+
+// listNames pages through GET /api/v1/sources one source at a time, starting
+// from cursor, and returns the names seen.
+func (f *accessFixture) listNames(t *testing.T, key, cursor string) []string {
+	t.Helper()
+
+	var names []string
+	for range len(accessSources) + 1 {
+		query := url.Values{"limit": {"1"}}
+		if cursor != "" {
+			query.Set("cursor", cursor)
+		}
+		w := f.do(http.MethodGet, "/api/v1/sources?"+query.Encode(), "", keyHeader(key))
+		if w.Code != http.StatusOK {
+			t.Fatalf("list = %d: %s", w.Code, w.Body)
+		}
+		var page struct {
+			Sources    []models.Source `json:"sources"`
+			NextCursor string          `json:"next_cursor"`
+		}
+		if err := json.Unmarshal(w.Body.Bytes(), &page); err != nil {
+			t.Fatal(err)
+		}
+		for i := range page.Sources {
+			names = append(names, page.Sources[i].Name)
+		}
+		if page.NextCursor == "" {
+			return names
+		}
+		cursor = page.NextCursor
+	}
+
+	t.Fatalf("list did not end after %d pages: %v", len(accessSources)+1, names)
+	return nil
+}
+
+func (f *accessFixture) exportNames(t *testing.T, key string) []string {
+	t.Helper()
+
+	w := f.do(http.MethodGet, "/api/v1/sources/export?format=json", "", keyHeader(key))
+	if w.Code != http.StatusOK {
+		t.Fatalf("export = %d: %s", w.Code, w.Body)
+	}
+	entries, err := sourcefile.Decode(w.Body.Bytes(), sourcefile.FormatJSON)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	names := make([]string, len(entries))
+	for i := range entries {
+		names[i] = entries[i].Name
+	}
+	return names
+}
+
+func citySourceBody(name, city string) string {
+	body := newSourceBody(name)
+	if city == "" {
+		return body
+	}
+	return strings.TrimSuffix(body, "}") + fmt.Sprintf(`,"city_name":%q}`, city)
+}
+
+func TestSourceAccess(t *testing.T) {
+	f := newAccessFixture(t)
+
+	tests := []struct {
+		name   string
+		key    string
+		method string
+		path   string
+		body   string
+		want   int
+	}{
+		{name: "viewer gets own city", key: f.viewer, method: http.MethodGet, path: f.sourcePath("a-sudbury"), want: http.StatusOK},
+		{name: "viewer gets other city", key: f.viewer, method: http.MethodGet, path: f.sourcePath("b-timmins"), want: http.StatusNotFound},
+		{
+			name: "viewer cannot create", key: f.viewer, method: http.MethodPost, path: "/api/v1/sources",
+			body: citySourceBody("f-sudbury", "sudbury_com"), want: http.StatusForbidden,
+		},
+		{
+			name: "viewer cannot update", key: f.viewer, method: http.MethodPut, path: f.sourcePath("a-sudbury"),
+			body: citySourceBody("a-sudbury", "sudbury_com"), want: http.StatusForbidden,
+		},
+		{name: "viewer cannot delete", key: f.viewer, method: http.MethodDelete, path: f.sourcePath("a-sudbury"), want: http.StatusForbidden},
+		{
+			name: "viewer cannot import", key: f.viewer, method: http.MethodPost, path: "/api/v1/sources/import",
+			body: `[]`, want: http.StatusForbidden,
+		},
+
+		{name: "editor gets own city", key: f.editor, method: http.MethodGet, path: f.sourcePath("a-sudbury"), want: http.StatusOK},
+		{name: "editor gets other city", key: f.editor, method: http.MethodGet, path: f.sourcePath("b-timmins"), want: http.StatusNotFound},
+		{name: "editor gets no city", key: f.editor, method: http.MethodGet, path: f.sourcePath("d-none"), want: http.StatusNotFound},
+		{
+			name: "editor gets other city's revisions", key: f.editor, method: http.MethodGet,
+			path: f.sourcePath("b-timmins") + "/revisions", want: http.StatusNotFound,
+		},
+		{
+			name: "editor creates in own city", key: f.editor, method: http.MethodPost, path: "/api/v1/sources",
+			body: citySourceBody("f-sudbury", "sudbury_com"), want: http.StatusCreated,
+		},
+		{
+			name: "editor creates in other city", key: f.editor, method: http.MethodPost, path: "/api/v1/sources",
+			body: citySourceBody("f-timmins", "timmins"), want: http.StatusForbidden,
+		},
+		{
+			name: "editor creates without a city", key: f.editor, method: http.MethodPost, path: "/api/v1/sources",
+			body: citySourceBody("f-none", ""), want: http.StatusForbidden,
+		},
+		{
+			name: "editor updates own city", key: f.editor, method: http.MethodPut, path: f.sourcePath("a-sudbury"),
+			body: citySourceBody("a-sudbury", "sudbury_com"), want: http.StatusOK,
+		},
+		{
+			name: "editor updates other city", key: f.editor, method: http.MethodPut, path: f.sourcePath("b-timmins"),
+			body: citySourceBody("b-timmins", "timmins"), want: http.StatusNotFound,
+		},
+		{
+			name: "editor moves a source into other city", key: f.editor, method: http.MethodPut, path: f.sourcePath("c-sudbury"),
+			body: citySourceBody("c-sudbury", "timmins"), want: http.StatusForbidden,
+		},
+		{
+			name: "editor moves a source out of other city", key: f.editor, method: http.MethodPut, path: f.sourcePath("b-timmins"),
+			body: citySourceBody("b-timmins", "sudbury_com"), want: http.StatusNotFound,
+		},
+		{name: "editor deletes other city", key: f.editor, method: http.MethodDelete, path: f.sourcePath("b-timmins"), want: http.StatusNotFound},
+		{name: "editor deletes no city", key: f.editor, method: http.MethodDelete, path: f.sourcePath("d-none"), want: http.StatusNotFound},
+		{
+			name: "editor gets other city record", key: f.editor, method: http.MethodGet,
+			path: "/api/v1/cities/" + f.cityIDs["timmins"], want: http.StatusNotFound,
+		},
+
+		{name: "admin gets other city", key: f.admin, method: http.MethodGet, path: f.sourcePath("b-timmins"), want: http.StatusOK},
+		{name: "admin gets no city", key: f.admin, method: http.MethodGet, path: f.sourcePath("d-none"), want: http.StatusOK},
+		{
+			name: "admin updates any city", key: f.admin, method: http.MethodPut, path: f.sourcePath("e-timmins"),
+			body: citySourceBody("e-timmins", "sudbury_com"), want: http.StatusOK,
+		},
+		{name: "admin deletes no city", key: f.admin, method: http.MethodDelete, path: f.sourcePath("d-none"), want: http.StatusNoContent},
+		{
+			name: "admin gets any city record", key: f.admin, method: http.MethodGet,
+			path: "/api/v1/cities/" + f.cityIDs["timmins"], want: http.StatusOK,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := f.do(tt.method, tt.path, tt.body, keyHeader(tt.key))
+			if w.Code != tt.want {
+				t.Errorf("%s %s = %d, want %d: %s", tt.method, tt.path, w.Code, tt.want, w.Body)
+			}
+		})
+	}
+
+	// The refused changes left the other cities as they were.
+	for _, name := range []string{"b-timmins", "c-sudbury"} {
+		source, err := f.sources.GetByID(context.Background(), f.sourceIDs[name])
+		if err != nil {
+			t.Fatalf("get %s: %v", name, err)
+		}
+		if source.Version != 1 {
+			t.Errorf("%s is at version %d after refused changes", name, source.Version)
+		}
+	}
+}
+
+func TestSourceAccessList(t *testing.T) {
+	f := newAccessFixture(t)
+	all := []string{"a-sudbury", "b-timmins", "c-sudbury", "d-none", "e-timmins"}
+	sudbury := []string{"a-sudbury", "c-sudbury"}
+
+	tests := []struct {
+		name string
+		key  string
+		want []string
+	}{
+		{name: "viewer", key: f.viewer, want: sudbury},
+		{name: "editor", key: f.editor, want: sudbury},
+		{name: "admin", key: f.admin, want: all},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := f.listNames(t, tt.key, ""); !slices.Equal(got, tt.want) {
+				t.Errorf("list = %v, want %v", got, tt.want)
+			}
+			if got := f.exportNames(t, tt.key); !slices.Equal(got, tt.want) {
+				t.Errorf("export = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+// TestSourceAccessCursor resumes an editor's listing from each cursor an
+// admin was given, which point at sources the editor may not see.
+func TestSourceAccessCursor(t *testing.T) {
+	f := newAccessFixture(t)
+
+	tests := []struct {
+		after string // the cursor's last source
+		want  []string
+	}{
+		{after: "a-sudbury", want: []string{"c-sudbury"}},
+		{after: "b-timmins", want: []string{"c-sudbury"}},
+		{after: "c-sudbury", want: nil},
+		{after: "d-none", want: nil},
+	}
+
+	cursor := ""
+	for _, tt := range tests {
+		t.Run("after "+tt.after, func(t *testing.T) {
+			query := url.Values{"limit": {"1"}}
+			if cursor != "" {
+				query.Set("cursor", cursor)
+			}
+			w := f.do(http.MethodGet, "/api/v1/sources?"+query.Encode(), "", keyHeader(f.admin))
+			if w.Code != http.StatusOK {
+				t.Fatalf("admin list = %d: %s", w.Code, w.Body)
+			}
+			var page struct {
+				Sources    []models.Source `json:"sources"`
+				NextCursor string          `json:"next_cursor"`
+			}
+			if err := json.Unmarshal(w.Body.Bytes(), &page); err != nil {
+				t.Fatal(err)
+			}
+			if len(page.Sources) != 1 || page.Sources[0].Name != tt.after {
+				t.Fatalf("admin page = %+v, want %s", page.Sources, tt.after)
+			}
+			cursor = page.NextCursor
+
+			if got := f.listNames(t, f.editor, cursor); !slices.Equal(got, tt.want) {
+				t.Errorf("editor list from the admin's cursor = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSourceAccessImport(t *testing.T) {
+	entry := func(name, city string) sourcefile.Entry {
+		e := sourcefile.Entry{
+			Name:         name,
+			URL:          "https://example.com/" + name,
+			ArticleIndex: "articles",
+			PageIndex:    "pages",
+		}
+		if city != "" {
+			e.CityName = &city
+		}
+		return e
+	}
+	own := []sourcefile.Entry{entry("a-sudbury", "sudbury_com"), entry("c-sudbury", "sudbury_com")}
+
+	tests := []struct {
+		name      string
+		admin     bool
+		query     string
+		entries   []sourcefile.Entry
+		want      []sourcefile.Action
+		wantTotal int // sources stored afterwards
+	}{
+		{
+			name:      "create in other city",
+			entries:   []sourcefile.Entry{entry("f-sudbury", "sudbury_com"), entry("f-timmins", "timmins")},
+			want:      []sourcefile.Action{sourcefile.ActionCreate, sourcefile.ActionError},
+			wantTotal: 6,
+		},
+		{
+			name:      "dry run create in other city",
+			query:     "dry_run=true",
+			entries:   []sourcefile.Entry{entry("f-sudbury", "sudbury_com"), entry("f-timmins", "timmins")},
+			want:      []sourcefile.Action{sourcefile.ActionCreate, sourcefile.ActionError},
+			wantTotal: 5,
+		},
+		{
+			name:      "create without a city",
+			entries:   []sourcefile.Entry{entry("f-none", "")},
+			want:      []sourcefile.Action{sourcefile.ActionError},
+			wantTotal: 5,
+		},
+		{
+			name:      "move own source into other city",
+			query:     "mode=upsert",
+			entries:   []sourcefile.Entry{entry("a-sudbury", "timmins")},
+			want:      []sourcefile.Action{sourcefile.ActionError},
+			wantTotal: 5,
+		},
+		{
+			name:      "prune keeps other cities",
+			query:     "mode=upsert&prune=true",
+			entries:   own,
+			want:      []sourcefile.Action{sourcefile.ActionUnchanged, sourcefile.ActionUnchanged},
+			wantTotal: 5,
+		},
+		{
+			name:    "admin prunes every city",
+			admin:   true,
+			query:   "mode=upsert&prune=true",
+			entries: own,
+			want: []sourcefile.Action{
+				sourcefile.ActionUnchanged, sourcefile.ActionUnchanged,
+				sourcefile.ActionDelete, sourcefile.ActionDelete, sourcefile.ActionDelete,
+			},
+			wantTotal: 2,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := newAccessFixture(t)
+			key := f.editor
+			if tt.admin {
+				key = f.admin
+			}
+			body, err := json.Marshal(tt.entries)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			w := f.do(http.MethodPost, "/api/v1/sources/import?"+tt.query, string(body), keyHeader(key))
+			if w.Code != http.StatusOK {
+				t.Fatalf("import = %d: %s", w.Code, w.Body)
+			}
+			var report sourcefile.Report
+			if err = json.Unmarshal(w.Body.Bytes(), &report); err != nil {
+				t.Fatal(err)
+			}
+			got := make([]sourcefile.Action, len(report.Results))
+			for i := range report.Results {
+				got[i] = report.Results[i].Action
+			}
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("actions = %v, want %v: %+v", got, tt.want, report.Results)
+			}
+
+			if names := f.listNames(t, f.admin, ""); len(names) != tt.wantTotal {
+				t.Errorf("stored %v, want %d sources", names, tt.wantTotal)
+			}
+			stored, err := f.sources.GetByID(context.Background(), f.sourceIDs["a-sudbury"])
+			if err != nil {
+				t.Fatal(err)
+			}
+			if *stored.CityName != "sudbury_com" {
+				t.Errorf("a-sudbury moved to %s", *stored.CityName)
+			}
+		})
+	}
+}
+
+func TestCityAccess(t *testing.T) {
+	f := newAccessFixture(t)
+
+	tests := []struct {
+		name string
+		key  string
+		want []string
+	}{
+		{name: "viewer", key: f.viewer, want: []string{"sudbury_com"}},
+		{name: "editor", key: f.editor, want: []string{"sudbury_com"}},
+		{name: "admin", key: f.admin, want: []string{"sudbury_com", "timmins"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for _, path := range []string{"/api/v1/cities", "/api/v1/cities?all=true"} {
+				w := f.do(http.MethodGet, path, "", keyHeader(tt.key))
+				if w.Code != http.StatusOK {
+					t.Fatalf("GET %s = %d: %s", path, w.Code, w.Body)
+				}
+				var body struct {
+					Cities []struct {
+						Name string `json:"name"`
+					} `json:"cities"`
+				}
+				if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil {
+					t.Fatal(err)
+				}
+				got := make([]string, len(body.Cities))
+				for i := range body.Cities {
+					got[i] = body.Cities[i].Name
+				}
+				slices.Sort(got)
+				if !slices.Equal(got, tt.want) {
+					t.Errorf("GET %s = %v, want %v", path, got, tt.want)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,332 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jonesrussell/gosources/internal/auth"
+	"github.com/jonesrussell/gosources/internal/events"
+	"github.com/jonesrussell/gosources/internal/health"
+	"github.com/jonesrussell/gosources/internal/logger"
+	"github.com/jonesrussell/gosources/internal/metrics"
+	"github.com/jonesrussell/gosources/internal/models"
+	"github.com/jonesrussell/gosources/internal/repository"
+	"github.com/jonesrussell/gosources/internal/webhook"
+)
+
+// testServer is a router over in-memory stores.
+type testServer struct {
+	router  *gin.Engine
+	sources *repository.MemorySourceStore
+	cities  *repository.MemoryCityStore
+	keys    *repository.MemoryAPIKeyStore
+	users   *repository.MemoryUserStore
+}
+
+func newTestServer(t *testing.T, requireAuth bool) *testServer {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+
+	log := logger.NewNopLogger()
+	eventLog := repository.NewMemoryEventLog(log)
+	sources := repository.NewMemorySourceStore(eventLog, log)
+	webhooks := repository.NewMemoryWebhookStore(log)
+	keys := repository.NewMemoryAPIKeyStore(log)
+	users := repository.NewMemoryUserStore(keys, log)
+	cities := repository.NewMemoryCityStore(sources, log)
+
+	router := NewRouter(Services{
+		Sources:    sources,
+		Webhooks:   webhooks,
+		Dispatcher: webhook.NewDispatcher(webhooks, eventLog, log),
+		EventLog:   eventLog,
+		Stream:     events.NewStream(eventLog, log),
+		APIKeys:    keys,
+		Users:      users,
+		Cities:     cities,
+		Metrics:    metrics.New(log),
+		Health:     health.NewChecker(),
+	}, requireAuth, log)
+
+	return &testServer{router: router, sources: sources, cities: cities, keys: keys, users: users}
+}
+
+// issue stores a key with the given scopes, for user when not nil, and
+// returns its plaintext.
+func (s *testServer) issue(t *testing.T, name string, user *models.User, scopes ...models.Scope) string {
+	t.Helper()
+	key, err := auth.Issue(context.Background(), s.keys, name, scopes, user)
+	if err != nil {
+		t.Fatalf("issue %s: %v", name, err)
+	}
+	return key.Key
+}
+
+func (s *testServer) createUser(t *testing.T, name string, role models.Role, cities ...string) *models.User {
+	t.Helper()
+	user := &models.User{Name: name, Role: role, Cities: cities}
+	if err := s.users.Create(context.Background(), user); err != nil {
+		t.Fatalf("create user %s: %v", name, err)
+	}
+	return user
+}
+
+func (s *testServer) do(method, path, body string, header http.Header) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, path, strings.NewReader(body))
+	if body != "" {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	for name, values := range header {
+		for _, value := range values {
+			req.Header.Add(name, value)
+		}
+	}
+	w := httptest.NewRecorder()
+	s.router.ServeHTTP(w, req)
+	return w
+}
+
+func keyHeader(key string) http.Header {
+	return http.Header{"X-Api-Key": {key}}
+}
+
+func newSourceBody(name string) string {
+	return fmt.Sprintf(`{"name":%q,"url":"https://example.com/%s","article_index":"articles","page_index":"pages","selectors":{}}`,
+		name, name)
+}
+
+func TestAuthenticate(t *testing.T) {
+	s := newTestServer(t, true)
+
+	admin := s.issue(t, "admin", nil, models.ScopeAdmin)
+	reader := s.issue(t, "reader", nil, models.ScopeSourcesRead)
+	writer := s.issue(t, "writer", nil, models.ScopeSourcesRead, models.ScopeSourcesWrite)
+	scraper := s.issue(t, "scraper", nil, models.ScopeMetricsRead)
+	gopost := s.issue(t, "gopost", nil, models.ScopeCitiesRead)
+
+	revoked := s.issue(t, "revoked", nil, models.ScopeAdmin)
+	stored, err := s.keys.GetByHash(context.Background(), auth.Hash(revoked))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err = s.keys.Revoke(context.Background(), stored.ID); err != nil {
+		t.Fatal(err)
+	}
+
+	viewer := s.createUser(t, "vera", models.RoleViewer, "sudbury_com")
+	viewerKey := s.issue(t, "vera-key", viewer, models.ScopeSourcesRead)
+
+	tests := []struct {
+		name   string
+		method string
+		path   string
+		body   string
+		header http.Header
+		want   int
+	}{
+		{name: "health is public", method: http.MethodGet, path: "/health", want: http.StatusOK},
+		{name: "readiness is public", method: http.MethodGet, path: "/readyz", want: http.StatusOK},
+
+		{name: "missing key", method: http.MethodGet, path: "/api/v1/sources", want: http.StatusUnauthorized},
+		{
+			name: "invalid key", method: http.MethodGet, path: "/api/v1/sources",
+			header: keyHeader("gsk_not-a-key"), want: http.StatusUnauthorized,
+		},
+		{
+			name: "revoked key", method: http.MethodGet, path: "/api/v1/sources",
+			header: keyHeader(revoked), want: http.StatusUnauthorized,
+		},
+		{
+			name: "bearer token", method: http.MethodGet, path: "/api/v1/sources",
+			header: http.Header{"Authorization": {"Bearer " + reader}}, want: http.StatusOK,
+		},
+		{
+			name: "key in the query is only read by the feed", method: http.MethodGet,
+			path: "/api/v1/sources?key=" + reader, want: http.StatusUnauthorized,
+		},
+		{
+			name: "key in the query for the feed", method: http.MethodGet,
+			path: "/api/v1/schedule.ics?key=" + reader, want: http.StatusOK,
+		},
+
+		{
+			name: "read scope reads sources", method: http.MethodGet, path: "/api/v1/sources",
+			header: keyHeader(reader), want: http.StatusOK,
+		},
+		{
+			name: "read scope cannot create", method: http.MethodPost, path: "/api/v1/sources",
+			body: newSourceBody("read-only"), header: keyHeader(reader), want: http.StatusForbidden,
+		},
+		{
+			name: "write scope creates", method: http.MethodPost, path: "/api/v1/sources",
+			body: newSourceBody("written"), header: keyHeader(writer), want: http.StatusCreated,
+		},
+		{
+			name: "write scope is not admin", method: http.MethodGet, path: "/api/v1/webhooks",
+			header: keyHeader(writer), want: http.StatusForbidden,
+		},
+		{
+			name: "cities scope reads cities", method: http.MethodGet, path: "/api/v1/cities",
+			header: keyHeader(gopost), want: http.StatusOK,
+		},
+		{
+			name: "cities scope cannot read sources", method: http.MethodGet, path: "/api/v1/sources",
+			header: keyHeader(gopost), want: http.StatusForbidden,
+		},
+		{
+			name: "admin manages keys", method: http.MethodGet, path: "/api/v1/api-keys",
+			header: keyHeader(admin), want: http.StatusOK,
+		},
+		{
+			name: "viewer role cannot create", method: http.MethodPost, path: "/api/v1/sources",
+			body: newSourceBody("viewer"), header: keyHeader(viewerKey), want: http.StatusForbidden,
+		},
+
+		{name: "metrics without a key", method: http.MethodGet, path: "/metrics", want: http.StatusUnauthorized},
+		{
+			name: "metrics without metrics:read", method: http.MethodGet, path: "/metrics",
+			header: keyHeader(reader), want: http.StatusForbidden,
+		},
+		{
+			name: "metrics with metrics:read", method: http.MethodGet, path: "/metrics",
+			header: keyHeader(scraper), want: http.StatusOK,
+		},
+		{
+			name: "metrics with admin", method: http.MethodGet, path: "/metrics",
+			header: keyHeader(admin), want: http.StatusOK,
+		},
+		{
+			name: "metrics:read cannot read sources", method: http.MethodGet, path: "/api/v1/sources",
+			header: keyHeader(scraper), want: http.StatusForbidden,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := s.do(tt.method, tt.path, tt.body, tt.header)
+			if w.Code != tt.want {
+				t.Errorf("%s %s = %d, want %d: %s", tt.method, tt.path, w.Code, tt.want, w.Body)
+			}
+			if w.Code == http.StatusUnauthorized && w.Header().Get("WWW-Authenticate") == "" {
+				t.Error("401 without WWW-Authenticate")
+			}
+		})
+	}
+}
+
+func TestAuthenticateWhenNotRequired(t *testing.T) {
+	s := newTestServer(t, false)
+
+	tests := []struct {
+		name   string
+		header http.Header
+		want   int
+	}{
+		{name: "no key", want: http.StatusOK},
+		{name: "invalid key is still rejected", header: keyHeader("gsk_not-a-key"), want: http.StatusUnauthorized},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if w := s.do(http.MethodGet, "/api/v1/sources", "", tt.header); w.Code != tt.want {
+				t.Errorf("GET /api/v1/sources = %d, want %d: %s", w.Code, tt.want, w.Body)
+			}
+		})
+	}
+}
+
+// TestActor checks who a change is attributed to in the source's revisions.
+func TestActor(t *testing.T) {
+	tests := []struct {
+		name        string
+		requireAuth bool
+		key         func(s *testServer) string // "" sends no key
+		actor       string                     // X-Actor header
+		want        string
+	}{
+		{
+			name:        "service key",
+			requireAuth: true,
+			key:         func(s *testServer) string { return s.issue(t, "deploy", nil, models.ScopeAdmin) },
+			want:        "apikey:deploy",
+		},
+		{
+			name:        "X-Actor cannot override the key",
+			requireAuth: true,
+			key:         func(s *testServer) string { return s.issue(t, "deploy", nil, models.ScopeAdmin) },
+			actor:       "mallory",
+			want:        "apikey:deploy",
+		},
+		{
+			name:        "user key",
+			requireAuth: true,
+			key: func(s *testServer) string {
+				return s.issue(t, "eddie-key", s.createUser(t, "eddie", models.RoleAdmin), models.ScopeAdmin)
+			},
+			actor: "mallory",
+			want:  "eddie",
+		},
+		{
+			name:        "key without auth required",
+			requireAuth: false,
+			key:         func(s *testServer) string { return s.issue(t, "deploy", nil, models.ScopeAdmin) },
+			actor:       "mallory",
+			want:        "apikey:deploy",
+		},
+		{
+			name:        "X-Actor without auth required",
+			requireAuth: false,
+			key:         func(*testServer) string { return "" },
+			actor:       "alice",
+			want:        "alice",
+		},
+		{
+			name:        "anonymous",
+			requireAuth: false,
+			key:         func(*testServer) string { return "" },
+			want:        repository.DefaultActor,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := newTestServer(t, tt.requireAuth)
+			header := http.Header{}
+			if key := tt.key(s); key != "" {
+				header = keyHeader(key)
+			}
+			if tt.actor != "" {
+				header.Set("X-Actor", tt.actor)
+			}
+
+			w := s.do(http.MethodPost, "/api/v1/sources", newSourceBody("attributed"), header)
+			if w.Code != http.StatusCreated {
+				t.Fatalf("create = %d: %s", w.Code, w.Body)
+			}
+			var source models.Source
+			if err := json.Unmarshal(w.Body.Bytes(), &source); err != nil {
+				t.Fatal(err)
+			}
+
+			w = s.do(http.MethodGet, "/api/v1/sources/"+source.ID+"/revisions", "", header)
+			if w.Code != http.StatusOK {
+				t.Fatalf("revisions = %d: %s", w.Code, w.Body)
+			}
+			var revisions struct {
+				Revisions []models.SourceRevision `json:"revisions"`
+			}
+			if err := json.Unmarshal(w.Body.Bytes(), &revisions); err != nil {
+				t.Fatal(err)
+			}
+			if len(revisions.Revisions) != 1 || revisions.Revisions[0].Actor != tt.want {
+				t.Errorf("revisions = %+v, want one by %q", revisions.Revisions, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,59 @@
+package api
+
+import (
+	"context"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/jonesrussell/gosources/internal/logger"
+)
+
+// RequestIDHeader carries the request ID in both directions.
+const RequestIDHeader = "X-Request-ID"
+
+// maxRequestIDLength bounds IDs accepted from clients so they stay usable as
+// log fields.
+const maxRequestIDLength = 128
+
+type requestIDKey struct{}
+
+// RequestIDFromContext returns the ID requestIDMiddleware assigned to the
+// request, or "" outside a request.
+func RequestIDFromContext(ctx context.Context) string {
+	id, _ := ctx.Value(requestIDKey{}).(string)
+	return id
+}
+
+// requestIDMiddleware takes the caller's X-Request-ID, or generates one when
+// it is missing or malformed, and echoes it in the response. The request
+// context gets a logger carrying request_id and the trace IDs, which handlers
+// and repositories reach through logger.FromContext.
+func requestIDMiddleware(log logger.Logger) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id := c.GetHeader(RequestIDHeader)
+		if !validRequestID(id) {
+			id = uuid.New().String()
+		}
+		c.Header(RequestIDHeader, id)
+
+		ctx := context.WithValue(c.Request.Context(), requestIDKey{}, id)
+		requestLog := logger.WithTrace(ctx, log).With(logger.String("request_id", id))
+		c.Request = c.Request.WithContext(logger.NewContext(ctx, requestLog))
+
+		c.Next()
+	}
+}
+
+// validRequestID accepts up to maxRequestIDLength printable ASCII characters
+// other than spaces, which covers UUIDs and the IDs proxies generate.
+func validRequestID(id string) bool {
+	if id == "" || len(id) > maxRequestIDLength {
+		return false
+	}
+	for i := range len(id) {
+		if id[i] <= ' ' || id[i] > '~' {
+			return false
+		}
+	}
+	return true
+}
@@ -6,16 +6,40 @@ import (
 
 	"github.com/gin-contrib/cors"
 	"github.com/gin-gonic/gin"
+	"github.com/jonesrussell/gosources/internal/events"
 	"github.com/jonesrussell/gosources/internal/handlers"
+	"github.com/jonesrussell/gosources/internal/health"
 	"github.com/jonesrussell/gosources/internal/logger"
+	"github.com/jonesrussell/gosources/internal/metrics"
+	"github.com/jonesrussell/gosources/internal/models"
 	"github.com/jonesrussell/gosources/internal/repository"
+	"github.com/jonesrussell/gosources/internal/tracing"
+	"github.com/jonesrussell/gosources/internal/webhook"
+	"go.opentelemetry.io/contrib/instrumentation/github.com/gin-gonic/gin/otelgin"
 )
 
 const (
 	corsMaxAgeHours = 12
 )
 
-func NewRouter(db *repository.SourceRepository, log logger.Logger) *gin.Engine {
+// Services are the stores and workers the HTTP handlers depend on.
+type Services struct {
+	Sources    repository.SourceStore
+	Webhooks   repository.WebhookStore
+	Dispatcher *webhook.Dispatcher
+	EventLog   repository.EventLog
+	Stream     *events.Stream
+	APIKeys    repository.APIKeyStore
+	Users      repository.UserStore
+	Cities     repository.CityStore
+	Metrics    *metrics.Metrics
+	Health     *health.Checker
+}
+
+// NewRouter registers every route. With requireAuth, /api/v1 routes and
+// /metrics need an API key granting the route's scope; /health, /livez and
+// /readyz are always public.
+func NewRouter(services Services, requireAuth bool, log logger.Logger) *gin.Engine {
 	router := gin.New()
 
 	// CORS middleware - must be first
@@ -26,40 +50,142 @@ func NewRouter(db *repository.SourceRepository, log logger.Logger) *gin.Engine {
 			"Origin", "Content-Type", "Content-Length", "Accept-Encoding",
 			"X-CSRF-Token", "Authorization", "accept", "origin",
 			"Cache-Control", "X-Requested-With",
+			"If-Match", "If-None-Match", "Last-Event-ID", "X-API-Key",
+			RequestIDHeader,
 		},
-		ExposeHeaders:    []string{"Content-Length"},
+		ExposeHeaders:    []string{"Content-Length", "ETag", RequestIDHeader},
 		AllowCredentials: true,
 		MaxAge:           corsMaxAgeHours * time.Hour,
 	}))
 
-	// Middleware
-	router.Use(ginLogger(log))
+	// Middleware. Tracing and the request ID come first so every log line
+	// for the request carries their IDs.
+	router.Use(otelgin.Middleware(tracing.ServiceName))
+	router.Use(requestIDMiddleware(log))
+	router.Use(ginLogger())
+	router.Use(services.Metrics.Middleware())
 	router.Use(gin.Recovery())
+	if !requireAuth {
+		router.Use(actorMiddleware())
+	}
 
 	// Health check
 	router.GET("/health", func(c *gin.Context) {
 		c.JSON(http.StatusOK, gin.H{"status": "ok"})
 	})
 
+	// Liveness and readiness probes
+	healthHandler := handlers.NewHealthHandler(services.Health, log)
+	router.GET("/livez", healthHandler.Livez)
+	router.GET("/readyz", healthHandler.Readyz)
+
+	authz := &authorizer{store: services.APIKeys, users: services.Users, required: requireAuth, logger: log}
+
+	// Prometheus metrics, which name cities and indexes
+	router.GET("/metrics",
+		authz.authenticate(), authz.require(models.ScopeMetricsRead),
+		gin.WrapH(services.Metrics.Handler()),
+	)
+
 	// API v1
-	v1 := router.Group("/api/v1")
-	sourceHandler := handlers.NewSourceHandler(db, log)
+	v1 := router.Group("/api/v1", authz.authenticate())
+	sourceHandler := handlers.NewSourceHandler(services.Sources, services.Cities)
 
 	// Sources endpoints
-	sources := v1.Group("/sources")
-	sources.POST("", sourceHandler.Create)
+	sources := v1.Group("/sources", authz.require(models.ScopeSourcesRead))
 	sources.GET("", sourceHandler.List)
+	sources.POST("/preview", sourceHandler.PreviewUnsaved)
+	sources.GET("/export", sourceHandler.Export)
 	sources.GET("/:id", sourceHandler.GetByID)
-	sources.PUT("/:id", sourceHandler.Update)
-	sources.DELETE("/:id", sourceHandler.Delete)
+	sources.POST("/:id/preview", sourceHandler.Preview)
+	sources.GET("/:id/revisions", sourceHandler.ListRevisions)
+	sources.GET("/:id/revisions/diff", sourceHandler.DiffRevisions)
+	sources.GET("/:id/revisions/:rev", sourceHandler.GetRevision)
+	sources.GET("/:id/schedule/next", sourceHandler.NextRuns)
+
+	sourceWrites := v1.Group("/sources", authz.require(models.ScopeSourcesWrite))
+	sourceWrites.POST("", sourceHandler.Create)
+	sourceWrites.POST("/import", sourceHandler.Import)
+	sourceWrites.PUT("/:id", sourceHandler.Update)
+	sourceWrites.PATCH("/:id", sourceHandler.Patch)
+	sourceWrites.DELETE("/:id", sourceHandler.Delete)
+	sourceWrites.POST("/:id/revisions/:rev/restore", sourceHandler.RestoreRevision)
+
+	// Selector syntax validation for the source form
+	selectorHandler := handlers.NewSelectorHandler(log)
+	v1.POST("/selectors/validate", authz.require(models.ScopeSourcesRead), selectorHandler.Validate)
+
+	// Crawl calendar across sources, with load analysis
+	schedule := v1.Group("/schedule", authz.require(models.ScopeSourcesRead))
+	schedule.GET("", sourceHandler.Calendar)
+	schedule.GET("/suggestions", sourceHandler.SuggestSchedule)
+
+	// The same calendar as an iCalendar feed. It sits outside the v1 group so
+	// the key can be passed as ?key= by calendar apps.
+	router.GET("/api/v1/schedule.ics",
+		queryKey(), authz.authenticate(), authz.require(models.ScopeSourcesRead),
+		sourceHandler.ScheduleICS,
+	)
 
-	// Cities endpoint for gopost integration
-	v1.GET("/cities", sourceHandler.GetCities)
+	// Cities. The list keeps the shape gopost reads; admins manage the records.
+	cityHandler := handlers.NewCityHandler(services.Cities, services.Sources)
+	cities := v1.Group("/cities", authz.require(models.ScopeCitiesRead))
+	cities.GET("", cityHandler.List)
+	cities.GET("/:id", cityHandler.GetByID)
+
+	cityWrites := v1.Group("/cities", authz.require(models.ScopeAdmin))
+	cityWrites.POST("", cityHandler.Create)
+	cityWrites.PUT("/:id", cityHandler.Update)
+	cityWrites.DELETE("/:id", cityHandler.Delete)
+
+	// Source change stream (Server-Sent Events)
+	eventHandler := handlers.NewEventHandler(services.EventLog, services.Stream, log)
+	v1.GET("/events", authz.require(models.ScopeSourcesRead), eventHandler.Stream)
+
+	// Webhook subscriptions for change notifications
+	webhookHandler := handlers.NewWebhookHandler(services.Webhooks, services.Dispatcher, log)
+	webhooks := v1.Group("/webhooks", authz.require(models.ScopeAdmin))
+	webhooks.POST("", webhookHandler.Create)
+	webhooks.GET("", webhookHandler.List)
+	webhooks.GET("/:id", webhookHandler.GetByID)
+	webhooks.PUT("/:id", webhookHandler.Update)
+	webhooks.DELETE("/:id", webhookHandler.Delete)
+	webhooks.GET("/:id/deliveries", webhookHandler.ListDeliveries)
+	webhooks.POST("/:id/ping", webhookHandler.Ping)
+
+	// API key management
+	apiKeyHandler := handlers.NewAPIKeyHandler(services.APIKeys, services.Users, log)
+	apiKeys := v1.Group("/api-keys", authz.require(models.ScopeAdmin))
+	apiKeys.POST("", apiKeyHandler.Create)
+	apiKeys.GET("", apiKeyHandler.List)
+	apiKeys.DELETE("/:id", apiKeyHandler.Revoke)
+
+	// Users, whose keys are limited to their role and cities
+	userHandler := handlers.NewUserHandler(services.Users, log)
+	users := v1.Group("/users", authz.require(models.ScopeAdmin))
+	users.POST("", userHandler.Create)
+	users.GET("", userHandler.List)
+	users.GET("/:id", userHandler.GetByID)
+	users.PUT("/:id", userHandler.Update)
+	users.DELETE("/:id", userHandler.Delete)
 
 	return router
 }
 
-func ginLogger(log logger.Logger) gin.HandlerFunc {
+// actorMiddleware attributes changes made during the request to the caller
+// named in the X-Actor header. It is only installed when authentication is
+// off; an API key always takes precedence, so a caller cannot act as someone
+// else.
+func actorMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if actor := c.GetHeader("X-Actor"); actor != "" {
+			c.Request = c.Request.WithContext(repository.WithActor(c.Request.Context(), actor))
+		}
+		c.Next()
+	}
+}
+
+func ginLogger() gin.HandlerFunc {
 	return func(c *gin.Context) {
 		start := time.Now()
 		path := c.Request.URL.Path
@@ -70,7 +196,7 @@ func ginLogger(log logger.Logger) gin.HandlerFunc {
 		duration := time.Since(start)
 		statusCode := c.Writer.Status()
 
-		log.Info("HTTP request",
+		logger.FromContext(c.Request.Context()).Info("HTTP request",
 			logger.String("method", method),
 			logger.String("path", path),
 			logger.Int("status_code", statusCode),
@@ -0,0 +1,69 @@
+// Package auth issues and hashes API keys and describes the caller they
+// identify.
+package auth
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+
+	"github.com/jonesrussell/gosources/internal/models"
+	"github.com/jonesrussell/gosources/internal/repository"
+)
+
+const (
+	// KeyPrefix starts every key so leaked keys are easy to recognize.
+	KeyPrefix = "gsk_"
+
+	keyBytes = 32
+
+	// displayLength is how much of a key is kept to identify it in lists.
+	displayLength = len(KeyPrefix) + 8
+)
+
+// IssuedKey is a stored key together with its plaintext, which is only
+// available when the key is issued.
+type IssuedKey struct {
+	models.APIKey
+	Key string `json:"key"`
+}
+
+// Issue validates the name and scopes, generates a key and stores its hash.
+// A key issued to a user may not grant more than the user's role.
+func Issue(
+	ctx context.Context, store repository.APIKeyStore, name string, scopes []models.Scope, user *models.User,
+) (*IssuedKey, error) {
+	key := models.APIKey{Name: name, Scopes: scopes}
+	if err := key.Validate(); err != nil {
+		return nil, err
+	}
+	if user != nil {
+		if err := user.ValidateKey(&key); err != nil {
+			return nil, err
+		}
+		key.UserID = &user.ID
+	}
+
+	b := make([]byte, keyBytes)
+	if _, err := rand.Read(b); err != nil {
+		return nil, fmt.Errorf("generate key: %w", err)
+	}
+	plaintext := KeyPrefix + hex.EncodeToString(b)
+
+	key.Prefix = plaintext[:displayLength]
+	key.Hash = Hash(plaintext)
+	if err := store.Create(ctx, &key); err != nil {
+		return nil, err
+	}
+
+	return &IssuedKey{APIKey: key, Key: plaintext}, nil
+}
+
+// Hash returns the stored form of a key. Keys are long and random, so a fast
+// hash is enough and lets a key be looked up by its hash.
+func Hash(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,101 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/jonesrussell/gosources/internal/logger"
+	"github.com/jonesrussell/gosources/internal/models"
+	"github.com/jonesrussell/gosources/internal/repository"
+)
+
+func TestIssue(t *testing.T) {
+	ctx := context.Background()
+	store := repository.NewMemoryAPIKeyStore(logger.NewNopLogger())
+	editor := &models.User{ID: "u1", Name: "eddie", Role: models.RoleEditor}
+
+	tests := []struct {
+		name    string
+		scopes  []models.Scope
+		user    *models.User
+		wantErr bool
+	}{
+		{name: "service key", scopes: []models.Scope{models.ScopeAdmin}},
+		{name: "user key within the role", scopes: []models.Scope{models.ScopeSourcesWrite}, user: editor},
+		{name: "user key beyond the role", scopes: []models.Scope{models.ScopeAdmin}, user: editor, wantErr: true},
+		{name: "unknown scope", scopes: []models.Scope{"sources:delete"}, wantErr: true},
+		{name: "no scopes", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			issued, err := Issue(ctx, store, tt.name, tt.scopes, tt.user)
+			if tt.wantErr {
+				var invalid models.ValidationErrors
+				if !errors.As(err, &invalid) {
+					t.Fatalf("Issue() error = %v, want ValidationErrors", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Issue() error = %v", err)
+			}
+
+			if !strings.HasPrefix(issued.Key, KeyPrefix) || !strings.HasPrefix(issued.Key, issued.Prefix) {
+				t.Errorf("key %q does not start with %q and prefix %q", issued.Key, KeyPrefix, issued.Prefix)
+			}
+			stored, err := store.GetByHash(ctx, Hash(issued.Key))
+			if err != nil {
+				t.Fatalf("GetByHash() error = %v", err)
+			}
+			if stored.Hash == issued.Key {
+				t.Error("the plaintext key was stored")
+			}
+			if (tt.user == nil) != (stored.UserID == nil) {
+				t.Errorf("UserID = %v, want the key tied to %v", stored.UserID, tt.user)
+			}
+		})
+	}
+}
+
+func TestPrincipalAllows(t *testing.T) {
+	key := func(scopes ...models.Scope) *models.APIKey { return &models.APIKey{Scopes: scopes} }
+	user := func(role models.Role) *models.User { return &models.User{Role: role} }
+
+	tests := []struct {
+		name      string
+		principal Principal
+		scope     models.Scope
+		want      bool
+	}{
+		{name: "granted scope", principal: Principal{Key: key(models.ScopeSourcesRead)}, scope: models.ScopeSourcesRead, want: true},
+		{name: "other scope", principal: Principal{Key: key(models.ScopeSourcesRead)}, scope: models.ScopeSourcesWrite},
+		{name: "admin implies every scope", principal: Principal{Key: key(models.ScopeAdmin)}, scope: models.ScopeMetricsRead, want: true},
+		{
+			name:      "role limits the key",
+			principal: Principal{Key: key(models.ScopeSourcesWrite), User: user(models.RoleViewer)},
+			scope:     models.ScopeSourcesWrite,
+		},
+		{
+			name:      "editor may write",
+			principal: Principal{Key: key(models.ScopeSourcesWrite), User: user(models.RoleEditor)},
+			scope:     models.ScopeSourcesWrite,
+			want:      true,
+		},
+		{
+			name:      "editor may not read metrics",
+			principal: Principal{Key: key(models.ScopeAdmin), User: user(models.RoleEditor)},
+			scope:     models.ScopeMetricsRead,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.principal.Allows(tt.scope); got != tt.want {
+				t.Errorf("Allows(%s) = %v, want %v", tt.scope, got, tt.want)
+			}
+		})
+	}
+}
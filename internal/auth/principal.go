@@ -0,0 +1,63 @@
+package auth
+
+import (
+	"context"
+	"errors"
+
+	"github.com/jonesrussell/gosources/internal/models"
+)
+
+// ErrForbidden is returned when the caller may not change a source.
+var ErrForbidden = errors.New("forbidden")
+
+// Principal is the authenticated caller: an API key and, for keys issued to a
+// user, that user.
+type Principal struct {
+	Key  *models.APIKey
+	User *models.User
+}
+
+// Allows reports whether the key grants scope and, for a user's key, the
+// user's role does too.
+func (p *Principal) Allows(scope models.Scope) bool {
+	return p.Key.Allows(scope) && (p.User == nil || p.User.Role.Grants(scope))
+}
+
+// Cities returns the cities whose sources the caller may see, or nil when the
+// caller is not limited to any. Service keys and admins are not limited.
+func (p *Principal) Cities() []string {
+	if p == nil || p.User == nil || p.User.Role == models.RoleAdmin {
+		return nil
+	}
+	if p.User.Cities == nil {
+		return []string{}
+	}
+	return p.User.Cities
+}
+
+// CanView reports whether the caller may see a source with the given city. A
+// nil Principal, as when authentication is disabled, may see everything.
+func (p *Principal) CanView(city *string) bool {
+	return p == nil || p.User == nil || p.User.CanView(city)
+}
+
+// CanEdit reports whether the caller may create, change or delete a source
+// with the given city. Whether the key allows writing at all is checked per
+// route.
+func (p *Principal) CanEdit(city *string) bool {
+	return p == nil || p.User == nil || p.User.CanEdit(city)
+}
+
+type principalKey struct{}
+
+// WithPrincipal returns a context carrying the caller.
+func WithPrincipal(ctx context.Context, p *Principal) context.Context {
+	return context.WithValue(ctx, principalKey{}, p)
+}
+
+// PrincipalFromContext returns the caller, or nil for unauthenticated
+// requests and background work, which are not limited by city.
+func PrincipalFromContext(ctx context.Context) *Principal {
+	p, _ := ctx.Value(principalKey{}).(*Principal)
+	return p
+}
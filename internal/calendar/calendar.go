@@ -0,0 +1,239 @@
+// Package calendar lays the crawl schedules of many sources out on a shared
+// timeline, finds time slots where too many of them run at once and suggests
+// spread-out times.
+package calendar
+
+import (
+	"cmp"
+	"errors"
+	"fmt"
+	"net/url"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/jonesrussell/gosources/internal/models"
+)
+
+const (
+	DefaultBucket     = 15 * time.Minute
+	DefaultWindow     = 24 * time.Hour
+	DefaultMaxSources = 5
+	DefaultMaxPerHost = 1
+
+	// MaxWindow bounds the time range of one calendar.
+	MaxWindow = 7 * 24 * time.Hour
+
+	minBucket = time.Minute
+)
+
+// ErrInvalidOptions is wrapped by the errors Options.Validate returns.
+var ErrInvalidOptions = errors.New("invalid calendar options")
+
+// ConflictReason says why a bucket was flagged.
+type ConflictReason string
+
+const (
+	// ReasonTooManySources marks a bucket with more runs than MaxSources.
+	ReasonTooManySources ConflictReason = "too_many_sources"
+	// ReasonSharedHost marks a bucket where one host has more runs than
+	// MaxPerHost.
+	ReasonSharedHost ConflictReason = "shared_host"
+)
+
+// Options describe the calendar window and when a bucket is overloaded.
+type Options struct {
+	From       time.Time
+	To         time.Time
+	Bucket     time.Duration
+	MaxSources int // Runs per bucket before it is flagged
+	MaxPerHost int // Runs per host and bucket before it is flagged
+}
+
+// Validate checks the window and thresholds.
+func (o *Options) Validate() error {
+	switch {
+	case !o.To.After(o.From):
+		return fmt.Errorf("%w: to must be after from", ErrInvalidOptions)
+	case o.To.Sub(o.From) > MaxWindow:
+		return fmt.Errorf("%w: the window must be at most %s", ErrInvalidOptions, MaxWindow)
+	case o.Bucket < minBucket || o.Bucket > DefaultWindow:
+		return fmt.Errorf("%w: bucket must be between %s and %s", ErrInvalidOptions, minBucket, DefaultWindow)
+	case o.MaxSources <= 0 || o.MaxPerHost <= 0:
+		return fmt.Errorf("%w: thresholds must be positive", ErrInvalidOptions)
+	}
+	return nil
+}
+
+// Run is one scheduled crawl of a source.
+type Run struct {
+	SourceID   string    `json:"source_id"`
+	SourceName string    `json:"source_name"`
+	Host       string    `json:"host"`
+	At         time.Time `json:"at"`
+}
+
+// Bucket holds the runs starting in [Start, End).
+type Bucket struct {
+	Start time.Time `json:"start"`
+	End   time.Time `json:"end"`
+	Runs  []Run     `json:"runs"`
+}
+
+// Conflict flags an overloaded bucket. Host is set for shared_host.
+type Conflict struct {
+	Start     time.Time      `json:"start"`
+	End       time.Time      `json:"end"`
+	Reason    ConflictReason `json:"reason"`
+	Host      string         `json:"host,omitempty"`
+	Count     int            `json:"count"`
+	SourceIDs []string       `json:"source_ids"`
+}
+
+// Skipped is a source whose schedule could not be evaluated.
+type Skipped struct {
+	SourceID string `json:"source_id"`
+	Error    string `json:"error"`
+}
+
+// Calendar is every run in the window, grouped into buckets. Buckets without
+// runs are left out.
+type Calendar struct {
+	From      time.Time  `json:"from"`
+	To        time.Time  `json:"to"`
+	Bucket    string     `json:"bucket"`
+	Sources   int        `json:"sources"` // Sources with at least one run in the window
+	Runs      int        `json:"runs"`
+	Peak      int        `json:"peak"` // Most runs in a single bucket
+	Buckets   []Bucket   `json:"buckets"`
+	Conflicts []Conflict `json:"conflicts"`
+	Skipped   []Skipped  `json:"skipped,omitempty"`
+}
+
+// Build lays out the runs of sources between opts.From and opts.To. Disabled
+// sources and sources without a schedule are ignored. Buckets are aligned
+// with time.Truncate, so 15 minute buckets start on the quarter hour.
+func Build(sources []models.Source, opts Options) *Calendar {
+	cal := &Calendar{
+		From:      opts.From,
+		To:        opts.To,
+		Bucket:    opts.Bucket.String(),
+		Buckets:   []Bucket{},
+		Conflicts: []Conflict{},
+	}
+
+	byStart := make(map[time.Time]*Bucket)
+	for i := range sources {
+		source := &sources[i]
+		schedule := source.EffectiveSchedule()
+		if !source.Enabled || schedule == nil {
+			continue
+		}
+
+		times, err := schedule.Between(opts.From, opts.To)
+		if err != nil {
+			cal.Skipped = append(cal.Skipped, Skipped{SourceID: source.ID, Error: err.Error()})
+			continue
+		}
+		if len(times) > 0 {
+			cal.Sources++
+		}
+
+		host := Host(source.URL)
+		for _, t := range times {
+			start := t.UTC().Truncate(opts.Bucket)
+			bucket, ok := byStart[start]
+			if !ok {
+				bucket = &Bucket{Start: start, End: start.Add(opts.Bucket)}
+				byStart[start] = bucket
+			}
+			bucket.Runs = append(bucket.Runs, Run{
+				SourceID:   source.ID,
+				SourceName: source.Name,
+				Host:       host,
+				At:         t,
+			})
+			cal.Runs++
+		}
+	}
+
+	for _, bucket := range byStart {
+		slices.SortFunc(bucket.Runs, func(a, b Run) int {
+			if c := a.At.Compare(b.At); c != 0 {
+				return c
+			}
+			return cmp.Compare(a.SourceName, b.SourceName)
+		})
+		cal.Buckets = append(cal.Buckets, *bucket)
+	}
+	slices.SortFunc(cal.Buckets, func(a, b Bucket) int { return a.Start.Compare(b.Start) })
+
+	for i := range cal.Buckets {
+		cal.Peak = max(cal.Peak, len(cal.Buckets[i].Runs))
+		cal.Conflicts = append(cal.Conflicts, conflicts(&cal.Buckets[i], opts)...)
+	}
+
+	return cal
+}
+
+// conflicts flags the bucket when it holds more than opts.MaxSources runs
+// and for every host with more than opts.MaxPerHost runs.
+func conflicts(bucket *Bucket, opts Options) []Conflict {
+	var found []Conflict
+
+	if len(bucket.Runs) > opts.MaxSources {
+		found = append(found, Conflict{
+			Start:     bucket.Start,
+			End:       bucket.End,
+			Reason:    ReasonTooManySources,
+			Count:     len(bucket.Runs),
+			SourceIDs: sourceIDs(bucket.Runs),
+		})
+	}
+
+	byHost := make(map[string][]Run)
+	for _, run := range bucket.Runs {
+		byHost[run.Host] = append(byHost[run.Host], run)
+	}
+	hosts := make([]string, 0, len(byHost))
+	for host := range byHost {
+		hosts = append(hosts, host)
+	}
+	slices.Sort(hosts)
+
+	for _, host := range hosts {
+		if runs := byHost[host]; len(runs) > opts.MaxPerHost {
+			found = append(found, Conflict{
+				Start:     bucket.Start,
+				End:       bucket.End,
+				Reason:    ReasonSharedHost,
+				Host:      host,
+				Count:     len(runs),
+				SourceIDs: sourceIDs(runs),
+			})
+		}
+	}
+
+	return found
+}
+
+// sourceIDs lists the distinct sources of runs in order.
+func sourceIDs(runs []Run) []string {
+	ids := make([]string, 0, len(runs))
+	for _, run := range runs {
+		if !slices.Contains(ids, run.SourceID) {
+			ids = append(ids, run.SourceID)
+		}
+	}
+	return ids
+}
+
+// Host returns the lowercased host name of a source URL without a leading
+// "www.", so both spellings of a site count as one host.
+func Host(rawURL string) string {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return ""
+	}
+	return strings.TrimPrefix(strings.ToLower(u.Hostname()), "www.")
+}
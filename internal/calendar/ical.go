@@ -0,0 +1,400 @@
+package calendar
+
+import (
+	"fmt"
+	"io"
+	"maps"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/jonesrussell/gosources/internal/models"
+	"github.com/robfig/cron/v3"
+)
+
+const (
+	icsProductID   = "-//gosources//crawl schedule//EN"
+	icsDuration    = "PT15M" // Crawls have no known length; events fill one default bucket
+	icsLineOctets  = 75
+	icsLocalLayout = "20060102T150405"
+	icsUTCLayout   = "20060102T150405Z"
+
+	// maxIntervalEvents bounds the events written for an interval that
+	// cannot be one rule, which gets an event per time of day.
+	maxIntervalEvents = 96
+
+	// cronStarBit marks a cron field written as "*", as in robfig/cron.
+	cronStarBit = 1 << 63
+)
+
+// icsDays are the iCalendar weekday codes indexed by time.Weekday.
+var icsDays = [...]string{"SU", "MO", "TU", "WE", "TH", "FR", "SA"}
+
+// event is one recurring VEVENT: the first run and the rule repeating it.
+type event struct {
+	start time.Time
+	rrule string
+}
+
+// WriteICS writes an iCalendar (RFC 5545) feed with recurring events for the
+// runs of every enabled source, starting today. Each time of day in a times
+// schedule is its own event. Intervals that divide an hour, or whole hours
+// that divide a day, become one rule; other intervals become an event per
+// time of day, up to maxIntervalEvents. Cron expressions become one rule, or
+// two for cron expressions that restrict both the day of month and the day
+// of week. Sources whose schedule cannot be rendered are left out and
+// returned.
+func WriteICS(w io.Writer, sources []models.Source, now time.Time) ([]Skipped, error) {
+	var skipped []Skipped
+	var events []string
+	zones := make(map[string]*time.Location)
+
+	for i := range sources {
+		source := &sources[i]
+		schedule := source.EffectiveSchedule()
+		if !source.Enabled || schedule == nil {
+			continue
+		}
+
+		loc, err := schedule.Location()
+		if err != nil {
+			skipped = append(skipped, Skipped{SourceID: source.ID, Error: err.Error()})
+			continue
+		}
+		sourceEvents, err := scheduleEvents(schedule, loc, now)
+		if err != nil {
+			skipped = append(skipped, Skipped{SourceID: source.ID, Error: err.Error()})
+			continue
+		}
+		if loc != time.UTC && len(sourceEvents) > 0 {
+			zones[loc.String()] = loc
+		}
+
+		for j, ev := range sourceEvents {
+			events = append(events, eventLines(source, j, ev, now)...)
+		}
+	}
+
+	lines := []string{
+		"BEGIN:VCALENDAR",
+		"VERSION:2.0",
+		"PRODID:" + icsProductID,
+		"CALSCALE:GREGORIAN",
+		"METHOD:PUBLISH",
+		"X-WR-CALNAME:Crawl schedule",
+	}
+	for _, name := range slices.Sorted(maps.Keys(zones)) {
+		lines = append(lines, vtimezone(zones[name], now.Year()-1)...)
+	}
+	lines = append(lines, events...)
+	lines = append(lines, "END:VCALENDAR")
+
+	var b strings.Builder
+	for _, line := range lines {
+		writeFolded(&b, line)
+	}
+	if _, err := io.WriteString(w, b.String()); err != nil {
+		return skipped, fmt.Errorf("write calendar: %w", err)
+	}
+
+	return skipped, nil
+}
+
+// scheduleEvents renders a schedule as recurring events whose first run is
+// on or after the start of today in loc.
+func scheduleEvents(schedule *models.Schedule, loc *time.Location, now time.Time) ([]event, error) {
+	local := now.In(loc)
+	from := time.Date(local.Year(), local.Month(), local.Day(), 0, 0, 0, 0, loc).Add(-time.Nanosecond)
+
+	if schedule.Cron != "" {
+		spec, err := schedule.CronSpec()
+		if err != nil {
+			return nil, err
+		}
+		return cronEvents(spec, from), nil
+	}
+
+	times := schedule.Times
+	if schedule.Interval != "" {
+		interval, err := time.ParseDuration(schedule.Interval)
+		if err != nil || interval < time.Minute {
+			return nil, fmt.Errorf("invalid interval %q", schedule.Interval)
+		}
+
+		if interval%time.Minute != 0 {
+			return nil, fmt.Errorf("interval %s is not a whole number of minutes", interval)
+		}
+
+		if rrule, ok := intervalRule(interval); ok {
+			first, nextErr := schedule.Next(from, 1)
+			if nextErr != nil || len(first) == 0 {
+				return nil, nextErr
+			}
+			return []event{{start: first[0], rrule: rrule + byDay(schedule.Weekdays)}}, nil
+		}
+
+		if int((day+interval-1)/interval) > maxIntervalEvents {
+			return nil, fmt.Errorf("interval %s runs at more than %d times of day", interval, maxIntervalEvents)
+		}
+		times = nil
+		for t := time.Duration(0); t < day; t += interval {
+			times = append(times, time.Time{}.Add(t).Format("15:04"))
+		}
+	}
+
+	freq := "FREQ=DAILY"
+	if len(schedule.Weekdays) > 0 {
+		freq = "FREQ=WEEKLY" + byDay(schedule.Weekdays)
+	}
+
+	var events []event
+	for _, hhmm := range slices.Compact(slices.Sorted(slices.Values(times))) {
+		single := models.Schedule{Times: []string{hhmm}, Weekdays: schedule.Weekdays, Timezone: schedule.Timezone}
+		first, err := single.Next(from, 1)
+		if err != nil {
+			return nil, err
+		}
+		if len(first) > 0 {
+			events = append(events, event{start: first[0], rrule: freq})
+		}
+	}
+	return events, nil
+}
+
+// intervalRule renders an interval that divides an hour, or a whole number
+// of hours that divides a day, as one hourly rule listing the hours and
+// minutes of its runs. Listing them keeps the runs on the same wall-clock
+// times as Schedule.Runs across daylight saving changes, which a rule
+// repeating every n minutes would not.
+func intervalRule(interval time.Duration) (string, bool) {
+	switch {
+	case time.Hour%interval == 0:
+		return "FREQ=HOURLY;BYMINUTE=" + steps(int(interval/time.Minute), 60), true
+	case interval%time.Hour == 0 && day%interval == 0:
+		return "FREQ=HOURLY;BYHOUR=" + steps(int(interval/time.Hour), 24) + ";BYMINUTE=0", true
+	}
+	return "", false
+}
+
+// steps lists the multiples of step below limit, starting at 0.
+func steps(step, limit int) string {
+	var values []string
+	for v := 0; v < limit; v += step {
+		values = append(values, strconv.Itoa(v))
+	}
+	return strings.Join(values, ",")
+}
+
+// cronEvents turns a cron expression into daily rules limited to its months
+// and days. Cron runs on days matching either a restricted day of month or a
+// restricted day of week, which iCalendar cannot say in one rule, so that
+// case becomes two events.
+func cronEvents(spec *cron.SpecSchedule, from time.Time) []event {
+	rule := "FREQ=DAILY;BYHOUR=" + bitList(spec.Hour, 0, 23) + ";BYMINUTE=" + bitList(spec.Minute, 0, 59)
+	if spec.Month&cronStarBit == 0 {
+		rule += ";BYMONTH=" + bitList(spec.Month, 1, 12)
+	}
+	byMonthDay := ";BYMONTHDAY=" + bitList(spec.Dom, 1, 31)
+	byWeekday := ";BYDAY=" + weekdayList(spec.Dow)
+
+	domStar := spec.Dom&cronStarBit != 0
+	dowStar := spec.Dow&cronStarBit != 0
+
+	type variant struct {
+		spec  cron.SpecSchedule
+		rrule string
+	}
+	var variants []variant
+	switch {
+	case domStar && dowStar:
+		variants = []variant{{*spec, rule}}
+	case dowStar:
+		variants = []variant{{*spec, rule + byMonthDay}}
+	case domStar:
+		variants = []variant{{*spec, rule + byWeekday}}
+	default:
+		domOnly, dowOnly := *spec, *spec
+		domOnly.Dow = ^uint64(0)
+		dowOnly.Dom = ^uint64(0)
+		variants = []variant{{domOnly, rule + byMonthDay}, {dowOnly, rule + byWeekday}}
+	}
+
+	var events []event
+	for i := range variants {
+		if first := variants[i].spec.Next(from); !first.IsZero() {
+			events = append(events, event{start: first, rrule: variants[i].rrule})
+		}
+	}
+	return events
+}
+
+// eventLines renders the n-th event of a source.
+func eventLines(source *models.Source, n int, ev event, now time.Time) []string {
+	dtstart := "DTSTART:" + ev.start.UTC().Format(icsUTCLayout)
+	if ev.start.Location() != time.UTC {
+		dtstart = "DTSTART;TZID=" + ev.start.Location().String() + ":" + ev.start.Format(icsLocalLayout)
+	}
+
+	description := source.URL
+	if source.CityName != nil {
+		description += "\nCity: " + *source.CityName
+	}
+
+	return []string{
+		"BEGIN:VEVENT",
+		"UID:" + source.ID + "-" + strconv.Itoa(n) + "@gosources",
+		"DTSTAMP:" + now.UTC().Format(icsUTCLayout),
+		dtstart,
+		"DURATION:" + icsDuration,
+		"RRULE:" + ev.rrule,
+		"SUMMARY:" + escapeText("Crawl "+source.Name),
+		"DESCRIPTION:" + escapeText(description),
+		"URL:" + source.URL,
+		"END:VEVENT",
+	}
+}
+
+// vtimezone describes loc's offsets from the given year on. Transitions are
+// found by scanning the year and repeat yearly on the same weekday of the
+// month, which is how current daylight saving rules are written.
+func vtimezone(loc *time.Location, year int) []string {
+	lines := []string{"BEGIN:VTIMEZONE", "TZID:" + loc.String()}
+
+	start := time.Date(year, time.January, 1, 0, 0, 0, 0, loc)
+	end := start.AddDate(1, 0, 0)
+	_, offset := start.Zone()
+	found := false
+	for t := start; t.Before(end); t = t.Add(time.Hour) {
+		next := t.Add(time.Hour)
+		if _, nextOffset := next.Zone(); nextOffset != offset {
+			lines = append(lines, observance(transition(t, next), offset)...)
+			offset = nextOffset
+			found = true
+		}
+	}
+
+	if !found {
+		name, _ := start.Zone()
+		lines = append(lines,
+			"BEGIN:STANDARD",
+			"DTSTART:19700101T000000",
+			"TZOFFSETFROM:"+formatOffset(offset),
+			"TZOFFSETTO:"+formatOffset(offset),
+			"TZNAME:"+name,
+			"END:STANDARD",
+		)
+	}
+
+	return append(lines, "END:VTIMEZONE")
+}
+
+// transition returns the first second in (before, after] with after's
+// offset.
+func transition(before, after time.Time) time.Time {
+	_, oldOffset := before.Zone()
+	for after.Sub(before) > time.Second {
+		mid := before.Add(after.Sub(before) / 2)
+		if _, offset := mid.Zone(); offset == oldOffset {
+			before = mid
+		} else {
+			after = mid
+		}
+	}
+	return after.Truncate(time.Second)
+}
+
+// observance is the STANDARD or DAYLIGHT block for a change from
+// fromOffset at the instant at.
+func observance(at time.Time, fromOffset int) []string {
+	name, toOffset := at.Zone()
+	kind := "STANDARD"
+	if at.IsDST() {
+		kind = "DAYLIGHT"
+	}
+
+	// DTSTART is the wall time just before the change.
+	wall := at.UTC().Add(time.Duration(fromOffset) * time.Second)
+	nth := (wall.Day()-1)/7 + 1
+	if wall.Day()+7 > time.Date(wall.Year(), wall.Month()+1, 0, 0, 0, 0, 0, time.UTC).Day() {
+		nth = -1
+	}
+
+	return []string{
+		"BEGIN:" + kind,
+		"DTSTART:" + wall.Format(icsLocalLayout),
+		"TZOFFSETFROM:" + formatOffset(fromOffset),
+		"TZOFFSETTO:" + formatOffset(toOffset),
+		"TZNAME:" + name,
+		fmt.Sprintf("RRULE:FREQ=YEARLY;BYMONTH=%d;BYDAY=%d%s", wall.Month(), nth, icsDays[wall.Weekday()]),
+		"END:" + kind,
+	}
+}
+
+// formatOffset renders seconds east of UTC as +HHMM.
+func formatOffset(seconds int) string {
+	sign := '+'
+	if seconds < 0 {
+		sign = '-'
+		seconds = -seconds
+	}
+	return fmt.Sprintf("%c%02d%02d", sign, seconds/3600, seconds%3600/60)
+}
+
+// byDay renders schedule weekdays ("mon") as a BYDAY part (";BYDAY=MO").
+func byDay(weekdays []string) string {
+	if len(weekdays) == 0 {
+		return ""
+	}
+	codes := make([]string, len(weekdays))
+	for i, day := range weekdays {
+		codes[i] = strings.ToUpper(day[:2])
+	}
+	return ";BYDAY=" + strings.Join(codes, ",")
+}
+
+// bitList lists the values in [lowest, highest] set in a cron field.
+func bitList(bits uint64, lowest, highest int) string {
+	var values []string
+	for v := lowest; v <= highest; v++ {
+		if bits&(1<<uint(v)) != 0 {
+			values = append(values, strconv.Itoa(v))
+		}
+	}
+	return strings.Join(values, ",")
+}
+
+// weekdayList lists the weekday codes set in a cron day-of-week field.
+func weekdayList(bits uint64) string {
+	var codes []string
+	for day, code := range icsDays {
+		if bits&(1<<uint(day)) != 0 {
+			codes = append(codes, code)
+		}
+	}
+	return strings.Join(codes, ",")
+}
+
+// escapeText escapes a TEXT property value.
+func escapeText(s string) string {
+	return strings.NewReplacer(`\`, `\\`, ";", `\;`, ",", `\,`, "\n", `\n`).Replace(s)
+}
+
+// writeFolded writes a content line ending in CRLF, folded so no line
+// exceeds 75 octets without splitting a UTF-8 sequence.
+func writeFolded(b *strings.Builder, line string) {
+	limit := icsLineOctets
+	for len(line) > limit {
+		cut := limit
+		for cut > 0 && !utf8.RuneStart(line[cut]) {
+			cut--
+		}
+		b.WriteString(line[:cut])
+		b.WriteString("\r\n ")
+		line = line[cut:]
+		limit = icsLineOctets - 1
+	}
+	b.WriteString(line)
+	b.WriteString("\r\n")
+}
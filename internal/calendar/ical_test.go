@@ -0,0 +1,154 @@
+package calendar
+
+import (
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jonesrussell/gosources/internal/models"
+)
+
+func TestWriteICS(t *testing.T) {
+	now := time.Date(2026, time.January, 5, 15, 0, 0, 0, time.UTC) // Monday
+
+	tests := []struct {
+		name     string
+		schedule models.Schedule
+		dtstart  []string
+		rrules   []string
+		skipped  bool
+	}{
+		{
+			name:     "times",
+			schedule: models.Schedule{Times: []string{"18:00", "06:30"}},
+			dtstart:  []string{"DTSTART:20260105T063000Z", "DTSTART:20260105T180000Z"},
+			rrules:   []string{"FREQ=DAILY", "FREQ=DAILY"},
+		},
+		{
+			name:     "times on weekdays in a time zone",
+			schedule: models.Schedule{Times: []string{"06:00"}, Weekdays: []string{"tue", "thu"}, Timezone: "America/Toronto"},
+			dtstart:  []string{"DTSTART;TZID=America/Toronto:20260106T060000"},
+			rrules:   []string{"FREQ=WEEKLY;BYDAY=TU,TH"},
+		},
+		{
+			name:     "interval in whole hours",
+			schedule: models.Schedule{Interval: "6h", Timezone: "America/Toronto"},
+			dtstart:  []string{"DTSTART;TZID=America/Toronto:20260105T000000"},
+			rrules:   []string{"FREQ=HOURLY;BYHOUR=0,6,12,18;BYMINUTE=0"},
+		},
+		{
+			name:     "interval that divides an hour",
+			schedule: models.Schedule{Interval: "15m", Weekdays: []string{"mon", "fri"}},
+			dtstart:  []string{"DTSTART:20260105T000000Z"},
+			rrules:   []string{"FREQ=HOURLY;BYMINUTE=0,15,30,45;BYDAY=MO,FR"},
+		},
+		{
+			name:     "interval that fits neither gets an event per time",
+			schedule: models.Schedule{Interval: "10h"},
+			dtstart:  []string{"DTSTART:20260105T000000Z", "DTSTART:20260105T100000Z", "DTSTART:20260105T200000Z"},
+			rrules:   []string{"FREQ=DAILY", "FREQ=DAILY", "FREQ=DAILY"},
+		},
+		{
+			name:     "interval with too many times is skipped",
+			schedule: models.Schedule{Interval: "7m"},
+			skipped:  true,
+		},
+		{
+			name:     "cron",
+			schedule: models.Schedule{Cron: "30 6 * * 1-5"},
+			dtstart:  []string{"DTSTART:20260105T063000Z"},
+			rrules:   []string{"FREQ=DAILY;BYHOUR=6;BYMINUTE=30;BYDAY=MO,TU,WE,TH,FR"},
+		},
+		{
+			name:     "cron restricting day of month and weekday",
+			schedule: models.Schedule{Cron: "0 12 1 * 5"},
+			dtstart:  []string{"DTSTART:20260201T120000Z", "DTSTART:20260109T120000Z"},
+			rrules:   []string{"FREQ=DAILY;BYHOUR=12;BYMINUTE=0;BYMONTHDAY=1", "FREQ=DAILY;BYHOUR=12;BYMINUTE=0;BYDAY=FR"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			source := models.Source{
+				ID:       "a",
+				Name:     "Sudbury, news",
+				URL:      "https://example.com",
+				Enabled:  true,
+				Schedule: &tt.schedule,
+			}
+
+			var b strings.Builder
+			skipped, err := WriteICS(&b, []models.Source{source}, now)
+			if err != nil {
+				t.Fatalf("WriteICS() error = %v", err)
+			}
+			if got := len(skipped) > 0; got != tt.skipped {
+				t.Fatalf("skipped = %+v, want skipped %v", skipped, tt.skipped)
+			}
+
+			dtstart, rrules := icsEvents(b.String())
+			if !slices.Equal(dtstart, tt.dtstart) {
+				t.Errorf("DTSTART = %q, want %q", dtstart, tt.dtstart)
+			}
+			if !slices.Equal(rrules, tt.rrules) {
+				t.Errorf("RRULE = %q, want %q", rrules, tt.rrules)
+			}
+		})
+	}
+}
+
+func TestWriteICSFormat(t *testing.T) {
+	source := models.Source{
+		ID:       "a",
+		Name:     "Sudbury, news; " + strings.Repeat("é", 60),
+		URL:      "https://example.com",
+		Enabled:  true,
+		Schedule: &models.Schedule{Times: []string{"06:00"}, Timezone: "America/Toronto"},
+	}
+
+	var b strings.Builder
+	if _, err := WriteICS(&b, []models.Source{source}, time.Date(2026, time.January, 5, 0, 0, 0, 0, time.UTC)); err != nil {
+		t.Fatalf("WriteICS() error = %v", err)
+	}
+	feed := b.String()
+
+	if !strings.HasSuffix(feed, "END:VCALENDAR\r\n") {
+		t.Error("feed does not end with END:VCALENDAR and CRLF")
+	}
+	for _, line := range strings.Split(strings.TrimSuffix(feed, "\r\n"), "\r\n") {
+		if len(line) > icsLineOctets {
+			t.Errorf("line of %d octets: %q", len(line), line)
+		}
+	}
+
+	unfolded := strings.ReplaceAll(feed, "\r\n ", "")
+	for _, want := range []string{
+		"TZID:America/Toronto",
+		`SUMMARY:Crawl Sudbury\, news\; `,
+		"RRULE:FREQ=YEARLY;BYMONTH=3;BYDAY=2SU",
+		"RRULE:FREQ=YEARLY;BYMONTH=11;BYDAY=1SU",
+	} {
+		if !strings.Contains(unfolded, want) {
+			t.Errorf("feed is missing %q", want)
+		}
+	}
+}
+
+// icsEvents returns the DTSTART lines and RRULE values of a feed's events.
+func icsEvents(feed string) (dtstart, rrules []string) {
+	inEvent := false
+	for _, line := range strings.Split(strings.ReplaceAll(feed, "\r\n ", ""), "\r\n") {
+		switch {
+		case line == "BEGIN:VEVENT":
+			inEvent = true
+		case line == "END:VEVENT":
+			inEvent = false
+		case inEvent && strings.HasPrefix(line, "DTSTART"):
+			dtstart = append(dtstart, line)
+		case inEvent && strings.HasPrefix(line, "RRULE:"):
+			rrules = append(rrules, strings.TrimPrefix(line, "RRULE:"))
+		}
+	}
+	return dtstart, rrules
+}
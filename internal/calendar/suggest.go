@@ -0,0 +1,271 @@
+package calendar
+
+import (
+	"cmp"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/jonesrussell/gosources/internal/models"
+)
+
+const (
+	day = 24 * time.Hour
+
+	// sampleDays is how many days of runs are read to learn how often and on
+	// which weekdays each source runs.
+	sampleDays = 7
+)
+
+// weekOrder lists weekdays in the order suggested schedules name them.
+var weekOrder = []time.Weekday{
+	time.Monday, time.Tuesday, time.Wednesday, time.Thursday, time.Friday, time.Saturday, time.Sunday,
+}
+
+// SuggestOptions configure Suggest.
+type SuggestOptions struct {
+	Day    time.Time     // The UTC day the suggested times are worked out for
+	Bucket time.Duration // Slot size; must divide a day evenly
+}
+
+// Validate checks the slot size.
+func (o *SuggestOptions) Validate() error {
+	if o.Bucket < minBucket || o.Bucket > day || day%o.Bucket != 0 {
+		return fmt.Errorf("%w: bucket must divide 24h evenly and be at least %s", ErrInvalidOptions, minBucket)
+	}
+	return nil
+}
+
+// Suggestion is the proposed schedule for one source. Suggested is nil when
+// the source's current slots are already the best found.
+type Suggestion struct {
+	SourceID   string           `json:"source_id"`
+	SourceName string           `json:"source_name"`
+	Host       string           `json:"host"`
+	RunsPerDay int              `json:"runs_per_day"`
+	Current    *models.Schedule `json:"current"`
+	Suggested  *models.Schedule `json:"suggested,omitempty"`
+}
+
+// Suggestions proposes spread-out schedules. The peaks are the most runs in
+// one slot if every source ran on the same day, before and after applying
+// the suggestions.
+type Suggestions struct {
+	Day           time.Time    `json:"day"`
+	Bucket        string       `json:"bucket"`
+	CurrentPeak   int          `json:"current_peak"`
+	SuggestedPeak int          `json:"suggested_peak"`
+	Sources       []Suggestion `json:"sources"`
+	Skipped       []Skipped    `json:"skipped,omitempty"`
+}
+
+// planned is a source with its runs mapped onto the day's slots.
+type planned struct {
+	source     *models.Source
+	schedule   *models.Schedule
+	host       string
+	loc        *time.Location
+	runsPerDay int
+	weekdays   []string // Days the source runs on; nil for every day
+	current    []int    // Slots of the runs on the source's busiest day
+}
+
+// Suggest proposes times of day that keep each enabled source's runs per day
+// and weekdays but spread the runs evenly over the day, away from busy slots
+// and from other sources on the same host. Sources that run more often than
+// once per slot are left as they are. Times are given in each source's own
+// time zone as of opts.Day.
+func Suggest(sources []models.Source, opts SuggestOptions) *Suggestions {
+	start := opts.Day.UTC().Truncate(day)
+	slots := int(day / opts.Bucket)
+	result := &Suggestions{
+		Day:     start,
+		Bucket:  opts.Bucket.String(),
+		Sources: []Suggestion{},
+	}
+
+	var plans []planned
+	for i := range sources {
+		source := &sources[i]
+		schedule := source.EffectiveSchedule()
+		if !source.Enabled || schedule == nil {
+			continue
+		}
+
+		plan, err := plan(source, schedule, start, opts.Bucket)
+		if err != nil {
+			result.Skipped = append(result.Skipped, Skipped{SourceID: source.ID, Error: err.Error()})
+			continue
+		}
+		if plan.runsPerDay > 0 {
+			plans = append(plans, plan)
+		}
+	}
+
+	// The busiest sources are placed first, while most slots are free.
+	slices.SortFunc(plans, func(a, b planned) int {
+		if c := cmp.Compare(b.runsPerDay, a.runsPerDay); c != 0 {
+			return c
+		}
+		if c := cmp.Compare(a.source.Name, b.source.Name); c != 0 {
+			return c
+		}
+		return cmp.Compare(a.source.ID, b.source.ID)
+	})
+
+	current := make([]int, slots)
+	load := make([]int, slots)
+	hostLoad := make(map[string][]int)
+	// A second run on the same host outweighs any number of other sources.
+	hostPenalty := len(plans) + 1
+
+	for i := range plans {
+		p := &plans[i]
+		for _, slot := range p.current {
+			current[slot]++
+		}
+		if hostLoad[p.host] == nil {
+			hostLoad[p.host] = make([]int, slots)
+		}
+
+		chosen := p.current
+		if p.runsPerDay <= slots {
+			// Runs sharing a slot today are spread over separate slots.
+			chosen = place(p, load, hostLoad[p.host], hostPenalty)
+		}
+		for _, slot := range chosen {
+			load[slot]++
+			hostLoad[p.host][slot]++
+		}
+
+		suggestion := Suggestion{
+			SourceID:   p.source.ID,
+			SourceName: p.source.Name,
+			Host:       p.host,
+			RunsPerDay: p.runsPerDay,
+			Current:    p.schedule,
+		}
+		if !slices.Equal(chosen, p.current) {
+			suggestion.Suggested = p.suggested(chosen, start, opts.Bucket)
+		}
+		result.Sources = append(result.Sources, suggestion)
+	}
+
+	result.CurrentPeak = slices.Max(current)
+	result.SuggestedPeak = slices.Max(load)
+
+	return result
+}
+
+// plan reads a week of the source's runs from start to learn its runs per
+// day, its weekdays and the slots it uses on its busiest day.
+func plan(source *models.Source, schedule *models.Schedule, start time.Time, bucket time.Duration) (planned, error) {
+	loc, err := schedule.Location()
+	if err != nil {
+		return planned{}, err
+	}
+	times, err := schedule.Between(start, start.Add(sampleDays*day))
+	if err != nil {
+		return planned{}, err
+	}
+
+	p := planned{
+		source:   source,
+		schedule: schedule,
+		host:     Host(source.URL),
+		loc:      loc,
+	}
+
+	// Runs are grouped by the local day they fall on, the day the schedule's
+	// weekdays and times refer to.
+	byDay := make(map[time.Time][]int)
+	active := make(map[time.Weekday]bool)
+	for _, t := range times {
+		local := t.In(loc)
+		date := time.Date(local.Year(), local.Month(), local.Day(), 0, 0, 0, 0, time.UTC)
+		byDay[date] = append(byDay[date], p.slot(local, start, bucket))
+		active[local.Weekday()] = true
+	}
+
+	for _, slotsOnDay := range byDay {
+		if len(slotsOnDay) > p.runsPerDay {
+			p.runsPerDay = len(slotsOnDay)
+			p.current = slices.Compact(slices.Sorted(slices.Values(slotsOnDay)))
+		}
+	}
+
+	if len(active) < len(weekOrder) {
+		for _, weekday := range weekOrder {
+			if active[weekday] {
+				p.weekdays = append(p.weekdays, strings.ToLower(weekday.String()[:3]))
+			}
+		}
+	}
+
+	return p, nil
+}
+
+// slot maps the local time of day of a run onto the slots of day start:
+// the time is read in the source's time zone on that date and measured from
+// start. suggested reverses it.
+func (p *planned) slot(local, start time.Time, bucket time.Duration) int {
+	at := time.Date(start.Year(), start.Month(), start.Day(), local.Hour(), local.Minute(), 0, 0, p.loc)
+	offset := at.Sub(start) % day
+	if offset < 0 {
+		offset += day
+	}
+	return int(offset / bucket)
+}
+
+// place picks runsPerDay evenly spaced slots with the least load, counting a
+// slot shared with the same host hostPenalty times. Ties go to the offset
+// closest to the source's current first run, to keep changes small.
+func place(p *planned, load, hostLoad []int, hostPenalty int) []int {
+	slots := len(load)
+	spacing := (slots + p.runsPerDay - 1) / p.runsPerDay
+
+	var best []int
+	bestCost, bestDistance := -1, 0
+	for offset := range spacing {
+		candidate := make([]int, p.runsPerDay)
+		cost := 0
+		for i := range candidate {
+			slot := (offset + i*slots/p.runsPerDay) % slots
+			candidate[i] = slot
+			cost += load[slot] + hostPenalty*hostLoad[slot]
+		}
+		slices.Sort(candidate)
+
+		distance := abs(offset - p.current[0]%spacing)
+		if bestCost < 0 || cost < bestCost || (cost == bestCost && distance < bestDistance) {
+			best, bestCost, bestDistance = candidate, cost, distance
+		}
+	}
+
+	return best
+}
+
+// suggested turns slots on day start into a times schedule in the source's
+// time zone, keeping its weekdays. Each slot is read in the same location
+// slot measured it in, so unchanged runs keep their local times.
+func (p *planned) suggested(slots []int, start time.Time, bucket time.Duration) *models.Schedule {
+	times := make([]string, 0, len(slots))
+	for _, slot := range slots {
+		times = append(times, start.Add(time.Duration(slot)*bucket).In(p.loc).Format("15:04"))
+	}
+	slices.Sort(times)
+
+	return &models.Schedule{
+		Times:    slices.Compact(times),
+		Weekdays: p.weekdays,
+		Timezone: p.schedule.Timezone,
+	}
+}
+
+func abs(n int) int {
+	if n < 0 {
+		return -n
+	}
+	return n
+}
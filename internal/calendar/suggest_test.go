@@ -0,0 +1,102 @@
+package calendar
+
+import (
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/jonesrussell/gosources/internal/models"
+)
+
+func TestSuggest(t *testing.T) {
+	source := func(id, url string, schedule models.Schedule) models.Source {
+		return models.Source{ID: id, Name: id, URL: url, Enabled: true, Schedule: &schedule}
+	}
+
+	tests := []struct {
+		name       string
+		sources    []models.Source
+		runsPerDay map[string]int
+		want       map[string]*models.Schedule // nil keeps the current schedule
+	}{
+		{
+			name: "evenly spread times are kept",
+			sources: []models.Source{
+				source("a", "https://a.example", models.Schedule{Times: []string{"00:00", "12:00"}}),
+			},
+			runsPerDay: map[string]int{"a": 2},
+			want:       map[string]*models.Schedule{"a": nil},
+		},
+		{
+			name: "local times across UTC midnight are kept",
+			sources: []models.Source{
+				source("a", "https://a.example", models.Schedule{
+					Times: []string{"07:00", "19:00"}, Weekdays: []string{"mon"}, Timezone: "America/Toronto",
+				}),
+				source("b", "https://b.example", models.Schedule{Times: []string{"06:00"}, Timezone: "Asia/Tokyo"}),
+			},
+			runsPerDay: map[string]int{"a": 2, "b": 1},
+			want:       map[string]*models.Schedule{"a": nil, "b": nil},
+		},
+		{
+			name: "runs on one local day that span two UTC days are counted together",
+			sources: []models.Source{
+				source("a", "https://a.example", models.Schedule{
+					Times: []string{"18:00", "21:00"}, Weekdays: []string{"mon"}, Timezone: "America/Toronto",
+				}),
+			},
+			runsPerDay: map[string]int{"a": 2},
+			want: map[string]*models.Schedule{"a": {
+				Times: []string{"09:00", "21:00"}, Weekdays: []string{"mon"}, Timezone: "America/Toronto",
+			}},
+		},
+		{
+			name: "runs on the same host are moved apart",
+			sources: []models.Source{
+				source("a", "https://www.example.com/a", models.Schedule{Times: []string{"06:00"}}),
+				source("b", "https://example.com/b", models.Schedule{Times: []string{"06:00"}}),
+			},
+			runsPerDay: map[string]int{"a": 1, "b": 1},
+			want: map[string]*models.Schedule{
+				"a": nil,
+				"b": {Times: []string{"05:00"}},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := Suggest(tt.sources, SuggestOptions{
+				Day:    time.Date(2026, time.January, 5, 15, 0, 0, 0, time.UTC),
+				Bucket: time.Hour,
+			})
+			if len(result.Skipped) > 0 {
+				t.Fatalf("Skipped = %+v", result.Skipped)
+			}
+			if len(result.Sources) != len(tt.want) {
+				t.Fatalf("got %d suggestions, want %d", len(result.Sources), len(tt.want))
+			}
+
+			for _, got := range result.Sources {
+				if got.RunsPerDay != tt.runsPerDay[got.SourceID] {
+					t.Errorf("%s: RunsPerDay = %d, want %d", got.SourceID, got.RunsPerDay, tt.runsPerDay[got.SourceID])
+				}
+				want := tt.want[got.SourceID]
+				switch {
+				case want == nil && got.Suggested != nil:
+					t.Errorf("%s: Suggested = %+v, want the current schedule kept", got.SourceID, got.Suggested)
+				case want != nil && got.Suggested == nil:
+					t.Errorf("%s: Suggested = nil, want %+v", got.SourceID, want)
+				case want != nil && !sameSchedule(got.Suggested, want):
+					t.Errorf("%s: Suggested = %+v, want %+v", got.SourceID, got.Suggested, want)
+				}
+			}
+		})
+	}
+}
+
+func sameSchedule(a, b *models.Schedule) bool {
+	return slices.Equal(a.Times, b.Times) &&
+		slices.Equal(a.Weekdays, b.Weekdays) &&
+		a.Timezone == b.Timezone
+}
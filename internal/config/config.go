@@ -18,12 +18,33 @@ const (
 	defaultMaxOpenConns    = 25
 	defaultMaxIdleConns    = 5
 	defaultConnMaxLifetime = 5
+	defaultSQLitePath      = "gosources.db"
+	defaultSyncInterval    = 5
+	defaultSampleRatio     = 1.0
+	defaultDrainDelay      = 5
+)
+
+// Supported values for database.driver.
+const (
+	DriverPostgres = "postgres"
+	DriverSQLite   = "sqlite"
+	DriverMemory   = "memory"
+)
+
+// Supported values for tracing.exporter.
+const (
+	ExporterNone   = "none"
+	ExporterOTLP   = "otlp"
+	ExporterStdout = "stdout"
 )
 
 type Config struct {
 	Debug    bool           `yaml:"debug"`
 	Server   ServerConfig   `yaml:"server"`
 	Database DatabaseConfig `yaml:"database"`
+	Sync     SyncConfig     `yaml:"sync"`
+	Auth     AuthConfig     `yaml:"auth"`
+	Tracing  TracingConfig  `yaml:"tracing"`
 }
 
 type ServerConfig struct {
@@ -31,9 +52,16 @@ type ServerConfig struct {
 	Port         int           `yaml:"port"`
 	ReadTimeout  time.Duration `yaml:"read_timeout"`
 	WriteTimeout time.Duration `yaml:"write_timeout"`
+
+	// DrainDelay is how long /readyz reports draining before the server
+	// stops accepting connections on shutdown, so load balancers can move
+	// traffic elsewhere first.
+	DrainDelay time.Duration `yaml:"drain_delay"`
 }
 
 type DatabaseConfig struct {
+	Driver          string        `yaml:"driver"`
+	Path            string        `yaml:"path"` // SQLite database file
 	Host            string        `yaml:"host"`
 	Port            int           `yaml:"port"`
 	User            string        `yaml:"user"`
@@ -43,6 +71,30 @@ type DatabaseConfig struct {
 	MaxOpenConns    int           `yaml:"max_open_conns"`
 	MaxIdleConns    int           `yaml:"max_idle_conns"`
 	ConnMaxLifetime time.Duration `yaml:"conn_max_lifetime"`
+	AutoMigrate     bool          `yaml:"auto_migrate"`
+}
+
+// SyncConfig enables the background reconciler that keeps the database in
+// line with a directory of source files. It is off when Dir is empty.
+type SyncConfig struct {
+	Dir      string        `yaml:"dir"`
+	Interval time.Duration `yaml:"interval"`
+	Prune    bool          `yaml:"prune"` // Delete sources that have no file
+}
+
+// AuthConfig controls API key authentication. It is enabled unless turned
+// off explicitly.
+type AuthConfig struct {
+	Enabled bool `yaml:"enabled"`
+}
+
+// TracingConfig selects where OpenTelemetry spans are sent. Endpoint is an
+// OTLP/HTTP URL such as http://localhost:4318; when empty the exporter falls
+// back to OTEL_EXPORTER_OTLP_ENDPOINT and then to its default.
+type TracingConfig struct {
+	Exporter    string  `yaml:"exporter"`
+	Endpoint    string  `yaml:"endpoint"`
+	SampleRatio float64 `yaml:"sample_ratio"` // Fraction of new traces recorded
 }
 
 func (c *Config) Validate() error {
@@ -52,16 +104,45 @@ func (c *Config) Validate() error {
 	if c.Server.Port <= 0 {
 		return errors.New("server.port is required and must be positive")
 	}
-	if c.Database.Host == "" {
+	if c.Server.DrainDelay < 0 {
+		return errors.New("server.drain_delay must not be negative")
+	}
+	if c.Sync.Dir != "" && c.Sync.Interval <= 0 {
+		return errors.New("sync.interval must be positive")
+	}
+	switch c.Tracing.Exporter {
+	case ExporterNone, ExporterOTLP, ExporterStdout:
+	default:
+		return fmt.Errorf("tracing.exporter %q is not supported", c.Tracing.Exporter)
+	}
+	if c.Tracing.SampleRatio < 0 || c.Tracing.SampleRatio > 1 {
+		return errors.New("tracing.sample_ratio must be between 0 and 1")
+	}
+	switch c.Database.Driver {
+	case DriverPostgres:
+		return c.Database.validatePostgres()
+	case DriverSQLite:
+		if c.Database.Path == "" {
+			return errors.New("database.path is required for the sqlite driver")
+		}
+	case DriverMemory:
+	default:
+		return fmt.Errorf("database.driver %q is not supported", c.Database.Driver)
+	}
+	return nil
+}
+
+func (d *DatabaseConfig) validatePostgres() error {
+	if d.Host == "" {
 		return errors.New("database.host is required")
 	}
-	if c.Database.Port <= 0 {
+	if d.Port <= 0 {
 		return errors.New("database.port is required and must be positive")
 	}
-	if c.Database.User == "" {
+	if d.User == "" {
 		return errors.New("database.user is required")
 	}
-	if c.Database.DBName == "" {
+	if d.DBName == "" {
 		return errors.New("database.dbname is required")
 	}
 	return nil
@@ -73,7 +154,13 @@ func Load(path string) (*Config, error) {
 		return nil, fmt.Errorf("read config file: %w", err)
 	}
 
-	var cfg Config
+	// Defaults that an explicit false or zero in the file must be able to
+	// override are set before parsing.
+	cfg := Config{
+		Server:  ServerConfig{DrainDelay: defaultDrainDelay * time.Second},
+		Auth:    AuthConfig{Enabled: true},
+		Tracing: TracingConfig{SampleRatio: defaultSampleRatio},
+	}
 	if err := yaml.Unmarshal(data, &cfg); err != nil {
 		return nil, fmt.Errorf("parse config: %w", err)
 	}
@@ -91,6 +178,12 @@ func Load(path string) (*Config, error) {
 	if cfg.Server.WriteTimeout == 0 {
 		cfg.Server.WriteTimeout = defaultServerTimeout * time.Second
 	}
+	if cfg.Database.Driver == "" {
+		cfg.Database.Driver = DriverPostgres
+	}
+	if cfg.Database.Path == "" {
+		cfg.Database.Path = defaultSQLitePath
+	}
 	if cfg.Database.Port == 0 {
 		cfg.Database.Port = defaultDatabasePort
 	}
@@ -106,6 +199,12 @@ func Load(path string) (*Config, error) {
 	if cfg.Database.ConnMaxLifetime == 0 {
 		cfg.Database.ConnMaxLifetime = defaultConnMaxLifetime * time.Minute
 	}
+	if cfg.Sync.Interval == 0 {
+		cfg.Sync.Interval = defaultSyncInterval * time.Minute
+	}
+	if cfg.Tracing.Exporter == "" {
+		cfg.Tracing.Exporter = ExporterNone
+	}
 
 	// Override with environment variables
 	overrideFromEnv(&cfg)
@@ -119,6 +218,12 @@ func Load(path string) (*Config, error) {
 }
 
 func overrideFromEnv(cfg *Config) {
+	if dbDriver := os.Getenv("DB_DRIVER"); dbDriver != "" {
+		cfg.Database.Driver = dbDriver
+	}
+	if dbPath := os.Getenv("DB_PATH"); dbPath != "" {
+		cfg.Database.Path = dbPath
+	}
 	if dbHost := os.Getenv("DB_HOST"); dbHost != "" {
 		cfg.Database.Host = dbHost
 	}
@@ -139,6 +244,29 @@ func overrideFromEnv(cfg *Config) {
 	if dbSSLMode := os.Getenv("DB_SSLMODE"); dbSSLMode != "" {
 		cfg.Database.SSLMode = dbSSLMode
 	}
+	if autoMigrate := os.Getenv("DB_AUTO_MIGRATE"); autoMigrate != "" {
+		cfg.Database.AutoMigrate = parseBool(autoMigrate)
+	}
+	if syncDir := os.Getenv("SYNC_DIR"); syncDir != "" {
+		cfg.Sync.Dir = syncDir
+	}
+	if syncInterval := os.Getenv("SYNC_INTERVAL"); syncInterval != "" {
+		if interval, err := time.ParseDuration(syncInterval); err == nil {
+			cfg.Sync.Interval = interval
+		}
+	}
+	if syncPrune := os.Getenv("SYNC_PRUNE"); syncPrune != "" {
+		cfg.Sync.Prune = parseBool(syncPrune)
+	}
+	if authEnabled := os.Getenv("AUTH_ENABLED"); authEnabled != "" {
+		cfg.Auth.Enabled = parseBool(authEnabled)
+	}
+	if tracingExporter := os.Getenv("TRACING_EXPORTER"); tracingExporter != "" {
+		cfg.Tracing.Exporter = tracingExporter
+	}
+	if tracingEndpoint := os.Getenv("TRACING_ENDPOINT"); tracingEndpoint != "" {
+		cfg.Tracing.Endpoint = tracingEndpoint
+	}
 	if serverHost := os.Getenv("SERVER_HOST"); serverHost != "" {
 		cfg.Server.Host = serverHost
 	}
@@ -147,6 +275,11 @@ func overrideFromEnv(cfg *Config) {
 			cfg.Server.Port = port
 		}
 	}
+	if drainDelay := os.Getenv("SERVER_DRAIN_DELAY"); drainDelay != "" {
+		if delay, err := time.ParseDuration(drainDelay); err == nil {
+			cfg.Server.DrainDelay = delay
+		}
+	}
 	if appDebug := os.Getenv("APP_DEBUG"); appDebug != "" {
 		cfg.Debug = parseBool(appDebug)
 	}
@@ -0,0 +1,97 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestLoadExplicitZeroValues(t *testing.T) {
+	// Keep the environment from overriding the file.
+	t.Setenv("AUTH_ENABLED", "")
+	t.Setenv("SERVER_DRAIN_DELAY", "")
+
+	tests := []struct {
+		name   string
+		yaml   string
+		check  func(*Config) bool
+		expect string
+	}{
+		{
+			name:   "sample ratio defaults to 1",
+			yaml:   "database: {driver: memory}\n",
+			check:  func(c *Config) bool { return c.Tracing.SampleRatio == 1 },
+			expect: "tracing.sample_ratio = 1",
+		},
+		{
+			name:   "sample ratio 0 is kept",
+			yaml:   "database: {driver: memory}\ntracing: {sample_ratio: 0}\n",
+			check:  func(c *Config) bool { return c.Tracing.SampleRatio == 0 },
+			expect: "tracing.sample_ratio = 0",
+		},
+		{
+			name:   "sample ratio is read",
+			yaml:   "database: {driver: memory}\ntracing: {sample_ratio: 0.25}\n",
+			check:  func(c *Config) bool { return c.Tracing.SampleRatio == 0.25 },
+			expect: "tracing.sample_ratio = 0.25",
+		},
+		{
+			name:   "auth defaults to enabled",
+			yaml:   "database: {driver: memory}\n",
+			check:  func(c *Config) bool { return c.Auth.Enabled },
+			expect: "auth.enabled = true",
+		},
+		{
+			name:   "auth can be disabled",
+			yaml:   "database: {driver: memory}\nauth: {enabled: false}\n",
+			check:  func(c *Config) bool { return !c.Auth.Enabled },
+			expect: "auth.enabled = false",
+		},
+		{
+			name:   "drain delay 0 is kept",
+			yaml:   "database: {driver: memory}\nserver: {drain_delay: 0s}\n",
+			check:  func(c *Config) bool { return c.Server.DrainDelay == 0 },
+			expect: "server.drain_delay = 0",
+		},
+		{
+			name:   "drain delay defaults to 5s",
+			yaml:   "database: {driver: memory}\n",
+			check:  func(c *Config) bool { return c.Server.DrainDelay == 5*time.Second },
+			expect: "server.drain_delay = 5s",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "config.yml")
+			if err := os.WriteFile(path, []byte(tt.yaml), 0o600); err != nil {
+				t.Fatal(err)
+			}
+
+			cfg, err := Load(path)
+			if err != nil {
+				t.Fatalf("Load() error = %v", err)
+			}
+			if !tt.check(cfg) {
+				t.Errorf("want %s, got %+v", tt.expect, cfg)
+			}
+		})
+	}
+}
+
+func TestLoadRejectsSampleRatioOutOfRange(t *testing.T) {
+	for _, ratio := range []string{"-0.1", "1.5"} {
+		t.Run(ratio, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "config.yml")
+			data := "database: {driver: memory}\ntracing: {sample_ratio: " + ratio + "}\n"
+			if err := os.WriteFile(path, []byte(data), 0o600); err != nil {
+				t.Fatal(err)
+			}
+
+			if _, err := Load(path); err == nil {
+				t.Errorf("Load() accepted sample_ratio %s", ratio)
+			}
+		})
+	}
+}
@@ -12,15 +12,86 @@ import (
 )
 
 const (
-	defaultPingTimeout = 5
+	defaultPingTimeout    = 5
+	defaultMigrateTimeout = 60
 )
 
 type DB struct {
 	db     *sql.DB
+	driver string
+	dsn    string
 	logger logger.Logger
 }
 
+// New opens the database selected by cfg.Database.Driver. The memory driver
+// has no database and is rejected here; callers select an in-memory store
+// instead.
+//
+// The schema version is checked on every start and New fails with
+// ErrSchemaTooNew when the database is ahead of the binary. Pending
+// migrations are applied when database.auto_migrate is set; SQLite
+// databases are always migrated since nothing else manages them.
 func New(cfg *config.Config, log logger.Logger) (*DB, error) {
+	db, err := Open(cfg, log)
+	if err != nil {
+		return nil, err
+	}
+
+	autoMigrate := cfg.Database.AutoMigrate || cfg.Database.Driver == config.DriverSQLite
+	if migrateErr := db.prepareSchema(autoMigrate); migrateErr != nil {
+		_ = db.Close()
+		return nil, migrateErr
+	}
+
+	return db, nil
+}
+
+// Open connects to the configured database without checking or migrating
+// the schema. It is used by the migrate command.
+func Open(cfg *config.Config, log logger.Logger) (*DB, error) {
+	switch cfg.Database.Driver {
+	case config.DriverPostgres:
+		return newPostgres(cfg, log)
+	case config.DriverSQLite:
+		return newSQLite(cfg, log)
+	default:
+		return nil, fmt.Errorf("database driver %q has no SQL database", cfg.Database.Driver)
+	}
+}
+
+func (d *DB) prepareSchema(autoMigrate bool) error {
+	ctx, cancel := context.WithTimeout(context.Background(), defaultMigrateTimeout*time.Second)
+	defer cancel()
+
+	migrator, err := NewMigrator(d)
+	if err != nil {
+		return err
+	}
+
+	version, err := migrator.Check(ctx)
+	if err != nil {
+		return err
+	}
+
+	if autoMigrate {
+		if version, err = migrator.Up(ctx); err != nil {
+			return fmt.Errorf("migrate database: %w", err)
+		}
+	} else if version < migrator.Latest() {
+		d.logger.Warn("Database schema is behind; run migrations or enable database.auto_migrate",
+			logger.Int("schema_version", version),
+			logger.Int("latest_version", migrator.Latest()),
+		)
+	}
+
+	d.logger.Info("Database schema ready",
+		logger.Int("schema_version", version),
+	)
+
+	return nil
+}
+
+func newPostgres(cfg *config.Config, log logger.Logger) (*DB, error) {
 	dsn := fmt.Sprintf(
 		"host=%s port=%d user=%s password=%s dbname=%s sslmode=%s",
 		cfg.Database.Host,
@@ -45,6 +116,7 @@ func New(cfg *config.Config, log logger.Logger) (*DB, error) {
 	defer cancel()
 
 	if pingErr := db.PingContext(ctx); pingErr != nil {
+		_ = db.Close()
 		return nil, fmt.Errorf("ping database: %w", pingErr)
 	}
 
@@ -56,6 +128,8 @@ func New(cfg *config.Config, log logger.Logger) (*DB, error) {
 
 	return &DB{
 		db:     db,
+		driver: config.DriverPostgres,
+		dsn:    dsn,
 		logger: log,
 	}, nil
 }
@@ -67,6 +141,19 @@ func (d *DB) Close() error {
 	return nil
 }
 
+// Ping checks that a connection to the database can be used.
+func (d *DB) Ping(ctx context.Context) error {
+	if err := d.db.PingContext(ctx); err != nil {
+		return fmt.Errorf("ping database: %w", err)
+	}
+	return nil
+}
+
 func (d *DB) DB() *sql.DB {
 	return d.db
 }
+
+// Driver returns the configured driver name, e.g. config.DriverPostgres.
+func (d *DB) Driver() string {
+	return d.driver
+}
@@ -0,0 +1,73 @@
+package database
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/jonesrussell/gosources/internal/config"
+	"github.com/jonesrussell/gosources/internal/logger"
+	"github.com/lib/pq"
+)
+
+const (
+	listenMinReconnect = time.Second
+	listenMaxReconnect = time.Minute
+	listenPingInterval = 90 * time.Second
+)
+
+// Listen subscribes to a PostgreSQL NOTIFY channel on a dedicated connection
+// and calls notify for every notification until ctx is done. notify is also
+// called after the connection is re-established, since notifications sent
+// while it was down are lost.
+func (d *DB) Listen(ctx context.Context, channel string, notify func()) error {
+	if d.driver != config.DriverPostgres {
+		return fmt.Errorf("database driver %q does not support LISTEN", d.driver)
+	}
+
+	listener := pq.NewListener(d.dsn, listenMinReconnect, listenMaxReconnect,
+		func(event pq.ListenerEventType, err error) {
+			switch event {
+			case pq.ListenerEventDisconnected:
+				d.logger.Warn("Database listener disconnected",
+					logger.String("channel", channel),
+					logger.Error(err),
+				)
+			case pq.ListenerEventConnectionAttemptFailed:
+				d.logger.Warn("Database listener failed to reconnect",
+					logger.String("channel", channel),
+					logger.Error(err),
+				)
+			case pq.ListenerEventReconnected:
+				d.logger.Info("Database listener reconnected",
+					logger.String("channel", channel),
+				)
+			case pq.ListenerEventConnected:
+			}
+		},
+	)
+	defer func() {
+		_ = listener.Close()
+	}()
+
+	if err := listener.Listen(channel); err != nil {
+		return fmt.Errorf("listen %s: %w", channel, err)
+	}
+
+	ping := time.NewTicker(listenPingInterval)
+	defer ping.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-listener.Notify:
+			// A nil notification follows a reconnect.
+			notify()
+		case <-ping.C:
+			go func() {
+				_ = listener.Ping()
+			}()
+		}
+	}
+}
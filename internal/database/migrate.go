@@ -0,0 +1,334 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"io/fs"
+	"path"
+	"regexp"
+	"slices"
+	"strconv"
+	"time"
+
+	"github.com/jonesrussell/gosources/internal/config"
+	"github.com/jonesrussell/gosources/internal/logger"
+	"github.com/jonesrussell/gosources/migrations"
+)
+
+// migrationLockID is the pg_advisory_lock key that serialises migration runs
+// across replicas starting at the same time.
+const migrationLockID = 7263540118
+
+var (
+	// ErrSchemaTooNew is returned when the database has migrations applied
+	// that this binary does not know about.
+	ErrSchemaTooNew = errors.New("database schema is newer than this binary")
+
+	// ErrSchemaBehind is returned when the database is missing migrations
+	// this binary expects.
+	ErrSchemaBehind = errors.New("database schema is older than this binary")
+
+	// ErrIrreversible is returned when rolling back a migration that has no
+	// down script.
+	ErrIrreversible = errors.New("migration has no down script")
+
+	migrationFileRe = regexp.MustCompile(`^(\d+)_(\w+)\.(up|down)\.sql$`)
+)
+
+// Migration is a single versioned schema change.
+type Migration struct {
+	Version int
+	Name    string
+	Up      string
+	Down    string
+}
+
+// Migrator applies the embedded migrations for a database driver and records
+// progress in the schema_migrations table.
+type Migrator struct {
+	db         *sql.DB
+	driver     string
+	migrations []Migration
+	logger     logger.Logger
+}
+
+func NewMigrator(db *DB) (*Migrator, error) {
+	loaded, err := loadMigrations(migrations.FS, db.driver)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Migrator{
+		db:         db.db,
+		driver:     db.driver,
+		migrations: loaded,
+		logger:     db.logger,
+	}, nil
+}
+
+func loadMigrations(fsys fs.FS, dir string) ([]Migration, error) {
+	entries, err := fs.ReadDir(fsys, dir)
+	if err != nil {
+		return nil, fmt.Errorf("read migrations: %w", err)
+	}
+
+	byVersion := make(map[int]*Migration)
+	for _, entry := range entries {
+		match := migrationFileRe.FindStringSubmatch(entry.Name())
+		if match == nil {
+			continue
+		}
+
+		version, _ := strconv.Atoi(match[1])
+		data, readErr := fs.ReadFile(fsys, path.Join(dir, entry.Name()))
+		if readErr != nil {
+			return nil, fmt.Errorf("read migration %s: %w", entry.Name(), readErr)
+		}
+
+		m, ok := byVersion[version]
+		if !ok {
+			m = &Migration{Version: version, Name: match[2]}
+			byVersion[version] = m
+		}
+		if m.Name != match[2] {
+			return nil, fmt.Errorf("migration %d has conflicting names %q and %q", version, m.Name, match[2])
+		}
+
+		if match[3] == "up" {
+			m.Up = string(data)
+		} else {
+			m.Down = string(data)
+		}
+	}
+
+	loaded := make([]Migration, 0, len(byVersion))
+	for _, m := range byVersion {
+		if m.Up == "" {
+			return nil, fmt.Errorf("migration %d_%s has no up script", m.Version, m.Name)
+		}
+		loaded = append(loaded, *m)
+	}
+
+	slices.SortFunc(loaded, func(a, b Migration) int { return a.Version - b.Version })
+
+	for i, m := range loaded {
+		if m.Version != i+1 {
+			return nil, fmt.Errorf("migration versions must be contiguous from 1, found %d at position %d", m.Version, i+1)
+		}
+	}
+
+	return loaded, nil
+}
+
+// Latest returns the highest migration version embedded in the binary.
+func (m *Migrator) Latest() int {
+	if len(m.migrations) == 0 {
+		return 0
+	}
+	return m.migrations[len(m.migrations)-1].Version
+}
+
+// Version returns the schema version currently recorded in the database, or
+// 0 when no migration has run yet. It only reads, so it is safe to call from
+// readiness probes and against a read-only replica.
+func (m *Migrator) Version(ctx context.Context) (int, error) {
+	query := `SELECT COUNT(*) FROM sqlite_master WHERE type = 'table' AND name = 'schema_migrations'`
+	if m.driver == config.DriverPostgres {
+		query = `SELECT COUNT(*) FROM information_schema.tables
+			WHERE table_schema = current_schema() AND table_name = 'schema_migrations'`
+	}
+
+	var tables int
+	if err := m.db.QueryRowContext(ctx, query).Scan(&tables); err != nil {
+		return 0, fmt.Errorf("look up schema_migrations: %w", err)
+	}
+	if tables == 0 {
+		return 0, nil
+	}
+
+	return currentVersion(ctx, m.db)
+}
+
+// Check returns ErrSchemaTooNew when the database is ahead of the binary.
+func (m *Migrator) Check(ctx context.Context) (int, error) {
+	version, err := m.Version(ctx)
+	if err != nil {
+		return 0, err
+	}
+	if version > m.Latest() {
+		return version, fmt.Errorf("%w: database at %d, binary supports %d", ErrSchemaTooNew, version, m.Latest())
+	}
+	return version, nil
+}
+
+// CheckCurrent returns ErrSchemaBehind or ErrSchemaTooNew unless the database
+// is at exactly the latest version. A database without schema_migrations is
+// behind.
+func (m *Migrator) CheckCurrent(ctx context.Context) error {
+	version, err := m.Check(ctx)
+	if err != nil {
+		return err
+	}
+	if version < m.Latest() {
+		return fmt.Errorf("%w: database at %d, binary expects %d", ErrSchemaBehind, version, m.Latest())
+	}
+	return nil
+}
+
+// Up applies every pending migration and returns the resulting version.
+func (m *Migrator) Up(ctx context.Context) (int, error) {
+	var version int
+
+	err := m.withLock(ctx, func(conn *sql.Conn) error {
+		var err error
+		version, err = currentVersion(ctx, conn)
+		if err != nil {
+			return err
+		}
+		if version > m.Latest() {
+			return fmt.Errorf("%w: database at %d, binary supports %d", ErrSchemaTooNew, version, m.Latest())
+		}
+
+		for _, migration := range m.migrations[version:] {
+			if applyErr := m.apply(ctx, conn, migration, true); applyErr != nil {
+				return applyErr
+			}
+			version = migration.Version
+		}
+		return nil
+	})
+
+	return version, err
+}
+
+// Down rolls back the given number of migrations and returns the resulting
+// version.
+func (m *Migrator) Down(ctx context.Context, steps int) (int, error) {
+	var version int
+
+	err := m.withLock(ctx, func(conn *sql.Conn) error {
+		var err error
+		version, err = currentVersion(ctx, conn)
+		if err != nil {
+			return err
+		}
+		if version > m.Latest() {
+			return fmt.Errorf("%w: database at %d, binary supports %d", ErrSchemaTooNew, version, m.Latest())
+		}
+
+		for ; steps > 0 && version > 0; steps-- {
+			migration := m.migrations[version-1]
+			if migration.Down == "" {
+				return fmt.Errorf("%w: %d_%s", ErrIrreversible, migration.Version, migration.Name)
+			}
+			if applyErr := m.apply(ctx, conn, migration, false); applyErr != nil {
+				return applyErr
+			}
+			version--
+		}
+		return nil
+	})
+
+	return version, err
+}
+
+func (m *Migrator) apply(ctx context.Context, conn *sql.Conn, migration Migration, up bool) error {
+	start := time.Now()
+
+	tx, err := conn.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("begin migration %d: %w", migration.Version, err)
+	}
+	defer func() {
+		_ = tx.Rollback()
+	}()
+
+	script, direction := migration.Up, "up"
+	if !up {
+		script, direction = migration.Down, "down"
+	}
+
+	if _, execErr := tx.ExecContext(ctx, script); execErr != nil {
+		return fmt.Errorf("migration %d_%s %s: %w", migration.Version, migration.Name, direction, execErr)
+	}
+
+	if up {
+		_, err = tx.ExecContext(ctx,
+			`INSERT INTO schema_migrations (version, name, applied_at) VALUES ($1, $2, $3)`,
+			migration.Version, migration.Name, time.Now().UTC(),
+		)
+	} else {
+		_, err = tx.ExecContext(ctx, `DELETE FROM schema_migrations WHERE version = $1`, migration.Version)
+	}
+	if err != nil {
+		return fmt.Errorf("record migration %d: %w", migration.Version, err)
+	}
+
+	if commitErr := tx.Commit(); commitErr != nil {
+		return fmt.Errorf("commit migration %d: %w", migration.Version, commitErr)
+	}
+
+	m.logger.Info("Applied migration",
+		logger.Int("schema_version", migration.Version),
+		logger.String("migration", migration.Name),
+		logger.String("direction", direction),
+		logger.Duration("duration", time.Since(start)),
+	)
+
+	return nil
+}
+
+// withLock runs fn on a dedicated connection holding the migration lock.
+func (m *Migrator) withLock(ctx context.Context, fn func(conn *sql.Conn) error) error {
+	conn, err := m.db.Conn(ctx)
+	if err != nil {
+		return fmt.Errorf("get connection: %w", err)
+	}
+	defer conn.Close()
+
+	// SQLite runs on a single connection, so holding it is already exclusive.
+	if m.driver == config.DriverPostgres {
+		if _, lockErr := conn.ExecContext(ctx, `SELECT pg_advisory_lock($1)`, migrationLockID); lockErr != nil {
+			return fmt.Errorf("acquire migration lock: %w", lockErr)
+		}
+		defer func() {
+			_, _ = conn.ExecContext(context.WithoutCancel(ctx), `SELECT pg_advisory_unlock($1)`, migrationLockID)
+		}()
+	}
+
+	if ensureErr := m.ensureTable(ctx, conn); ensureErr != nil {
+		return ensureErr
+	}
+
+	return fn(conn)
+}
+
+func (m *Migrator) ensureTable(ctx context.Context, conn *sql.Conn) error {
+	query := `
+		CREATE TABLE IF NOT EXISTS schema_migrations (
+			version INTEGER PRIMARY KEY,
+			name VARCHAR(255) NOT NULL,
+			applied_at TIMESTAMP NOT NULL
+		)
+	`
+	if _, err := conn.ExecContext(ctx, query); err != nil {
+		return fmt.Errorf("create schema_migrations: %w", err)
+	}
+	return nil
+}
+
+// rowQueryer is satisfied by *sql.DB and *sql.Conn.
+type rowQueryer interface {
+	QueryRowContext(ctx context.Context, query string, args ...any) *sql.Row
+}
+
+func currentVersion(ctx context.Context, q rowQueryer) (int, error) {
+	var version int
+	query := `SELECT COALESCE(MAX(version), 0) FROM schema_migrations`
+	if err := q.QueryRowContext(ctx, query).Scan(&version); err != nil {
+		return 0, fmt.Errorf("query schema version: %w", err)
+	}
+	return version, nil
+}
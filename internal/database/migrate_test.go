@@ -0,0 +1,150 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"testing"
+
+	"github.com/jonesrussell/gosources/internal/config"
+	"github.com/jonesrussell/gosources/internal/logger"
+)
+
+func openSQLite(t *testing.T) (*DB, *Migrator) {
+	t.Helper()
+
+	cfg := &config.Config{Database: config.DatabaseConfig{
+		Driver: config.DriverSQLite,
+		Path:   filepath.Join(t.TempDir(), "migrate.db"),
+	}}
+	db, err := Open(cfg, logger.NewNopLogger())
+	if err != nil {
+		t.Fatalf("Open() error = %v", err)
+	}
+	t.Cleanup(func() { _ = db.Close() })
+
+	migrator, err := NewMigrator(db)
+	if err != nil {
+		t.Fatalf("NewMigrator() error = %v", err)
+	}
+	return db, migrator
+}
+
+func TestCheckCurrentIsReadOnly(t *testing.T) {
+	db, migrator := openSQLite(t)
+	ctx := context.Background()
+
+	if err := migrator.CheckCurrent(ctx); !errors.Is(err, ErrSchemaBehind) {
+		t.Fatalf("CheckCurrent() on an empty database error = %v, want ErrSchemaBehind", err)
+	}
+
+	var tables int
+	err := db.DB().QueryRowContext(ctx, `SELECT COUNT(*) FROM sqlite_master WHERE type = 'table'`).Scan(&tables)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if tables != 0 {
+		t.Errorf("CheckCurrent() created %d tables", tables)
+	}
+
+	if _, err := migrator.Up(ctx); err != nil {
+		t.Fatalf("Up() error = %v", err)
+	}
+	if err := migrator.CheckCurrent(ctx); err != nil {
+		t.Errorf("CheckCurrent() after Up error = %v", err)
+	}
+
+	if _, err := migrator.Down(ctx, 1); err != nil {
+		t.Fatalf("Down() error = %v", err)
+	}
+	if err := migrator.CheckCurrent(ctx); !errors.Is(err, ErrSchemaBehind) {
+		t.Errorf("CheckCurrent() one step behind error = %v, want ErrSchemaBehind", err)
+	}
+}
+
+// TestMigrationsRoundTrip applies every migration, rolls each one back and
+// applies them again, checking the version at every step.
+func TestMigrationsRoundTrip(t *testing.T) {
+	_, migrator := openSQLite(t)
+	ctx := context.Background()
+	latest := migrator.Latest()
+
+	version, err := migrator.Up(ctx)
+	if err != nil {
+		t.Fatalf("Up() error = %v", err)
+	}
+	if version != latest {
+		t.Fatalf("Up() version = %d, want %d", version, latest)
+	}
+
+	for want := latest - 1; want >= 0; want-- {
+		version, err = migrator.Down(ctx, 1)
+		if err != nil {
+			t.Fatalf("Down() to %d error = %v", want, err)
+		}
+		if version != want {
+			t.Fatalf("Down() version = %d, want %d", version, want)
+		}
+		if got, versionErr := migrator.Version(ctx); versionErr != nil || got != want {
+			t.Fatalf("Version() = %d, %v; want %d", got, versionErr, want)
+		}
+	}
+
+	if version, err = migrator.Up(ctx); err != nil || version != latest {
+		t.Fatalf("Up() again = %d, %v; want %d", version, err, latest)
+	}
+}
+
+// TestSnapshotsIncludeSchedule checks that the change-log triggers capture
+// the schedule both after 008 adds it and after later migrations rebuild the
+// sources table.
+func TestSnapshotsIncludeSchedule(t *testing.T) {
+	db, migrator := openSQLite(t)
+	ctx := context.Background()
+	if _, err := migrator.Up(ctx); err != nil {
+		t.Fatalf("Up() error = %v", err)
+	}
+
+	const schedule = `{"interval":"6h"}`
+
+	for _, version := range []int{migrator.Latest(), 8} {
+		t.Run(fmt.Sprintf("version %d", version), func(t *testing.T) {
+			current, err := migrator.Version(ctx)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if current > version {
+				if _, err = migrator.Down(ctx, current-version); err != nil {
+					t.Fatalf("Down() error = %v", err)
+				}
+			}
+
+			id := fmt.Sprintf("source-%d", version)
+			_, err = db.DB().ExecContext(ctx, `INSERT INTO sources
+				(id, name, url, article_index, page_index, selectors, schedule)
+				VALUES ($1, $1, 'https://example.com', 'articles', 'pages', '{}', $2)`, id, schedule)
+			if err != nil {
+				t.Fatalf("insert: %v", err)
+			}
+			if _, err = db.DB().ExecContext(ctx, `UPDATE sources SET name = name || ' renamed' WHERE id = $1`, id); err != nil {
+				t.Fatalf("update: %v", err)
+			}
+
+			var created, updated, previous string
+			err = db.DB().QueryRowContext(ctx, `SELECT
+				(SELECT COALESCE(json_extract(source, '$.schedule'), 'null') FROM source_events WHERE source_id = $1 AND event = 'source.created'),
+				(SELECT COALESCE(json_extract(source, '$.schedule'), 'null') FROM source_events WHERE source_id = $1 AND event = 'source.updated'),
+				(SELECT COALESCE(json_extract(previous, '$.schedule'), 'null') FROM source_events WHERE source_id = $1 AND event = 'source.updated')`,
+				id).Scan(&created, &updated, &previous)
+			if err != nil {
+				t.Fatalf("read events: %v", err)
+			}
+			for name, got := range map[string]string{"created": created, "updated": updated, "previous": previous} {
+				if got != schedule {
+					t.Errorf("%s snapshot schedule = %s, want %s", name, got, schedule)
+				}
+			}
+		})
+	}
+}
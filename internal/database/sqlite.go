@@ -0,0 +1,47 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/jonesrussell/gosources/internal/config"
+	"github.com/jonesrussell/gosources/internal/logger"
+	_ "modernc.org/sqlite" //nolint:blankimports // SQLite driver
+)
+
+func newSQLite(cfg *config.Config, log logger.Logger) (*DB, error) {
+	dsn := fmt.Sprintf(
+		"file:%s?_pragma=foreign_keys(1)&_pragma=busy_timeout(5000)&_time_format=sqlite",
+		cfg.Database.Path,
+	)
+
+	db, err := sql.Open("sqlite", dsn)
+	if err != nil {
+		return nil, fmt.Errorf("open database: %w", err)
+	}
+
+	// SQLite allows a single writer; one connection avoids SQLITE_BUSY
+	// errors and keeps ":memory:" databases shared across queries.
+	db.SetMaxOpenConns(1)
+
+	ctx, cancel := context.WithTimeout(context.Background(), defaultPingTimeout*time.Second)
+	defer cancel()
+
+	if pingErr := db.PingContext(ctx); pingErr != nil {
+		_ = db.Close()
+		return nil, fmt.Errorf("ping database: %w", pingErr)
+	}
+
+	log.Info("Database connection established",
+		logger.String("driver", config.DriverSQLite),
+		logger.String("path", cfg.Database.Path),
+	)
+
+	return &DB{
+		db:     db,
+		driver: config.DriverSQLite,
+		logger: log,
+	}, nil
+}
@@ -0,0 +1,126 @@
+// Package events streams the source event log to subscribers such as the
+// Server-Sent Events handler.
+package events
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/jonesrussell/gosources/internal/logger"
+	"github.com/jonesrussell/gosources/internal/repository"
+)
+
+const (
+	// Channel is the PostgreSQL NOTIFY channel the source_events triggers
+	// signal on.
+	Channel = "source_events"
+
+	// Retention is how long events stay in the log for clients to resume.
+	Retention = 7 * 24 * time.Hour
+
+	pruneInterval = time.Hour
+)
+
+// Stream wakes subscribers when new events are in the log. Subscribers read
+// the events themselves, each from the last ID it sent, so a slow client never
+// holds up the others.
+type Stream struct {
+	eventLog    repository.EventLog
+	logger      logger.Logger
+	mu          sync.Mutex
+	subscribers map[chan struct{}]struct{}
+	done        chan struct{}
+}
+
+func NewStream(eventLog repository.EventLog, log logger.Logger) *Stream {
+	return &Stream{
+		eventLog:    eventLog,
+		logger:      log,
+		subscribers: make(map[chan struct{}]struct{}),
+		done:        make(chan struct{}),
+	}
+}
+
+// Done is closed when Run returns, so open streams can end before the server
+// shuts down.
+func (s *Stream) Done() <-chan struct{} {
+	return s.done
+}
+
+// Subscribe returns a channel that receives a value after new events arrive,
+// and a function that ends the subscription.
+func (s *Stream) Subscribe() (<-chan struct{}, func()) {
+	wake := make(chan struct{}, 1)
+
+	s.mu.Lock()
+	s.subscribers[wake] = struct{}{}
+	s.mu.Unlock()
+
+	return wake, func() {
+		s.mu.Lock()
+		delete(s.subscribers, wake)
+		s.mu.Unlock()
+	}
+}
+
+// Notify wakes every subscriber. It never blocks; a subscriber that has not
+// consumed its last wake-up still has one pending.
+func (s *Stream) Notify() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for wake := range s.subscribers {
+		select {
+		case wake <- struct{}{}:
+		default:
+		}
+	}
+}
+
+// Run polls the log every interval, notifying subscribers when its last ID
+// changes, and prunes events older than Retention. Polling is how changes are
+// seen without LISTEN/NOTIFY, and a safety net when a notification is lost.
+func (s *Stream) Run(ctx context.Context, interval time.Duration) {
+	defer close(s.done)
+
+	poll := time.NewTicker(interval)
+	defer poll.Stop()
+	prune := time.NewTicker(pruneInterval)
+	defer prune.Stop()
+
+	lastID, err := s.eventLog.LastID(ctx)
+	if err != nil {
+		s.logger.Error("Failed to read event log", logger.Error(err))
+	}
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-poll.C:
+			id, pollErr := s.eventLog.LastID(ctx)
+			if pollErr != nil {
+				s.logger.Error("Failed to read event log", logger.Error(pollErr))
+				continue
+			}
+			if id != lastID {
+				lastID = id
+				s.Notify()
+			}
+		case <-prune.C:
+			s.prune(ctx)
+		}
+	}
+}
+
+func (s *Stream) prune(ctx context.Context) {
+	deleted, err := s.eventLog.Prune(ctx, time.Now().UTC().Add(-Retention))
+	if err != nil {
+		s.logger.Error("Failed to prune event log", logger.Error(err))
+		return
+	}
+	if deleted > 0 {
+		s.logger.Info("Pruned event log", logger.Int64("deleted", deleted))
+	}
+}
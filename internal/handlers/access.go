@@ -0,0 +1,182 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/jonesrussell/gosources/internal/auth"
+	"github.com/jonesrussell/gosources/internal/models"
+	"github.com/jonesrussell/gosources/internal/repository"
+)
+
+// accessStore limits a SourceStore to the sources the request's caller may
+// see and edit. Sources the caller may not see are reported as not found;
+// changes to sources they may see but not edit fail with auth.ErrForbidden.
+// Requests without a user, such as those made with service keys, are not
+// limited.
+type accessStore struct {
+	repository.SourceStore
+}
+
+func (s accessStore) Create(ctx context.Context, source *models.Source) error {
+	if err := checkEdit(auth.PrincipalFromContext(ctx), source.CityName); err != nil {
+		return err
+	}
+	return s.SourceStore.Create(ctx, source)
+}
+
+func (s accessStore) GetByID(ctx context.Context, id string) (*models.Source, error) {
+	source, err := s.SourceStore.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if !auth.PrincipalFromContext(ctx).CanView(source.CityName) {
+		return nil, fmt.Errorf("source %s: %w", id, repository.ErrNotFound)
+	}
+	return source, nil
+}
+
+func (s accessStore) List(ctx context.Context, opts repository.ListOptions) (*repository.ListResult, error) {
+	if cities := auth.PrincipalFromContext(ctx).Cities(); cities != nil {
+		opts.Cities = cities
+	}
+	return s.SourceStore.List(ctx, opts)
+}
+
+// Update checks both the stored city and the new one, so a source cannot be
+// moved into or out of a city the caller does not edit.
+func (s accessStore) Update(ctx context.Context, source *models.Source) error {
+	current, err := s.GetByID(ctx, source.ID)
+	if err != nil {
+		return err
+	}
+
+	p := auth.PrincipalFromContext(ctx)
+	if editErr := checkEdit(p, current.CityName); editErr != nil {
+		return editErr
+	}
+	if editErr := checkEdit(p, source.CityName); editErr != nil {
+		return editErr
+	}
+
+	return s.SourceStore.Update(ctx, source)
+}
+
+func (s accessStore) Delete(ctx context.Context, id string) error {
+	current, err := s.GetByID(ctx, id)
+	if err != nil {
+		return err
+	}
+	if editErr := checkEdit(auth.PrincipalFromContext(ctx), current.CityName); editErr != nil {
+		return editErr
+	}
+
+	return s.SourceStore.Delete(ctx, id)
+}
+
+func (s accessStore) GetCities(ctx context.Context) ([]models.CityIndex, error) {
+	cities, err := s.SourceStore.GetCities(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	p := auth.PrincipalFromContext(ctx)
+	visible := make([]models.CityIndex, 0, len(cities))
+	for i := range cities {
+		if p.CanView(&cities[i].Name) {
+			visible = append(visible, cities[i])
+		}
+	}
+
+	return visible, nil
+}
+
+func (s accessStore) ListRevisions(ctx context.Context, sourceID string) ([]models.SourceRevision, error) {
+	revisions, err := s.SourceStore.ListRevisions(ctx, sourceID)
+	if err != nil {
+		return nil, err
+	}
+	if len(revisions) > 0 && !canViewHistory(ctx, &revisions[len(revisions)-1]) {
+		return nil, fmt.Errorf("source %s: %w", sourceID, repository.ErrNotFound)
+	}
+	return revisions, nil
+}
+
+func (s accessStore) GetRevision(ctx context.Context, sourceID string, revision int) (*models.SourceRevision, error) {
+	if _, err := s.ListRevisions(ctx, sourceID); err != nil {
+		return nil, err
+	}
+	return s.SourceStore.GetRevision(ctx, sourceID, revision)
+}
+
+// Restore needs edit access to the source as it is now, if it still exists,
+// and as it will be after the restore.
+func (s accessStore) Restore(ctx context.Context, sourceID string, revision int) (*models.Source, error) {
+	target, err := s.GetRevision(ctx, sourceID, revision)
+	if err != nil {
+		return nil, err
+	}
+
+	p := auth.PrincipalFromContext(ctx)
+	if current, getErr := s.SourceStore.GetByID(ctx, sourceID); getErr == nil {
+		if editErr := checkEdit(p, current.CityName); editErr != nil {
+			return nil, editErr
+		}
+	}
+	if editErr := checkEdit(p, target.Snapshot.CityName); editErr != nil {
+		return nil, editErr
+	}
+
+	return s.SourceStore.Restore(ctx, sourceID, revision)
+}
+
+// cityAccessStore limits a CityStore to the cities the request's caller may
+// see, reporting others as not found. Changes are left to the route's scope,
+// since only admins manage cities.
+type cityAccessStore struct {
+	repository.CityStore
+}
+
+func (s cityAccessStore) GetByID(ctx context.Context, id string) (*models.City, error) {
+	city, err := s.CityStore.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if !auth.PrincipalFromContext(ctx).CanView(&city.Name) {
+		return nil, fmt.Errorf("city %s: %w", id, repository.ErrNotFound)
+	}
+	return city, nil
+}
+
+func (s cityAccessStore) List(ctx context.Context) ([]models.City, error) {
+	cities, err := s.CityStore.List(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	p := auth.PrincipalFromContext(ctx)
+	visible := make([]models.City, 0, len(cities))
+	for i := range cities {
+		if p.CanView(&cities[i].Name) {
+			visible = append(visible, cities[i])
+		}
+	}
+
+	return visible, nil
+}
+
+// canViewHistory decides from the newest revision, which also covers
+// deleted sources.
+func canViewHistory(ctx context.Context, latest *models.SourceRevision) bool {
+	return auth.PrincipalFromContext(ctx).CanView(latest.Snapshot.CityName)
+}
+
+func checkEdit(p *auth.Principal, city *string) error {
+	if p.CanEdit(city) {
+		return nil
+	}
+	if city == nil {
+		return fmt.Errorf("sources without a city: %w", auth.ErrForbidden)
+	}
+	return fmt.Errorf("sources in %s: %w", *city, auth.ErrForbidden)
+}
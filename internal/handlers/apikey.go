@@ -0,0 +1,105 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jonesrussell/gosources/internal/auth"
+	"github.com/jonesrussell/gosources/internal/logger"
+	"github.com/jonesrussell/gosources/internal/models"
+	"github.com/jonesrussell/gosources/internal/repository"
+)
+
+// APIKeyRequest is the body of POST /api/v1/api-keys. A key with a user_id
+// acts as that user, limited to their role and cities.
+type APIKeyRequest struct {
+	Name   string         `json:"name"`
+	Scopes []models.Scope `json:"scopes"`
+	UserID *string        `json:"user_id"`
+}
+
+type APIKeyHandler struct {
+	store  repository.APIKeyStore
+	users  repository.UserStore
+	logger logger.Logger
+}
+
+func NewAPIKeyHandler(store repository.APIKeyStore, users repository.UserStore, log logger.Logger) *APIKeyHandler {
+	return &APIKeyHandler{
+		store:  store,
+		users:  users,
+		logger: log,
+	}
+}
+
+// Create issues a key. The response is the only one that includes the key
+// itself.
+func (h *APIKeyHandler) Create(c *gin.Context) {
+	var req APIKeyRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		respondBadRequest(c, "Invalid request body", err)
+		return
+	}
+
+	var user *models.User
+	if req.UserID != nil {
+		var err error
+		user, err = h.users.GetByID(c.Request.Context(), *req.UserID)
+		if errors.Is(err, repository.ErrNotFound) {
+			err = models.ValidationErrors{{Field: "user_id", Message: "no such user"}}
+		}
+		if err != nil {
+			respondError(c, err, "API key", "create")
+			return
+		}
+	}
+
+	key, err := auth.Issue(c.Request.Context(), h.store, req.Name, req.Scopes, user)
+	if err != nil {
+		respondError(c, err, "API key", "create")
+		return
+	}
+
+	h.logger.Info("API key created",
+		logger.String("api_key_id", key.ID),
+		logger.String("name", key.Name),
+		logger.String("actor", repository.ActorFromContext(c.Request.Context())),
+	)
+
+	c.JSON(http.StatusCreated, key)
+}
+
+func (h *APIKeyHandler) List(c *gin.Context) {
+	keys, err := h.store.List(c.Request.Context())
+	if err != nil {
+		respondError(c, err, "API keys", "list")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"api_keys": keys,
+		"count":    len(keys),
+	})
+}
+
+// Revoke disables a key immediately. The key is kept so its history stays
+// readable.
+func (h *APIKeyHandler) Revoke(c *gin.Context) {
+	id := c.Param("id")
+
+	key, err := h.store.Revoke(c.Request.Context(), id)
+	if err != nil {
+		respondError(c, err, "API key", "revoke",
+			logger.String("api_key_id", id),
+		)
+		return
+	}
+
+	h.logger.Info("API key revoked",
+		logger.String("api_key_id", id),
+		logger.String("actor", repository.ActorFromContext(c.Request.Context())),
+	)
+
+	c.JSON(http.StatusOK, key)
+}
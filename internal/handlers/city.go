@@ -0,0 +1,200 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jonesrussell/gosources/internal/logger"
+	"github.com/jonesrussell/gosources/internal/models"
+	"github.com/jonesrussell/gosources/internal/repository"
+)
+
+// CityRequest is the body of POST and PUT /api/v1/cities. Name may be left
+// out of a PUT; when given it must match the stored name.
+type CityRequest struct {
+	Name         string `json:"name"`
+	DisplayName  string `json:"display_name"`
+	Region       string `json:"region"`
+	Timezone     string `json:"timezone"`
+	GroupID      string `json:"group_id"`
+	DefaultIndex string `json:"default_index"`
+}
+
+func (r *CityRequest) applyTo(city *models.City) {
+	city.Name = r.Name
+	city.DisplayName = r.DisplayName
+	city.Region = r.Region
+	city.Timezone = r.Timezone
+	city.GroupID = r.GroupID
+	city.DefaultIndex = r.DefaultIndex
+}
+
+type CityHandler struct {
+	store   repository.CityStore
+	sources repository.SourceStore
+}
+
+// NewCityHandler serves cities from store. The gopost view of GET
+// /api/v1/cities is read from sources.
+func NewCityHandler(store repository.CityStore, sources repository.SourceStore) *CityHandler {
+	return &CityHandler{
+		store:   cityAccessStore{CityStore: store},
+		sources: accessStore{SourceStore: sources},
+	}
+}
+
+func (h *CityHandler) Create(c *gin.Context) {
+	var req CityRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		respondBadRequest(c, "Invalid request body", err)
+		return
+	}
+
+	var city models.City
+	req.applyTo(&city)
+
+	if err := city.Validate(); err != nil {
+		respondError(c, err, "city", "create")
+		return
+	}
+
+	if err := h.store.Create(c.Request.Context(), &city); err != nil {
+		respondError(c, err, "city", "create",
+			logger.String("city_name", city.Name),
+		)
+		return
+	}
+
+	logger.FromContext(c.Request.Context()).Info("City created",
+		logger.String("city_id", city.ID),
+		logger.String("city_name", city.Name),
+		logger.String("actor", repository.ActorFromContext(c.Request.Context())),
+	)
+
+	c.JSON(http.StatusCreated, city)
+}
+
+// List returns, for gopost, every city with an enabled source and the index
+// and group its articles go to. With ?all=true it returns every city record
+// instead.
+func (h *CityHandler) List(c *gin.Context) {
+	all := false
+	if raw := c.Query("all"); raw != "" {
+		v, err := strconv.ParseBool(raw)
+		if err != nil {
+			respondBadRequest(c, "Invalid query parameters", err)
+			return
+		}
+		all = v
+	}
+
+	if all {
+		cities, err := h.store.List(c.Request.Context())
+		if err != nil {
+			respondError(c, err, "cities", "list")
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{
+			"cities": cities,
+			"count":  len(cities),
+		})
+		return
+	}
+
+	cities, err := h.sources.GetCities(c.Request.Context())
+	if err != nil {
+		respondError(c, err, "cities", "get")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"cities": cities,
+		"count":  len(cities),
+	})
+}
+
+func (h *CityHandler) GetByID(c *gin.Context) {
+	id := c.Param("id")
+
+	city, err := h.store.GetByID(c.Request.Context(), id)
+	if err != nil {
+		respondError(c, err, "city", "get",
+			logger.String("city_id", id),
+		)
+		return
+	}
+
+	c.JSON(http.StatusOK, city)
+}
+
+// Update replaces everything but the city's name, which sources and users
+// refer to.
+func (h *CityHandler) Update(c *gin.Context) {
+	id := c.Param("id")
+
+	var req CityRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		respondBadRequest(c, "Invalid request body", err)
+		return
+	}
+
+	current, err := h.store.GetByID(c.Request.Context(), id)
+	if err != nil {
+		respondError(c, err, "city", "update",
+			logger.String("city_id", id),
+		)
+		return
+	}
+	if req.Name != "" && req.Name != current.Name {
+		respondError(c, models.ValidationErrors{{Field: "name", Message: "cannot be changed"}}, "city", "update",
+			logger.String("city_id", id),
+		)
+		return
+	}
+
+	city := models.City{ID: id}
+	req.applyTo(&city)
+	city.Name = current.Name
+
+	if validateErr := city.Validate(); validateErr != nil {
+		respondError(c, validateErr, "city", "update",
+			logger.String("city_id", id),
+		)
+		return
+	}
+
+	if updateErr := h.store.Update(c.Request.Context(), &city); updateErr != nil {
+		respondError(c, updateErr, "city", "update",
+			logger.String("city_id", id),
+		)
+		return
+	}
+
+	logger.FromContext(c.Request.Context()).Info("City updated",
+		logger.String("city_id", id),
+		logger.String("city_name", city.Name),
+		logger.String("actor", repository.ActorFromContext(c.Request.Context())),
+	)
+
+	c.JSON(http.StatusOK, city)
+}
+
+// Delete removes a city no source belongs to; otherwise it responds 409.
+func (h *CityHandler) Delete(c *gin.Context) {
+	id := c.Param("id")
+
+	if err := h.store.Delete(c.Request.Context(), id); err != nil {
+		respondError(c, err, "city", "delete",
+			logger.String("city_id", id),
+		)
+		return
+	}
+
+	logger.FromContext(c.Request.Context()).Info("City deleted",
+		logger.String("city_id", id),
+		logger.String("actor", repository.ActorFromContext(c.Request.Context())),
+	)
+
+	c.JSON(http.StatusNoContent, nil)
+}
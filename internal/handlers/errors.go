@@ -0,0 +1,94 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jonesrussell/gosources/internal/auth"
+	"github.com/jonesrussell/gosources/internal/logger"
+	"github.com/jonesrussell/gosources/internal/models"
+	"github.com/jonesrussell/gosources/internal/repository"
+)
+
+// ErrorResponse is the JSON body returned for every failed request.
+type ErrorResponse struct {
+	Error   string `json:"error"`
+	Details string `json:"details,omitempty"`
+	Field   string `json:"field,omitempty"`
+
+	// CurrentVersion is set on 412 responses so clients can refetch or merge.
+	CurrentVersion int `json:"current_version,omitempty"`
+
+	Errors models.ValidationErrors `json:"errors,omitempty"`
+}
+
+// respondError maps validation and repository errors to an HTTP status and
+// writes a consistent JSON error body. resource names the entity ("source") and action
+// the operation ("update") for the messages; unexpected errors are logged with
+// the request's logger and returned as 500 without exposing internal details.
+func respondError(c *gin.Context, err error, resource, action string, fields ...logger.Field) {
+	title := strings.ToUpper(resource[:1]) + resource[1:]
+	log := logger.FromContext(c.Request.Context())
+
+	var conflict *repository.ConflictError
+	var mismatch *repository.VersionMismatchError
+	var invalid models.ValidationErrors
+	switch {
+	case errors.As(err, &invalid):
+		log.Debug("Invalid "+resource, append(fields, logger.Error(err))...)
+		c.JSON(http.StatusUnprocessableEntity, ErrorResponse{
+			Error:  "Invalid " + resource,
+			Errors: invalid,
+		})
+
+	case errors.Is(err, auth.ErrForbidden):
+		log.Debug(title+" forbidden", append(fields, logger.Error(err))...)
+		c.JSON(http.StatusForbidden, ErrorResponse{
+			Error:   "Not allowed to " + action + " this " + resource,
+			Details: err.Error(),
+		})
+
+	case errors.Is(err, repository.ErrNotFound):
+		log.Debug(title+" not found", append(fields, logger.Error(err))...)
+		c.JSON(http.StatusNotFound, ErrorResponse{Error: title + " not found"})
+
+	case errors.As(err, &conflict):
+		log.Debug(title+" conflict", append(fields, logger.Error(err))...)
+		c.JSON(http.StatusConflict, ErrorResponse{
+			Error:   "A " + resource + " with this " + conflict.Field + " already exists",
+			Details: conflict.Error(),
+			Field:   conflict.Field,
+		})
+
+	case errors.As(err, &mismatch):
+		log.Debug(title+" version mismatch", append(fields, logger.Error(err))...)
+		setETag(c, mismatch.Current)
+		c.JSON(http.StatusPreconditionFailed, ErrorResponse{
+			Error:          title + " has been modified since it was read",
+			Details:        mismatch.Error(),
+			CurrentVersion: mismatch.Current,
+		})
+
+	case errors.Is(err, repository.ErrConflict):
+		log.Debug(title+" conflict", append(fields, logger.Error(err))...)
+		c.JSON(http.StatusConflict, ErrorResponse{Error: title + " conflicts with an existing record"})
+
+	case errors.Is(err, repository.ErrConstraint):
+		log.Debug("Invalid "+resource, append(fields, logger.Error(err))...)
+		c.JSON(http.StatusUnprocessableEntity, ErrorResponse{
+			Error:   "Invalid " + resource,
+			Details: err.Error(),
+		})
+
+	default:
+		log.Error("Failed to "+action+" "+resource, append(fields, logger.Error(err))...)
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "Failed to " + action + " " + resource})
+	}
+}
+
+// respondBadRequest writes a 400 response for malformed input.
+func respondBadRequest(c *gin.Context, message string, err error) {
+	c.JSON(http.StatusBadRequest, ErrorResponse{Error: message, Details: err.Error()})
+}
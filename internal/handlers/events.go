@@ -0,0 +1,157 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-contrib/sse"
+	"github.com/gin-gonic/gin"
+	"github.com/jonesrussell/gosources/internal/auth"
+	"github.com/jonesrussell/gosources/internal/events"
+	"github.com/jonesrussell/gosources/internal/logger"
+	"github.com/jonesrussell/gosources/internal/models"
+	"github.com/jonesrussell/gosources/internal/repository"
+)
+
+const (
+	eventBatchSize    = 100
+	heartbeatInterval = 15 * time.Second
+
+	// reconnectDelay is sent as the SSE retry field so clients come back
+	// quickly after a dropped connection.
+	reconnectDelay = time.Second
+)
+
+var errInvalidLastEventID = errors.New("must be a non-negative integer")
+
+type EventHandler struct {
+	eventLog repository.EventLog
+	stream   *events.Stream
+	logger   logger.Logger
+}
+
+func NewEventHandler(eventLog repository.EventLog, stream *events.Stream, log logger.Logger) *EventHandler {
+	return &EventHandler{
+		eventLog: eventLog,
+		stream:   stream,
+		logger:   log,
+	}
+}
+
+// Stream sends source change events as Server-Sent Events until the client
+// disconnects. Each event's id is its position in the event log; a client
+// that reconnects with a Last-Event-ID header (or ?last_event_id=) receives
+// everything after it first. Without one the stream starts with the next
+// change. Callers limited to some cities only receive events for sources
+// in them, before or after the change.
+func (h *EventHandler) Stream(c *gin.Context) {
+	ctx := c.Request.Context()
+	principal := auth.PrincipalFromContext(ctx)
+
+	after, err := lastEventID(c)
+	if err != nil {
+		respondBadRequest(c, "Invalid Last-Event-ID", err)
+		return
+	}
+
+	wake, unsubscribe := h.stream.Subscribe()
+	defer unsubscribe()
+
+	if after < 0 {
+		if after, err = h.eventLog.LastID(ctx); err != nil {
+			respondError(c, err, "events", "stream")
+			return
+		}
+	}
+
+	// The server's write timeout would otherwise end the stream.
+	if deadlineErr := http.NewResponseController(c.Writer).SetWriteDeadline(time.Time{}); deadlineErr != nil {
+		h.logger.Debug("Failed to clear write deadline", logger.Error(deadlineErr))
+	}
+
+	c.Header("Content-Type", "text/event-stream")
+	c.Header("Cache-Control", "no-cache")
+	c.Header("Connection", "keep-alive")
+	c.Header("X-Accel-Buffering", "no")
+	c.Status(http.StatusOK)
+	_, _ = fmt.Fprintf(c.Writer, "retry: %d\n\n", reconnectDelay.Milliseconds())
+	c.Writer.Flush()
+
+	h.logger.Debug("Event stream opened",
+		logger.Int64("last_event_id", after),
+		logger.String("client_ip", c.ClientIP()),
+	)
+
+	heartbeat := time.NewTicker(heartbeatInterval)
+	defer heartbeat.Stop()
+
+	for {
+		batch, sinceErr := h.eventLog.Since(ctx, after, eventBatchSize)
+		if sinceErr != nil {
+			if ctx.Err() == nil {
+				h.logger.Error("Failed to read events", logger.Error(sinceErr))
+			}
+			return
+		}
+
+		for i := range batch {
+			after = batch[i].Sequence
+			if !canViewEvent(principal, &batch[i]) {
+				continue
+			}
+			c.Render(-1, sse.Event{
+				Id:    batch[i].ID,
+				Event: string(batch[i].Type),
+				Data:  batch[i],
+			})
+		}
+		if len(batch) > 0 {
+			c.Writer.Flush()
+		}
+		if len(batch) == eventBatchSize {
+			continue
+		}
+
+		select {
+		case <-ctx.Done():
+			h.logger.Debug("Event stream closed",
+				logger.Int64("last_event_id", after),
+			)
+			return
+		case <-h.stream.Done():
+			return
+		case <-wake:
+		case <-heartbeat.C:
+			// A comment line keeps proxies from closing an idle connection.
+			_, _ = fmt.Fprint(c.Writer, ": heartbeat\n\n")
+			c.Writer.Flush()
+		}
+	}
+}
+
+func canViewEvent(p *auth.Principal, event *models.SourceEvent) bool {
+	return (event.Source != nil && p.CanView(event.Source.CityName)) ||
+		(event.Previous != nil && p.CanView(event.Previous.CityName))
+}
+
+// lastEventID returns the ID to resume after, or -1 when the client did not
+// send one.
+func lastEventID(c *gin.Context) (int64, error) {
+	raw := c.GetHeader("Last-Event-ID")
+	if raw == "" {
+		raw = c.Query("last_event_id")
+	}
+	if raw == "" {
+		return -1, nil
+	}
+
+	id, err := strconv.ParseInt(raw, 10, 64)
+	if err != nil || id < 0 {
+		return 0, errInvalidLastEventID
+	}
+
+	return id, nil
+}
@@ -0,0 +1,43 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jonesrussell/gosources/internal/health"
+	"github.com/jonesrussell/gosources/internal/logger"
+)
+
+type HealthHandler struct {
+	checker *health.Checker
+	logger  logger.Logger
+}
+
+func NewHealthHandler(checker *health.Checker, log logger.Logger) *HealthHandler {
+	return &HealthHandler{
+		checker: checker,
+		logger:  log,
+	}
+}
+
+// Livez reports that the process is up and serving requests. It checks no
+// dependencies, so an unreachable database never gets the process restarted.
+func (h *HealthHandler) Livez(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{"status": health.StatusOK})
+}
+
+// Readyz runs the dependency checks and answers 503 when any fails or the
+// server is draining for shutdown.
+func (h *HealthHandler) Readyz(c *gin.Context) {
+	report := h.checker.Check(c.Request.Context())
+	if !report.Ready() {
+		h.logger.Debug("Not ready",
+			logger.String("status", report.Status),
+			logger.Any("checks", report.Checks),
+		)
+		c.JSON(http.StatusServiceUnavailable, report)
+		return
+	}
+
+	c.JSON(http.StatusOK, report)
+}
@@ -0,0 +1,130 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"mime"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jonesrussell/gosources/internal/jsonpatch"
+	"github.com/jonesrussell/gosources/internal/logger"
+	"github.com/jonesrussell/gosources/internal/models"
+)
+
+// Patch media types. Plain application/json is treated as a merge patch.
+const (
+	mediaTypeMergePatch = "application/merge-patch+json"
+	mediaTypeJSONPatch  = "application/json-patch+json"
+)
+
+// maxPatchBodyBytes bounds the patch documents accepted by Patch.
+const maxPatchBodyBytes = 1 << 20
+
+// Patch partially updates a source. The body is an RFC 7396 merge patch, or
+// an RFC 6902 JSON Patch when sent as application/json-patch+json. The patch
+// is applied to the stored source and the result is validated and saved like
+// a PUT. The save is conditional on the version the patch was applied to, or
+// on If-Match when given.
+func (h *SourceHandler) Patch(c *gin.Context) {
+	id := c.Param("id")
+	ctx := c.Request.Context()
+
+	version, err := parseIfMatch(c)
+	if err != nil {
+		respondBadRequest(c, "Invalid If-Match header", err)
+		return
+	}
+
+	applyPatch := jsonpatch.MergePatch
+	mediaType, _, _ := mime.ParseMediaType(c.ContentType())
+	switch mediaType {
+	case mediaTypeMergePatch, "application/json", "":
+	case mediaTypeJSONPatch:
+		applyPatch = jsonpatch.Apply
+	default:
+		c.Header("Accept-Patch", mediaTypeMergePatch+", "+mediaTypeJSONPatch)
+		c.JSON(http.StatusUnsupportedMediaType, ErrorResponse{
+			Error:   "Unsupported patch media type",
+			Details: "use " + mediaTypeMergePatch + " or " + mediaTypeJSONPatch,
+		})
+		return
+	}
+
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, maxPatchBodyBytes)
+	patch, err := c.GetRawData()
+	if err != nil {
+		respondBadRequest(c, "Invalid request body", err)
+		return
+	}
+
+	current, err := h.repo.GetByID(ctx, id)
+	if err != nil {
+		respondError(c, err, "source", "get",
+			logger.String("source_id", id),
+		)
+		return
+	}
+	if version == 0 {
+		version = current.Version
+	}
+
+	doc, err := json.Marshal(current)
+	if err != nil {
+		respondError(c, err, "source", "patch",
+			logger.String("source_id", id),
+		)
+		return
+	}
+
+	patched, err := applyPatch(doc, patch)
+	switch {
+	case errors.Is(err, jsonpatch.ErrPathNotFound), errors.Is(err, jsonpatch.ErrTestFailed):
+		logger.FromContext(c.Request.Context()).Debug("Patch could not be applied",
+			logger.String("source_id", id),
+			logger.Error(err),
+		)
+		c.JSON(http.StatusConflict, ErrorResponse{
+			Error:   "Patch could not be applied",
+			Details: err.Error(),
+		})
+		return
+	case err != nil:
+		respondBadRequest(c, "Invalid patch", err)
+		return
+	}
+
+	var source models.Source
+	decoder := json.NewDecoder(bytes.NewReader(patched))
+	decoder.DisallowUnknownFields()
+	if decodeErr := decoder.Decode(&source); decodeErr != nil {
+		respondBadRequest(c, "Invalid patch", decodeErr)
+		return
+	}
+
+	source.ID = id
+	source.Version = version
+
+	if validateErr := source.Validate(); validateErr != nil {
+		respondError(c, validateErr, "source", "patch",
+			logger.String("source_id", id),
+		)
+		return
+	}
+
+	if updateErr := h.repo.Update(ctx, &source); updateErr != nil {
+		respondError(c, updateErr, "source", "patch",
+			logger.String("source_id", id),
+		)
+		return
+	}
+
+	logger.FromContext(c.Request.Context()).Info("Source patched",
+		logger.String("source_id", id),
+		logger.String("source_name", source.Name),
+	)
+
+	setETag(c, source.Version)
+	c.JSON(http.StatusOK, source)
+}
@@ -0,0 +1,103 @@
+package handlers
+
+import (
+	"bytes"
+	"net/http"
+	"net/url"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jonesrussell/gosources/internal/logger"
+	"github.com/jonesrussell/gosources/internal/models"
+	"github.com/jonesrussell/gosources/internal/preview"
+)
+
+// maxPreviewBodyBytes bounds the HTML accepted by the preview endpoints.
+const maxPreviewBodyBytes = 5 << 20
+
+// PreviewRequest is the body of POST /api/v1/sources/preview, used to try
+// selectors before the source is saved.
+type PreviewRequest struct {
+	URL       string                `json:"url"`
+	Selectors models.SelectorConfig `json:"selectors"`
+	HTML      string                `json:"html" binding:"required"`
+}
+
+// Preview runs a saved source's selectors against the raw HTML in the request
+// body. The ?type= query parameter selects article (default), list or page
+// selectors, and ?url= overrides the base URL used to resolve links.
+func (h *SourceHandler) Preview(c *gin.Context) {
+	id := c.Param("id")
+
+	typ, err := preview.ParseType(c.Query("type"))
+	if err != nil {
+		respondBadRequest(c, "Invalid query parameters", err)
+		return
+	}
+
+	source, err := h.repo.GetByID(c.Request.Context(), id)
+	if err != nil {
+		respondError(c, err, "source", "get",
+			logger.String("source_id", id),
+		)
+		return
+	}
+
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, maxPreviewBodyBytes)
+	html, err := c.GetRawData()
+	if err != nil {
+		respondBadRequest(c, "Invalid request body", err)
+		return
+	}
+
+	baseURL := c.DefaultQuery("url", source.URL)
+	h.runPreview(c, html, &source.Selectors, typ, baseURL)
+}
+
+// PreviewUnsaved runs the selectors in the JSON body against its html field.
+func (h *SourceHandler) PreviewUnsaved(c *gin.Context) {
+	typ, err := preview.ParseType(c.Query("type"))
+	if err != nil {
+		respondBadRequest(c, "Invalid query parameters", err)
+		return
+	}
+
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, maxPreviewBodyBytes)
+
+	var req PreviewRequest
+	if bindErr := c.ShouldBindJSON(&req); bindErr != nil {
+		logger.FromContext(c.Request.Context()).Debug("Invalid request body",
+			logger.String("error", bindErr.Error()),
+		)
+		respondBadRequest(c, "Invalid request body", bindErr)
+		return
+	}
+
+	if validateErr := req.Selectors.Validate(); validateErr != nil {
+		respondError(c, validateErr, "selectors", "preview")
+		return
+	}
+
+	h.runPreview(c, []byte(req.HTML), &req.Selectors, typ, req.URL)
+}
+
+func (h *SourceHandler) runPreview(
+	c *gin.Context, html []byte, selectors *models.SelectorConfig, typ preview.Type, rawBaseURL string,
+) {
+	var baseURL *url.URL
+	if rawBaseURL != "" {
+		parsed, err := url.Parse(rawBaseURL)
+		if err != nil {
+			respondBadRequest(c, "Invalid base URL", err)
+			return
+		}
+		baseURL = parsed
+	}
+
+	result, err := preview.Run(bytes.NewReader(html), selectors, typ, baseURL)
+	if err != nil {
+		respondError(c, err, "preview", "run")
+		return
+	}
+
+	c.JSON(http.StatusOK, result)
+}
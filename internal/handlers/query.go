@@ -0,0 +1,56 @@
+package handlers
+
+import (
+	"errors"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jonesrussell/gosources/internal/repository"
+)
+
+// parseListOptions reads the filtering, sorting and pagination query
+// parameters accepted by GET /api/v1/sources.
+func parseListOptions(c *gin.Context) (repository.ListOptions, error) {
+	var opts repository.ListOptions
+
+	if enabled := c.Query("enabled"); enabled != "" {
+		v, err := strconv.ParseBool(enabled)
+		if err != nil {
+			return opts, errors.New("enabled: must be true or false")
+		}
+		opts.Enabled = &v
+	}
+
+	opts.CityName = c.Query("city_name")
+	opts.ArticleIndex = c.Query("article_index")
+	opts.Search = c.Query("q")
+
+	if since := c.Query("updated_since"); since != "" {
+		t, err := time.Parse(time.RFC3339, since)
+		if err != nil {
+			return opts, errors.New("updated_since: must be an RFC 3339 timestamp")
+		}
+		t = t.UTC()
+		opts.UpdatedSince = &t
+	}
+
+	sort, descending, err := repository.ParseSort(c.Query("sort"))
+	if err != nil {
+		return opts, errors.New("sort: must be one of name, created_at, updated_at, optionally prefixed with -")
+	}
+	opts.Sort = sort
+	opts.Descending = descending
+
+	if limit := c.Query("limit"); limit != "" {
+		v, convErr := strconv.Atoi(limit)
+		if convErr != nil || v <= 0 {
+			return opts, errors.New("limit: must be a positive integer")
+		}
+		opts.Limit = min(v, repository.MaxListLimit)
+	}
+
+	opts.Cursor = c.Query("cursor")
+
+	return opts, nil
+}
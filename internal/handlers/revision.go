@@ -0,0 +1,158 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jonesrussell/gosources/internal/jsondiff"
+	"github.com/jonesrussell/gosources/internal/logger"
+)
+
+// revisionSummary is a revision without its snapshot, used in listings.
+type revisionSummary struct {
+	Revision  int       `json:"revision"`
+	Action    string    `json:"action"`
+	Actor     string    `json:"actor"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+func (h *SourceHandler) ListRevisions(c *gin.Context) {
+	id := c.Param("id")
+
+	revisions, err := h.repo.ListRevisions(c.Request.Context(), id)
+	if err != nil {
+		respondError(c, err, "source", "list revisions for",
+			logger.String("source_id", id),
+		)
+		return
+	}
+
+	summaries := make([]revisionSummary, len(revisions))
+	for i, rev := range revisions {
+		summaries[i] = revisionSummary{
+			Revision:  rev.Revision,
+			Action:    string(rev.Action),
+			Actor:     rev.Actor,
+			CreatedAt: rev.CreatedAt,
+		}
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"revisions": summaries,
+		"count":     len(summaries),
+	})
+}
+
+func (h *SourceHandler) GetRevision(c *gin.Context) {
+	id := c.Param("id")
+
+	rev, err := parseRevision(c.Param("rev"))
+	if err != nil {
+		respondBadRequest(c, "Invalid revision", err)
+		return
+	}
+
+	revision, err := h.repo.GetRevision(c.Request.Context(), id, rev)
+	if err != nil {
+		respondError(c, err, "revision", "get",
+			logger.String("source_id", id),
+			logger.Int("revision", rev),
+		)
+		return
+	}
+
+	c.JSON(http.StatusOK, revision)
+}
+
+// DiffRevisions returns the changes between ?from= and ?to= as JSON Patch
+// operations. to defaults to the latest revision.
+func (h *SourceHandler) DiffRevisions(c *gin.Context) {
+	id := c.Param("id")
+	ctx := c.Request.Context()
+
+	from, err := parseRevision(c.Query("from"))
+	if err != nil {
+		respondBadRequest(c, "Invalid from revision", err)
+		return
+	}
+
+	var to int
+	if rawTo := c.Query("to"); rawTo != "" {
+		if to, err = parseRevision(rawTo); err != nil {
+			respondBadRequest(c, "Invalid to revision", err)
+			return
+		}
+	} else {
+		revisions, listErr := h.repo.ListRevisions(ctx, id)
+		if listErr != nil {
+			respondError(c, listErr, "source", "list revisions for",
+				logger.String("source_id", id),
+			)
+			return
+		}
+		to = revisions[0].Revision
+	}
+
+	older, err := h.repo.GetRevision(ctx, id, from)
+	if err != nil {
+		respondError(c, err, "revision", "get", logger.String("source_id", id))
+		return
+	}
+	newer, err := h.repo.GetRevision(ctx, id, to)
+	if err != nil {
+		respondError(c, err, "revision", "get", logger.String("source_id", id))
+		return
+	}
+
+	changes, err := jsondiff.Diff(older.Snapshot, newer.Snapshot)
+	if err != nil {
+		respondError(c, err, "revision", "diff", logger.String("source_id", id))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"source_id": id,
+		"from":      from,
+		"to":        to,
+		"changes":   changes,
+	})
+}
+
+func (h *SourceHandler) RestoreRevision(c *gin.Context) {
+	id := c.Param("id")
+
+	rev, err := parseRevision(c.Param("rev"))
+	if err != nil {
+		respondBadRequest(c, "Invalid revision", err)
+		return
+	}
+
+	source, err := h.repo.Restore(c.Request.Context(), id, rev)
+	if err != nil {
+		respondError(c, err, "source", "restore",
+			logger.String("source_id", id),
+			logger.Int("revision", rev),
+		)
+		return
+	}
+
+	logger.FromContext(c.Request.Context()).Info("Source restored",
+		logger.String("source_id", id),
+		logger.String("source_name", source.Name),
+		logger.Int("revision", rev),
+	)
+
+	setETag(c, source.Version)
+	c.JSON(http.StatusOK, source)
+}
+
+func parseRevision(s string) (int, error) {
+	rev, err := strconv.Atoi(s)
+	if err != nil || rev < 1 {
+		return 0, errors.New("revision must be a positive integer")
+	}
+	return rev, nil
+}
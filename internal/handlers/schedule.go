@@ -0,0 +1,265 @@
+package handlers
+
+import (
+	"bytes"
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jonesrussell/gosources/internal/calendar"
+	"github.com/jonesrussell/gosources/internal/logger"
+	"github.com/jonesrussell/gosources/internal/models"
+)
+
+const (
+	defaultNextRuns = 10
+	maxNextRuns     = 100
+)
+
+// NextRunsResponse lists a source's upcoming crawl times.
+type NextRunsResponse struct {
+	SourceID string           `json:"source_id"`
+	Schedule *models.Schedule `json:"schedule"` // The effective schedule; null when the source has none
+	Timezone string           `json:"timezone"`
+	Runs     []time.Time      `json:"runs"` // In the schedule's time zone
+}
+
+// NextRuns returns the next ?n= (default 10, at most 100) run times of the
+// source's schedule after ?after= (an RFC 3339 timestamp, default now).
+// Sources with only the legacy time list run at those times daily in UTC.
+func (h *SourceHandler) NextRuns(c *gin.Context) {
+	id := c.Param("id")
+
+	n := defaultNextRuns
+	if raw := c.Query("n"); raw != "" {
+		v, err := strconv.Atoi(raw)
+		if err != nil || v <= 0 || v > maxNextRuns {
+			respondBadRequest(c, "Invalid query parameters",
+				errors.New("n: must be an integer between 1 and "+strconv.Itoa(maxNextRuns)))
+			return
+		}
+		n = v
+	}
+
+	after := time.Now()
+	if raw := c.Query("after"); raw != "" {
+		t, err := time.Parse(time.RFC3339, raw)
+		if err != nil {
+			respondBadRequest(c, "Invalid query parameters", errors.New("after: must be an RFC 3339 timestamp"))
+			return
+		}
+		after = t
+	}
+
+	source, err := h.repo.GetByID(c.Request.Context(), id)
+	if err != nil {
+		respondError(c, err, "source", "get",
+			logger.String("source_id", id),
+		)
+		return
+	}
+
+	response := NextRunsResponse{
+		SourceID: source.ID,
+		Schedule: source.EffectiveSchedule(),
+		Timezone: time.UTC.String(),
+		Runs:     []time.Time{},
+	}
+
+	if response.Schedule != nil {
+		loc, locErr := response.Schedule.Location()
+		if locErr == nil {
+			response.Timezone = loc.String()
+		}
+
+		runs, nextErr := response.Schedule.Next(after, n)
+		if nextErr != nil {
+			respondError(c, nextErr, "schedule", "compute",
+				logger.String("source_id", id),
+			)
+			return
+		}
+		response.Runs = runs
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+// Calendar lays out the runs of every enabled source the caller can see,
+// grouped into buckets, and flags overloaded buckets. ?from= and ?to= are
+// RFC 3339 timestamps (default now and 24 hours later, at most 7 days
+// apart), ?bucket= is a duration (default 15m), and a bucket is flagged when
+// it holds more than ?max_sources= runs (default 5) or more than
+// ?max_per_host= runs against one host (default 1). The source list filters,
+// such as ?city_name=, narrow the sources considered.
+func (h *SourceHandler) Calendar(c *gin.Context) {
+	opts := calendar.Options{
+		From:       time.Now().UTC(),
+		Bucket:     calendar.DefaultBucket,
+		MaxSources: calendar.DefaultMaxSources,
+		MaxPerHost: calendar.DefaultMaxPerHost,
+	}
+	if err := parseCalendarQuery(c, &opts); err != nil {
+		respondBadRequest(c, "Invalid query parameters", err)
+		return
+	}
+
+	sources, ok := h.scheduledSources(c)
+	if !ok {
+		return
+	}
+
+	c.JSON(http.StatusOK, calendar.Build(sources, opts))
+}
+
+// SuggestSchedule proposes times that keep each enabled source's runs per
+// day but spread them over ?bucket= slots (default 15m, must divide a day),
+// away from busy slots and other sources on the same host. ?day= (a date,
+// default today in UTC) is the day the times are worked out for.
+func (h *SourceHandler) SuggestSchedule(c *gin.Context) {
+	opts := calendar.SuggestOptions{
+		Day:    time.Now().UTC(),
+		Bucket: calendar.DefaultBucket,
+	}
+	if raw := c.Query("day"); raw != "" {
+		d, err := time.Parse(time.DateOnly, raw)
+		if err != nil {
+			respondBadRequest(c, "Invalid query parameters", errors.New("day: must be a date such as 2026-01-31"))
+			return
+		}
+		opts.Day = d
+	}
+	if raw := c.Query("bucket"); raw != "" {
+		d, err := time.ParseDuration(raw)
+		if err != nil {
+			respondBadRequest(c, "Invalid query parameters", errors.New("bucket: must be a duration such as \"15m\""))
+			return
+		}
+		opts.Bucket = d
+	}
+	if err := opts.Validate(); err != nil {
+		respondBadRequest(c, "Invalid query parameters", err)
+		return
+	}
+
+	sources, ok := h.scheduledSources(c)
+	if !ok {
+		return
+	}
+
+	c.JSON(http.StatusOK, calendar.Suggest(sources, opts))
+}
+
+// parseCalendarQuery reads the calendar window and thresholds into opts,
+// keeping its defaults for missing parameters.
+func parseCalendarQuery(c *gin.Context, opts *calendar.Options) error {
+	if raw := c.Query("from"); raw != "" {
+		t, err := time.Parse(time.RFC3339, raw)
+		if err != nil {
+			return errors.New("from: must be an RFC 3339 timestamp")
+		}
+		opts.From = t
+	}
+	opts.To = opts.From.Add(calendar.DefaultWindow)
+	if raw := c.Query("to"); raw != "" {
+		t, err := time.Parse(time.RFC3339, raw)
+		if err != nil {
+			return errors.New("to: must be an RFC 3339 timestamp")
+		}
+		opts.To = t
+	}
+
+	if raw := c.Query("bucket"); raw != "" {
+		d, err := time.ParseDuration(raw)
+		if err != nil {
+			return errors.New("bucket: must be a duration such as \"15m\"")
+		}
+		opts.Bucket = d
+	}
+
+	var err error
+	if opts.MaxSources, err = queryPositiveInt(c, "max_sources", opts.MaxSources); err != nil {
+		return err
+	}
+	if opts.MaxPerHost, err = queryPositiveInt(c, "max_per_host", opts.MaxPerHost); err != nil {
+		return err
+	}
+
+	return opts.Validate()
+}
+
+// queryPositiveInt reads a positive integer query parameter, returning def
+// when it is absent.
+func queryPositiveInt(c *gin.Context, key string, def int) (int, error) {
+	raw := c.Query(key)
+	if raw == "" {
+		return def, nil
+	}
+	v, err := strconv.Atoi(raw)
+	if err != nil || v <= 0 {
+		return 0, errors.New(key + ": must be a positive integer")
+	}
+	return v, nil
+}
+
+// scheduledSources lists the enabled sources matching the list filters. It
+// writes the error response and returns false when listing fails.
+func (h *SourceHandler) scheduledSources(c *gin.Context) ([]models.Source, bool) {
+	opts, err := parseListOptions(c)
+	if err != nil {
+		respondBadRequest(c, "Invalid query parameters", err)
+		return nil, false
+	}
+	enabled := true
+	opts.Enabled = &enabled
+	opts.Limit = 0
+	opts.Cursor = ""
+
+	result, err := h.repo.List(c.Request.Context(), opts)
+	if err != nil {
+		respondError(c, err, "sources", "list")
+		return nil, false
+	}
+
+	return result.Sources, true
+}
+
+// ScheduleICS serves the runs of every enabled source the caller can see as
+// an iCalendar feed of recurring events. ?source_id= narrows it to one source
+// and the source list filters, such as ?city_name=, to matching sources.
+func (h *SourceHandler) ScheduleICS(c *gin.Context) {
+	var sources []models.Source
+	if id := c.Query("source_id"); id != "" {
+		source, err := h.repo.GetByID(c.Request.Context(), id)
+		if err != nil {
+			respondError(c, err, "source", "get",
+				logger.String("source_id", id),
+			)
+			return
+		}
+		sources = []models.Source{*source}
+	} else {
+		var ok bool
+		if sources, ok = h.scheduledSources(c); !ok {
+			return
+		}
+	}
+
+	var feed bytes.Buffer
+	skipped, err := calendar.WriteICS(&feed, sources, time.Now())
+	if err != nil {
+		respondError(c, err, "calendar", "render")
+		return
+	}
+	for _, s := range skipped {
+		logger.FromContext(c.Request.Context()).Warn("Left source out of calendar feed",
+			logger.String("source_id", s.SourceID),
+			logger.String("error", s.Error),
+		)
+	}
+
+	c.Header("Content-Disposition", `inline; filename="gosources.ics"`)
+	c.Data(http.StatusOK, "text/calendar; charset=utf-8", feed.Bytes())
+}
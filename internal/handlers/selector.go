@@ -0,0 +1,70 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jonesrussell/gosources/internal/logger"
+	"github.com/jonesrussell/gosources/internal/models"
+)
+
+type SelectorHandler struct {
+	logger logger.Logger
+}
+
+func NewSelectorHandler(log logger.Logger) *SelectorHandler {
+	return &SelectorHandler{
+		logger: log,
+	}
+}
+
+// ValidateRequest carries either a single selector, a full selector config,
+// or both.
+type ValidateRequest struct {
+	Selector  *string                `json:"selector"`
+	Selectors *models.SelectorConfig `json:"selectors"`
+}
+
+// ValidateResponse reports whether every supplied selector parsed.
+type ValidateResponse struct {
+	Valid  bool                    `json:"valid"`
+	Errors models.ValidationErrors `json:"errors"`
+}
+
+// Validate checks CSS selector syntax without saving anything. It always
+// returns 200 with the per-field result so the form can call it as the user
+// types.
+func (h *SelectorHandler) Validate(c *gin.Context) {
+	var req ValidateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		h.logger.Debug("Invalid request body",
+			logger.String("error", err.Error()),
+		)
+		respondBadRequest(c, "Invalid request body", err)
+		return
+	}
+
+	errs := models.ValidationErrors{}
+
+	if req.Selector != nil {
+		if err := models.ValidateSelector(*req.Selector); err != nil {
+			errs = append(errs, models.FieldError{
+				Field:   "selector",
+				Message: "invalid CSS selector: " + err.Error(),
+			})
+		}
+	}
+
+	if req.Selectors != nil {
+		var fieldErrs models.ValidationErrors
+		if errors.As(req.Selectors.Validate(), &fieldErrs) {
+			errs = append(errs, fieldErrs...)
+		}
+	}
+
+	c.JSON(http.StatusOK, ValidateResponse{
+		Valid:  len(errs) == 0,
+		Errors: errs,
+	})
+}
@@ -1,6 +1,7 @@
 package handlers
 
 import (
+	"errors"
 	"net/http"
 
 	"github.com/gin-gonic/gin"
@@ -9,42 +10,53 @@ import (
 	"github.com/jonesrussell/gosources/internal/repository"
 )
 
+// SourceHandler logs through logger.FromContext so every line carries the
+// request's request_id.
 type SourceHandler struct {
-	repo   *repository.SourceRepository
-	logger logger.Logger
+	repo   repository.SourceStore
+	cities repository.CityStore
 }
 
-func NewSourceHandler(repo *repository.SourceRepository, log logger.Logger) *SourceHandler {
+// NewSourceHandler returns a handler that limits every operation to the
+// sources the caller's user may see and edit. cities is only used to check
+// that imported sources name existing cities.
+func NewSourceHandler(repo repository.SourceStore, cities repository.CityStore) *SourceHandler {
 	return &SourceHandler{
-		repo:   repo,
-		logger: log,
+		repo:   accessStore{SourceStore: repo},
+		cities: cities,
 	}
 }
 
 func (h *SourceHandler) Create(c *gin.Context) {
 	var source models.Source
 	if err := c.ShouldBindJSON(&source); err != nil {
-		h.logger.Debug("Invalid request body",
+		logger.FromContext(c.Request.Context()).Debug("Invalid request body",
 			logger.String("error", err.Error()),
 		)
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body", "details": err.Error()})
+		respondBadRequest(c, "Invalid request body", err)
+		return
+	}
+
+	if err := source.Validate(); err != nil {
+		respondError(c, err, "source", "create",
+			logger.String("source_name", source.Name),
+		)
 		return
 	}
 
 	if err := h.repo.Create(c.Request.Context(), &source); err != nil {
-		h.logger.Error("Failed to create source",
+		respondError(c, err, "source", "create",
 			logger.String("source_name", source.Name),
-			logger.Error(err),
 		)
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create source"})
 		return
 	}
 
-	h.logger.Info("Source created",
+	logger.FromContext(c.Request.Context()).Info("Source created",
 		logger.String("source_id", source.ID),
 		logger.String("source_name", source.Name),
 	)
 
+	setETag(c, source.Version)
 	c.JSON(http.StatusCreated, source)
 }
 
@@ -53,11 +65,15 @@ func (h *SourceHandler) GetByID(c *gin.Context) {
 
 	source, err := h.repo.GetByID(c.Request.Context(), id)
 	if err != nil {
-		h.logger.Debug("Source not found",
+		respondError(c, err, "source", "get",
 			logger.String("source_id", id),
-			logger.Error(err),
 		)
-		c.JSON(http.StatusNotFound, gin.H{"error": "Source not found"})
+		return
+	}
+
+	setETag(c, source.Version)
+	if notModified(c, source.Version) {
+		c.Status(http.StatusNotModified)
 		return
 	}
 
@@ -65,46 +81,75 @@ func (h *SourceHandler) GetByID(c *gin.Context) {
 }
 
 func (h *SourceHandler) List(c *gin.Context) {
-	sources, err := h.repo.List(c.Request.Context())
+	opts, err := parseListOptions(c)
 	if err != nil {
-		h.logger.Error("Failed to list sources",
-			logger.Error(err),
+		logger.FromContext(c.Request.Context()).Debug("Invalid list parameters",
+			logger.String("error", err.Error()),
 		)
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to list sources"})
+		respondBadRequest(c, "Invalid query parameters", err)
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{
-		"sources": sources,
-		"count":   len(sources),
-	})
+	result, err := h.repo.List(c.Request.Context(), opts)
+	if errors.Is(err, repository.ErrInvalidCursor) {
+		respondBadRequest(c, "Invalid query parameters", err)
+		return
+	}
+	if err != nil {
+		respondError(c, err, "source", "list")
+		return
+	}
+
+	response := gin.H{
+		"sources": result.Sources,
+		"count":   result.Total,
+	}
+	if result.NextCursor != "" {
+		response["next_cursor"] = result.NextCursor
+	}
+
+	c.JSON(http.StatusOK, response)
 }
 
+// Update replaces a source. An If-Match header makes the write conditional on
+// the version the client last read; a stale version gets 412.
 func (h *SourceHandler) Update(c *gin.Context) {
 	id := c.Param("id")
 
+	version, ifMatchErr := parseIfMatch(c)
+	if ifMatchErr != nil {
+		respondBadRequest(c, "Invalid If-Match header", ifMatchErr)
+		return
+	}
+
 	var source models.Source
 	if err := c.ShouldBindJSON(&source); err != nil {
-		h.logger.Debug("Invalid request body",
+		logger.FromContext(c.Request.Context()).Debug("Invalid request body",
 			logger.String("source_id", id),
 			logger.String("error", err.Error()),
 		)
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body", "details": err.Error()})
+		respondBadRequest(c, "Invalid request body", err)
 		return
 	}
 
 	source.ID = id
+	source.Version = version
+
+	if err := source.Validate(); err != nil {
+		respondError(c, err, "source", "update",
+			logger.String("source_id", id),
+		)
+		return
+	}
 
 	if err := h.repo.Update(c.Request.Context(), &source); err != nil {
-		h.logger.Error("Failed to update source",
+		respondError(c, err, "source", "update",
 			logger.String("source_id", id),
-			logger.Error(err),
 		)
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update source"})
 		return
 	}
 
-	h.logger.Info("Source updated",
+	logger.FromContext(c.Request.Context()).Info("Source updated",
 		logger.String("source_id", id),
 		logger.String("source_name", source.Name),
 	)
@@ -112,10 +157,12 @@ func (h *SourceHandler) Update(c *gin.Context) {
 	// Fetch updated source
 	updated, err := h.repo.GetByID(c.Request.Context(), id)
 	if err != nil {
+		setETag(c, source.Version)
 		c.JSON(http.StatusOK, source)
 		return
 	}
 
+	setETag(c, updated.Version)
 	c.JSON(http.StatusOK, updated)
 }
 
@@ -123,33 +170,15 @@ func (h *SourceHandler) Delete(c *gin.Context) {
 	id := c.Param("id")
 
 	if err := h.repo.Delete(c.Request.Context(), id); err != nil {
-		h.logger.Error("Failed to delete source",
+		respondError(c, err, "source", "delete",
 			logger.String("source_id", id),
-			logger.Error(err),
 		)
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete source"})
 		return
 	}
 
-	h.logger.Info("Source deleted",
+	logger.FromContext(c.Request.Context()).Info("Source deleted",
 		logger.String("source_id", id),
 	)
 
 	c.JSON(http.StatusNoContent, nil)
 }
-
-func (h *SourceHandler) GetCities(c *gin.Context) {
-	cities, err := h.repo.GetCities(c.Request.Context())
-	if err != nil {
-		h.logger.Error("Failed to get cities",
-			logger.Error(err),
-		)
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get cities"})
-		return
-	}
-
-	c.JSON(http.StatusOK, gin.H{
-		"cities": cities,
-		"count":  len(cities),
-	})
-}
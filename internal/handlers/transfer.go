@@ -0,0 +1,133 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jonesrussell/gosources/internal/logger"
+	"github.com/jonesrussell/gosources/internal/sourcefile"
+)
+
+// maxImportBodyBytes bounds the sources files accepted by Import.
+const maxImportBodyBytes = 10 << 20
+
+// Import creates or updates sources from a sources.yml file in the request
+// body. ?format= (yaml or json) defaults from the Content-Type, ?mode= is
+// create, upsert or skip, ?dry_run=true reports without writing and
+// ?prune=true deletes sources missing from the file.
+func (h *SourceHandler) Import(c *gin.Context) {
+	format, err := importFormat(c)
+	if err != nil {
+		respondBadRequest(c, "Invalid query parameters", err)
+		return
+	}
+	mode, err := sourcefile.ParseMode(c.Query("mode"))
+	if err != nil {
+		respondBadRequest(c, "Invalid query parameters", err)
+		return
+	}
+	opts := sourcefile.Options{Mode: mode}
+	if opts.DryRun, err = queryBool(c, "dry_run"); err != nil {
+		respondBadRequest(c, "Invalid query parameters", err)
+		return
+	}
+	if opts.Prune, err = queryBool(c, "prune"); err != nil {
+		respondBadRequest(c, "Invalid query parameters", err)
+		return
+	}
+
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, maxImportBodyBytes)
+	data, err := c.GetRawData()
+	if err != nil {
+		respondBadRequest(c, "Invalid request body", err)
+		return
+	}
+
+	entries, err := sourcefile.Decode(data, format)
+	if err != nil {
+		respondBadRequest(c, "Invalid sources file", err)
+		return
+	}
+
+	log := logger.FromContext(c.Request.Context())
+	report, err := sourcefile.NewImporter(h.repo, h.cities, log).Import(c.Request.Context(), entries, opts)
+	if err != nil {
+		respondError(c, err, "sources", "import")
+		return
+	}
+
+	log.Info("Sources imported",
+		logger.String("mode", string(mode)),
+		logger.Bool("dry_run", opts.DryRun),
+		logger.Bool("prune", opts.Prune),
+		logger.Int("entries", len(entries)),
+		logger.Int("created", report.Summary[sourcefile.ActionCreate]),
+		logger.Int("updated", report.Summary[sourcefile.ActionUpdate]),
+		logger.Int("deleted", report.Summary[sourcefile.ActionDelete]),
+		logger.Int("failed", report.Summary[sourcefile.ActionError]),
+	)
+
+	c.JSON(http.StatusOK, report)
+}
+
+// Export writes the sources matching the list filters as a sources.yml file
+// the crawler can read. ?format= is yaml (default) or json.
+func (h *SourceHandler) Export(c *gin.Context) {
+	format, err := sourcefile.ParseFormat(c.Query("format"))
+	if err != nil {
+		respondBadRequest(c, "Invalid query parameters", err)
+		return
+	}
+	opts, err := parseListOptions(c)
+	if err != nil {
+		respondBadRequest(c, "Invalid query parameters", err)
+		return
+	}
+	opts.Limit = 0
+	opts.Cursor = ""
+
+	result, err := h.repo.List(c.Request.Context(), opts)
+	if err != nil {
+		respondError(c, err, "sources", "export")
+		return
+	}
+
+	contentType := "application/yaml"
+	if format == sourcefile.FormatJSON {
+		contentType = "application/json"
+	}
+	c.Header("Content-Type", contentType)
+	c.Header("Content-Disposition", `attachment; filename="sources.`+string(format)+`"`)
+	c.Status(http.StatusOK)
+
+	if encodeErr := sourcefile.Encode(c.Writer, result.Sources, format); encodeErr != nil {
+		logger.FromContext(c.Request.Context()).Error("Failed to export sources", logger.Error(encodeErr))
+	}
+}
+
+// importFormat reads ?format=, falling back to the request Content-Type.
+func importFormat(c *gin.Context) (sourcefile.Format, error) {
+	if format := c.Query("format"); format != "" {
+		return sourcefile.ParseFormat(format)
+	}
+	if strings.Contains(c.ContentType(), "json") {
+		return sourcefile.FormatJSON, nil
+	}
+	return sourcefile.FormatYAML, nil
+}
+
+// queryBool parses an optional boolean query parameter.
+func queryBool(c *gin.Context, key string) (bool, error) {
+	raw := c.Query(key)
+	if raw == "" {
+		return false, nil
+	}
+	v, err := strconv.ParseBool(raw)
+	if err != nil {
+		return false, errors.New(key + ": must be true or false")
+	}
+	return v, nil
+}
@@ -0,0 +1,153 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jonesrussell/gosources/internal/logger"
+	"github.com/jonesrussell/gosources/internal/models"
+	"github.com/jonesrussell/gosources/internal/repository"
+)
+
+// UserRequest is the body of POST and PUT /api/v1/users.
+type UserRequest struct {
+	Name   string      `json:"name"`
+	Role   models.Role `json:"role"`
+	Cities []string    `json:"cities"`
+}
+
+func (r *UserRequest) applyTo(user *models.User) {
+	user.Name = r.Name
+	user.Role = r.Role
+	user.Cities = r.Cities
+	if user.Cities == nil {
+		user.Cities = []string{}
+	}
+}
+
+type UserHandler struct {
+	store  repository.UserStore
+	logger logger.Logger
+}
+
+func NewUserHandler(store repository.UserStore, log logger.Logger) *UserHandler {
+	return &UserHandler{
+		store:  store,
+		logger: log,
+	}
+}
+
+func (h *UserHandler) Create(c *gin.Context) {
+	var req UserRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		respondBadRequest(c, "Invalid request body", err)
+		return
+	}
+
+	var user models.User
+	req.applyTo(&user)
+
+	if err := user.Validate(); err != nil {
+		respondError(c, err, "user", "create")
+		return
+	}
+
+	if err := h.store.Create(c.Request.Context(), &user); err != nil {
+		respondError(c, err, "user", "create",
+			logger.String("user_name", user.Name),
+		)
+		return
+	}
+
+	h.logger.Info("User created",
+		logger.String("user_id", user.ID),
+		logger.String("user_name", user.Name),
+		logger.String("role", string(user.Role)),
+		logger.String("actor", repository.ActorFromContext(c.Request.Context())),
+	)
+
+	c.JSON(http.StatusCreated, user)
+}
+
+func (h *UserHandler) List(c *gin.Context) {
+	users, err := h.store.List(c.Request.Context())
+	if err != nil {
+		respondError(c, err, "users", "list")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"users": users,
+		"count": len(users),
+	})
+}
+
+func (h *UserHandler) GetByID(c *gin.Context) {
+	id := c.Param("id")
+
+	user, err := h.store.GetByID(c.Request.Context(), id)
+	if err != nil {
+		respondError(c, err, "user", "get",
+			logger.String("user_id", id),
+		)
+		return
+	}
+
+	c.JSON(http.StatusOK, user)
+}
+
+// Update replaces the user's name, role and cities. The change applies to
+// their existing keys from the next request.
+func (h *UserHandler) Update(c *gin.Context) {
+	id := c.Param("id")
+
+	var req UserRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		respondBadRequest(c, "Invalid request body", err)
+		return
+	}
+
+	user := models.User{ID: id}
+	req.applyTo(&user)
+
+	if err := user.Validate(); err != nil {
+		respondError(c, err, "user", "update",
+			logger.String("user_id", id),
+		)
+		return
+	}
+
+	if err := h.store.Update(c.Request.Context(), &user); err != nil {
+		respondError(c, err, "user", "update",
+			logger.String("user_id", id),
+		)
+		return
+	}
+
+	h.logger.Info("User updated",
+		logger.String("user_id", id),
+		logger.String("role", string(user.Role)),
+		logger.String("actor", repository.ActorFromContext(c.Request.Context())),
+	)
+
+	c.JSON(http.StatusOK, user)
+}
+
+// Delete removes the user and their API keys.
+func (h *UserHandler) Delete(c *gin.Context) {
+	id := c.Param("id")
+
+	if err := h.store.Delete(c.Request.Context(), id); err != nil {
+		respondError(c, err, "user", "delete",
+			logger.String("user_id", id),
+		)
+		return
+	}
+
+	h.logger.Info("User deleted",
+		logger.String("user_id", id),
+		logger.String("actor", repository.ActorFromContext(c.Request.Context())),
+	)
+
+	c.JSON(http.StatusNoContent, nil)
+}
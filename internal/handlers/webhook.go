@@ -0,0 +1,247 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jonesrussell/gosources/internal/logger"
+	"github.com/jonesrussell/gosources/internal/models"
+	"github.com/jonesrussell/gosources/internal/repository"
+	"github.com/jonesrussell/gosources/internal/webhook"
+)
+
+const (
+	defaultDeliveryLimit = 50
+	maxDeliveryLimit     = 500
+)
+
+// WebhookRequest is the body of POST and PUT /api/v1/webhooks. A secret is
+// generated when none is given on create; on update an empty secret keeps the
+// current one.
+type WebhookRequest struct {
+	URL         string             `json:"url"`
+	Secret      string             `json:"secret"`
+	Events      []models.EventType `json:"events"`
+	Description string             `json:"description"`
+	Enabled     *bool              `json:"enabled"` // Defaults to true
+}
+
+type WebhookHandler struct {
+	store      repository.WebhookStore
+	dispatcher *webhook.Dispatcher
+	logger     logger.Logger
+}
+
+func NewWebhookHandler(store repository.WebhookStore, dispatcher *webhook.Dispatcher, log logger.Logger) *WebhookHandler {
+	return &WebhookHandler{
+		store:      store,
+		dispatcher: dispatcher,
+		logger:     log,
+	}
+}
+
+// Create subscribes a URL to events. The response is the only one that
+// includes the secret.
+func (h *WebhookHandler) Create(c *gin.Context) {
+	var req WebhookRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		respondBadRequest(c, "Invalid request body", err)
+		return
+	}
+
+	hook := models.Webhook{}
+	req.applyTo(&hook)
+
+	if hook.Secret == "" {
+		secret, err := webhook.NewSecret()
+		if err != nil {
+			respondError(c, err, "webhook", "create")
+			return
+		}
+		hook.Secret = secret
+	}
+
+	if err := hook.Validate(); err != nil {
+		respondError(c, err, "webhook", "create")
+		return
+	}
+
+	if err := h.store.Create(c.Request.Context(), &hook); err != nil {
+		respondError(c, err, "webhook", "create")
+		return
+	}
+
+	h.logger.Info("Webhook created",
+		logger.String("webhook_id", hook.ID),
+		logger.String("url", hook.URL),
+	)
+
+	c.JSON(http.StatusCreated, hook)
+}
+
+func (h *WebhookHandler) List(c *gin.Context) {
+	hooks, err := h.store.List(c.Request.Context())
+	if err != nil {
+		respondError(c, err, "webhooks", "list")
+		return
+	}
+
+	for i := range hooks {
+		hooks[i].Secret = ""
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"webhooks": hooks,
+		"count":    len(hooks),
+	})
+}
+
+func (h *WebhookHandler) GetByID(c *gin.Context) {
+	id := c.Param("id")
+
+	hook, err := h.store.GetByID(c.Request.Context(), id)
+	if err != nil {
+		respondError(c, err, "webhook", "get",
+			logger.String("webhook_id", id),
+		)
+		return
+	}
+
+	hook.Secret = ""
+	c.JSON(http.StatusOK, hook)
+}
+
+func (h *WebhookHandler) Update(c *gin.Context) {
+	id := c.Param("id")
+	ctx := c.Request.Context()
+
+	var req WebhookRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		respondBadRequest(c, "Invalid request body", err)
+		return
+	}
+
+	hook, err := h.store.GetByID(ctx, id)
+	if err != nil {
+		respondError(c, err, "webhook", "update",
+			logger.String("webhook_id", id),
+		)
+		return
+	}
+
+	req.applyTo(hook)
+
+	if validateErr := hook.Validate(); validateErr != nil {
+		respondError(c, validateErr, "webhook", "update",
+			logger.String("webhook_id", id),
+		)
+		return
+	}
+
+	if updateErr := h.store.Update(ctx, hook); updateErr != nil {
+		respondError(c, updateErr, "webhook", "update",
+			logger.String("webhook_id", id),
+		)
+		return
+	}
+
+	h.logger.Info("Webhook updated",
+		logger.String("webhook_id", id),
+	)
+
+	hook.Secret = ""
+	c.JSON(http.StatusOK, hook)
+}
+
+func (h *WebhookHandler) Delete(c *gin.Context) {
+	id := c.Param("id")
+
+	if err := h.store.Delete(c.Request.Context(), id); err != nil {
+		respondError(c, err, "webhook", "delete",
+			logger.String("webhook_id", id),
+		)
+		return
+	}
+
+	h.logger.Info("Webhook deleted",
+		logger.String("webhook_id", id),
+	)
+
+	c.JSON(http.StatusNoContent, nil)
+}
+
+// ListDeliveries returns the webhook's most recent deliveries, newest first,
+// up to ?limit= (default 50).
+func (h *WebhookHandler) ListDeliveries(c *gin.Context) {
+	id := c.Param("id")
+	ctx := c.Request.Context()
+
+	limit := defaultDeliveryLimit
+	if raw := c.Query("limit"); raw != "" {
+		v, err := strconv.Atoi(raw)
+		if err != nil || v <= 0 {
+			c.JSON(http.StatusBadRequest, ErrorResponse{
+				Error:   "Invalid query parameters",
+				Details: "limit: must be a positive integer",
+			})
+			return
+		}
+		limit = min(v, maxDeliveryLimit)
+	}
+
+	if _, err := h.store.GetByID(ctx, id); err != nil {
+		respondError(c, err, "webhook", "get",
+			logger.String("webhook_id", id),
+		)
+		return
+	}
+
+	deliveries, err := h.store.ListDeliveries(ctx, id, limit)
+	if err != nil {
+		respondError(c, err, "deliveries", "list",
+			logger.String("webhook_id", id),
+		)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"deliveries": deliveries,
+		"count":      len(deliveries),
+	})
+}
+
+// Ping queues a ping delivery so a subscriber can check its endpoint and
+// signature verification.
+func (h *WebhookHandler) Ping(c *gin.Context) {
+	id := c.Param("id")
+	ctx := c.Request.Context()
+
+	hook, err := h.store.GetByID(ctx, id)
+	if err != nil {
+		respondError(c, err, "webhook", "ping",
+			logger.String("webhook_id", id),
+		)
+		return
+	}
+
+	delivery, err := h.dispatcher.Ping(ctx, hook)
+	if err != nil {
+		respondError(c, err, "webhook", "ping",
+			logger.String("webhook_id", id),
+		)
+		return
+	}
+
+	c.JSON(http.StatusAccepted, delivery)
+}
+
+func (r *WebhookRequest) applyTo(hook *models.Webhook) {
+	hook.URL = r.URL
+	hook.Events = r.Events
+	hook.Description = r.Description
+	hook.Enabled = r.Enabled == nil || *r.Enabled
+	if r.Secret != "" {
+		hook.Secret = r.Secret
+	}
+}
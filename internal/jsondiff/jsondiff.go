@@ -0,0 +1,124 @@
+// Package jsondiff computes the structural difference between two JSON
+// documents. Changes are expressed as RFC 6902 operations with JSON Pointer
+// paths, so a diff can be applied as a JSON Patch.
+package jsondiff
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// Operation names from RFC 6902.
+const (
+	OpAdd     = "add"
+	OpRemove  = "remove"
+	OpReplace = "replace"
+)
+
+// Change is a single difference. Old holds the previous value for remove and
+// replace operations; Value holds the new value for add and replace. Both
+// are always written, so a change to or from null still carries its value.
+type Change struct {
+	Op    string `json:"op"`
+	Path  string `json:"path"`
+	Value any    `json:"value"`
+	Old   any    `json:"old"`
+}
+
+// Diff returns the changes that turn a into b. Both values are marshalled to
+// JSON first, so struct tags decide the field names.
+func Diff(a, b any) ([]Change, error) {
+	left, err := normalize(a)
+	if err != nil {
+		return nil, err
+	}
+	right, err := normalize(b)
+	if err != nil {
+		return nil, err
+	}
+
+	changes := []Change{}
+	walk("", left, right, &changes)
+	return changes, nil
+}
+
+func normalize(v any) (any, error) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil, fmt.Errorf("marshal: %w", err)
+	}
+	var out any
+	if unmarshalErr := json.Unmarshal(data, &out); unmarshalErr != nil {
+		return nil, fmt.Errorf("unmarshal: %w", unmarshalErr)
+	}
+	return out, nil
+}
+
+func walk(path string, a, b any, changes *[]Change) {
+	switch av := a.(type) {
+	case map[string]any:
+		if bv, ok := b.(map[string]any); ok {
+			walkObject(path, av, bv, changes)
+			return
+		}
+	case []any:
+		if bv, ok := b.([]any); ok {
+			walkArray(path, av, bv, changes)
+			return
+		}
+	}
+
+	if !reflect.DeepEqual(a, b) {
+		*changes = append(*changes, Change{Op: OpReplace, Path: path, Value: b, Old: a})
+	}
+}
+
+func walkObject(path string, a, b map[string]any, changes *[]Change) {
+	keys := make([]string, 0, len(a)+len(b))
+	for k := range a {
+		keys = append(keys, k)
+	}
+	for k := range b {
+		if _, ok := a[k]; !ok {
+			keys = append(keys, k)
+		}
+	}
+	slices.Sort(keys)
+
+	for _, k := range keys {
+		child := path + "/" + escape(k)
+		av, inA := a[k]
+		bv, inB := b[k]
+		switch {
+		case !inA:
+			*changes = append(*changes, Change{Op: OpAdd, Path: child, Value: bv})
+		case !inB:
+			*changes = append(*changes, Change{Op: OpRemove, Path: child, Old: av})
+		default:
+			walk(child, av, bv, changes)
+		}
+	}
+}
+
+func walkArray(path string, a, b []any, changes *[]Change) {
+	common := min(len(a), len(b))
+	for i := range common {
+		walk(path+"/"+strconv.Itoa(i), a[i], b[i], changes)
+	}
+	for i := common; i < len(b); i++ {
+		*changes = append(*changes, Change{Op: OpAdd, Path: path + "/" + strconv.Itoa(i), Value: b[i]})
+	}
+	// Remove from the end so each index stays valid when applied in order.
+	for i := len(a) - 1; i >= common; i-- {
+		*changes = append(*changes, Change{Op: OpRemove, Path: path + "/" + strconv.Itoa(i), Old: a[i]})
+	}
+}
+
+// escape encodes a key as a JSON Pointer reference token (RFC 6901).
+func escape(key string) string {
+	return strings.NewReplacer("~", "~0", "/", "~1").Replace(key)
+}
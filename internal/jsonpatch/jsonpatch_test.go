@@ -0,0 +1,281 @@
+package jsonpatch
+
+import (
+	"encoding/json"
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestMergePatch(t *testing.T) {
+	tests := []struct {
+		name  string
+		doc   string
+		patch string
+		want  string
+	}{
+		{
+			name:  "replaces a member",
+			doc:   `{"a":"b"}`,
+			patch: `{"a":"c"}`,
+			want:  `{"a":"c"}`,
+		},
+		{
+			name:  "adds a member",
+			doc:   `{"a":"b"}`,
+			patch: `{"b":"c"}`,
+			want:  `{"a":"b","b":"c"}`,
+		},
+		{
+			name:  "null removes a member",
+			doc:   `{"a":"b","b":"c"}`,
+			patch: `{"a":null}`,
+			want:  `{"b":"c"}`,
+		},
+		{
+			name:  "merges nested objects",
+			doc:   `{"a":{"b":"c","d":"e"}}`,
+			patch: `{"a":{"d":null,"f":"g"}}`,
+			want:  `{"a":{"b":"c","f":"g"}}`,
+		},
+		{
+			name:  "replaces arrays whole",
+			doc:   `{"a":[1,2,3]}`,
+			patch: `{"a":[4]}`,
+			want:  `{"a":[4]}`,
+		},
+		{
+			name:  "replaces a scalar with an object",
+			doc:   `{"a":"b"}`,
+			patch: `{"a":{"c":null,"d":1}}`,
+			want:  `{"a":{"d":1}}`,
+		},
+		{
+			name:  "non-object patch replaces the document",
+			doc:   `{"a":"b"}`,
+			patch: `["c"]`,
+			want:  `["c"]`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := MergePatch([]byte(tt.doc), []byte(tt.patch))
+			if err != nil {
+				t.Fatalf("MergePatch() error = %v", err)
+			}
+			assertJSONEqual(t, got, tt.want)
+		})
+	}
+}
+
+func TestMergePatchInvalid(t *testing.T) {
+	_, err := MergePatch([]byte(`{}`), []byte(`{`))
+	if !errors.Is(err, ErrInvalidPatch) {
+		t.Fatalf("MergePatch() error = %v, want ErrInvalidPatch", err)
+	}
+}
+
+func TestApply(t *testing.T) {
+	tests := []struct {
+		name    string
+		doc     string
+		patch   string
+		want    string
+		wantErr error
+	}{
+		{
+			name:  "add member",
+			doc:   `{"a":1}`,
+			patch: `[{"op":"add","path":"/b","value":2}]`,
+			want:  `{"a":1,"b":2}`,
+		},
+		{
+			name:  "add replaces an existing member",
+			doc:   `{"a":1}`,
+			patch: `[{"op":"add","path":"/a","value":2}]`,
+			want:  `{"a":2}`,
+		},
+		{
+			name:  "add explicit null",
+			doc:   `{"a":1}`,
+			patch: `[{"op":"add","path":"/b","value":null}]`,
+			want:  `{"a":1,"b":null}`,
+		},
+		{
+			name:  "add inserts into an array",
+			doc:   `{"a":[1,3]}`,
+			patch: `[{"op":"add","path":"/a/1","value":2}]`,
+			want:  `{"a":[1,2,3]}`,
+		},
+		{
+			name:  "add appends with -",
+			doc:   `{"a":[1]}`,
+			patch: `[{"op":"add","path":"/a/-","value":2}]`,
+			want:  `{"a":[1,2]}`,
+		},
+		{
+			name:  "add at array length appends",
+			doc:   `{"a":[1]}`,
+			patch: `[{"op":"add","path":"/a/1","value":2}]`,
+			want:  `{"a":[1,2]}`,
+		},
+		{
+			name:  "add replaces the whole document",
+			doc:   `{"a":1}`,
+			patch: `[{"op":"add","path":"","value":[1]}]`,
+			want:  `[1]`,
+		},
+		{
+			name:    "add past the end of an array",
+			doc:     `{"a":[1]}`,
+			patch:   `[{"op":"add","path":"/a/2","value":2}]`,
+			wantErr: ErrPathNotFound,
+		},
+		{
+			name:    "add under a missing parent",
+			doc:     `{}`,
+			patch:   `[{"op":"add","path":"/a/b","value":1}]`,
+			wantErr: ErrPathNotFound,
+		},
+		{
+			name:    "add without value",
+			doc:     `{}`,
+			patch:   `[{"op":"add","path":"/a"}]`,
+			wantErr: ErrInvalidPatch,
+		},
+		{
+			name:  "remove member",
+			doc:   `{"a":1,"b":2}`,
+			patch: `[{"op":"remove","path":"/a"}]`,
+			want:  `{"b":2}`,
+		},
+		{
+			name:  "remove array element",
+			doc:   `{"a":[1,2,3]}`,
+			patch: `[{"op":"remove","path":"/a/1"}]`,
+			want:  `{"a":[1,3]}`,
+		},
+		{
+			name:    "remove missing member",
+			doc:     `{"a":1}`,
+			patch:   `[{"op":"remove","path":"/b"}]`,
+			wantErr: ErrPathNotFound,
+		},
+		{
+			name:    "remove the whole document",
+			doc:     `{"a":1}`,
+			patch:   `[{"op":"remove","path":""}]`,
+			wantErr: ErrInvalidPatch,
+		},
+		{
+			name:  "replace nested member",
+			doc:   `{"a":{"b":1}}`,
+			patch: `[{"op":"replace","path":"/a/b","value":"x"}]`,
+			want:  `{"a":{"b":"x"}}`,
+		},
+		{
+			name:    "replace missing member",
+			doc:     `{"a":1}`,
+			patch:   `[{"op":"replace","path":"/b","value":2}]`,
+			wantErr: ErrPathNotFound,
+		},
+		{
+			name:  "move member",
+			doc:   `{"a":{"b":1},"c":{}}`,
+			patch: `[{"op":"move","from":"/a/b","path":"/c/d"}]`,
+			want:  `{"a":{},"c":{"d":1}}`,
+		},
+		{
+			name:    "move into own child",
+			doc:     `{"a":{"b":1}}`,
+			patch:   `[{"op":"move","from":"/a","path":"/a/b"}]`,
+			wantErr: ErrInvalidPatch,
+		},
+		{
+			name:  "copy does not share values",
+			doc:   `{"a":{"b":1}}`,
+			patch: `[{"op":"copy","from":"/a","path":"/c"},{"op":"replace","path":"/c/b","value":2}]`,
+			want:  `{"a":{"b":1},"c":{"b":2}}`,
+		},
+		{
+			name:  "test passes",
+			doc:   `{"a":[1,{"b":"c"}]}`,
+			patch: `[{"op":"test","path":"/a","value":[1,{"b":"c"}]}]`,
+			want:  `{"a":[1,{"b":"c"}]}`,
+		},
+		{
+			name:    "test fails",
+			doc:     `{"a":1}`,
+			patch:   `[{"op":"test","path":"/a","value":2}]`,
+			wantErr: ErrTestFailed,
+		},
+		{
+			name:  "escaped pointer tokens",
+			doc:   `{"a/b":1,"c~d":2}`,
+			patch: `[{"op":"replace","path":"/a~1b","value":3},{"op":"remove","path":"/c~0d"}]`,
+			want:  `{"a/b":3}`,
+		},
+		{
+			name:    "pointer without leading slash",
+			doc:     `{"a":1}`,
+			patch:   `[{"op":"remove","path":"a"}]`,
+			wantErr: ErrInvalidPatch,
+		},
+		{
+			name:    "array index with leading zero",
+			doc:     `{"a":[1,2]}`,
+			patch:   `[{"op":"remove","path":"/a/01"}]`,
+			wantErr: ErrInvalidPatch,
+		},
+		{
+			name:    "unknown op",
+			doc:     `{}`,
+			patch:   `[{"op":"frobnicate","path":"/a"}]`,
+			wantErr: ErrInvalidPatch,
+		},
+		{
+			name:    "patch is not an array",
+			doc:     `{}`,
+			patch:   `{"op":"add","path":"/a","value":1}`,
+			wantErr: ErrInvalidPatch,
+		},
+		{
+			name:    "later failure rejects the whole patch",
+			doc:     `{"a":1}`,
+			patch:   `[{"op":"replace","path":"/a","value":2},{"op":"test","path":"/a","value":1}]`,
+			wantErr: ErrTestFailed,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Apply([]byte(tt.doc), []byte(tt.patch))
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("Apply() error = %v, want %v", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Apply() error = %v", err)
+			}
+			assertJSONEqual(t, got, tt.want)
+		})
+	}
+}
+
+func assertJSONEqual(t *testing.T, got []byte, want string) {
+	t.Helper()
+
+	var g, w any
+	if err := json.Unmarshal(got, &g); err != nil {
+		t.Fatalf("unmarshal result %s: %v", got, err)
+	}
+	if err := json.Unmarshal([]byte(want), &w); err != nil {
+		t.Fatalf("unmarshal want %s: %v", want, err)
+	}
+	if !reflect.DeepEqual(g, w) {
+		t.Errorf("got %s, want %s", got, want)
+	}
+}
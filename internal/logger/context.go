@@ -0,0 +1,33 @@
+package logger
+
+import (
+	"context"
+	"sync/atomic"
+)
+
+type contextKey struct{}
+
+var defaultLogger atomic.Pointer[Logger]
+
+// SetDefault sets the logger FromContext returns for contexts without one.
+// Until it is called that is a no-op logger.
+func SetDefault(log Logger) {
+	defaultLogger.Store(&log)
+}
+
+// NewContext returns a context carrying log, for FromContext to return.
+func NewContext(ctx context.Context, log Logger) context.Context {
+	return context.WithValue(ctx, contextKey{}, log)
+}
+
+// FromContext returns the logger stored by NewContext, usually one carrying
+// the request's request_id and trace fields, or the default logger.
+func FromContext(ctx context.Context) Logger {
+	if log, ok := ctx.Value(contextKey{}).(Logger); ok {
+		return log
+	}
+	if log := defaultLogger.Load(); log != nil {
+		return *log
+	}
+	return NewNopLogger()
+}
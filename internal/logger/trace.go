@@ -0,0 +1,22 @@
+package logger
+
+import (
+	"context"
+
+	"go.opentelemetry.io/otel/trace"
+)
+
+// WithTrace returns log with trace_id and span_id fields for the span in ctx,
+// so log lines can be matched to traces. log is returned unchanged when ctx
+// carries no span.
+func WithTrace(ctx context.Context, log Logger) Logger {
+	spanContext := trace.SpanContextFromContext(ctx)
+	if !spanContext.IsValid() {
+		return log
+	}
+
+	return log.With(
+		String("trace_id", spanContext.TraceID().String()),
+		String("span_id", spanContext.SpanID().String()),
+	)
+}
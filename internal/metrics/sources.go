@@ -0,0 +1,101 @@
+package metrics
+
+import (
+	"context"
+	"time"
+
+	"github.com/jonesrussell/gosources/internal/logger"
+	"github.com/jonesrussell/gosources/internal/repository"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// collectTimeout bounds the query made for each scrape.
+const collectTimeout = 5 * time.Second
+
+var (
+	sourcesDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, "", "sources"),
+		"Number of sources.",
+		nil, nil,
+	)
+	enabledSourcesDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, "", "sources_enabled"),
+		"Number of enabled sources.",
+		nil, nil,
+	)
+	sourcesByCityDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, "", "sources_by_city"),
+		"Number of sources per city_name; sources without a city have an empty label.",
+		[]string{"city_name"}, nil,
+	)
+	sourcesByIndexDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, "", "sources_by_article_index"),
+		"Number of sources per article_index.",
+		[]string{"article_index"}, nil,
+	)
+	scrapeErrorDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, "", "sources_scrape_error"),
+		"1 when the sources could not be counted during this scrape.",
+		nil, nil,
+	)
+)
+
+// sourceCollector counts sources when scraped, so the gauges are never
+// stale and need no bookkeeping on writes.
+type sourceCollector struct {
+	store  repository.SourceStore
+	logger logger.Logger
+}
+
+func newSourceCollector(store repository.SourceStore, log logger.Logger) *sourceCollector {
+	return &sourceCollector{
+		store:  store,
+		logger: log,
+	}
+}
+
+func (c *sourceCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- sourcesDesc
+	ch <- enabledSourcesDesc
+	ch <- sourcesByCityDesc
+	ch <- sourcesByIndexDesc
+	ch <- scrapeErrorDesc
+}
+
+func (c *sourceCollector) Collect(ch chan<- prometheus.Metric) {
+	ctx, cancel := context.WithTimeout(context.Background(), collectTimeout)
+	defer cancel()
+
+	result, err := c.store.List(ctx, repository.ListOptions{})
+	if err != nil {
+		c.logger.Error("Failed to count sources for metrics", logger.Error(err))
+		ch <- prometheus.MustNewConstMetric(scrapeErrorDesc, prometheus.GaugeValue, 1)
+		return
+	}
+
+	enabled := 0
+	byCity := make(map[string]int)
+	byIndex := make(map[string]int)
+	for i := range result.Sources {
+		source := &result.Sources[i]
+		if source.Enabled {
+			enabled++
+		}
+		city := ""
+		if source.CityName != nil {
+			city = *source.CityName
+		}
+		byCity[city]++
+		byIndex[source.ArticleIndex]++
+	}
+
+	ch <- prometheus.MustNewConstMetric(scrapeErrorDesc, prometheus.GaugeValue, 0)
+	ch <- prometheus.MustNewConstMetric(sourcesDesc, prometheus.GaugeValue, float64(len(result.Sources)))
+	ch <- prometheus.MustNewConstMetric(enabledSourcesDesc, prometheus.GaugeValue, float64(enabled))
+	for city, n := range byCity {
+		ch <- prometheus.MustNewConstMetric(sourcesByCityDesc, prometheus.GaugeValue, float64(n), city)
+	}
+	for index, n := range byIndex {
+		ch <- prometheus.MustNewConstMetric(sourcesByIndexDesc, prometheus.GaugeValue, float64(n), index)
+	}
+}
@@ -0,0 +1,84 @@
+package models
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+)
+
+// Scope grants an API key access to a group of endpoints.
+type Scope string
+
+const (
+	ScopeSourcesRead  Scope = "sources:read"
+	ScopeSourcesWrite Scope = "sources:write" // Implies sources:read
+	ScopeCitiesRead   Scope = "cities:read"
+	ScopeMetricsRead  Scope = "metrics:read" // Scraping /metrics
+	ScopeAdmin        Scope = "admin"        // Implies every other scope
+)
+
+// Scopes lists every scope in a stable order.
+var Scopes = []Scope{
+	ScopeSourcesRead,
+	ScopeSourcesWrite,
+	ScopeCitiesRead,
+	ScopeMetricsRead,
+	ScopeAdmin,
+}
+
+// ParseScopes splits a comma-separated list such as "sources:read,cities:read".
+func ParseScopes(s string) []Scope {
+	var scopes []Scope
+	for part := range strings.SplitSeq(s, ",") {
+		if part = strings.TrimSpace(part); part != "" {
+			scopes = append(scopes, Scope(part))
+		}
+	}
+	return scopes
+}
+
+// APIKey authenticates a client. Only a hash of the key is stored; the key
+// itself is shown once, when it is created.
+type APIKey struct {
+	ID         string     `json:"id" db:"id"`
+	Name       string     `json:"name" db:"name"`
+	Prefix     string     `json:"prefix" db:"prefix"` // Leading characters of the key, to tell keys apart
+	Hash       string     `json:"-" db:"key_hash"`
+	UserID     *string    `json:"user_id,omitempty" db:"user_id"` // Keys without a user are service keys
+	Scopes     []Scope    `json:"scopes" db:"scopes"`
+	CreatedAt  time.Time  `json:"created_at" db:"created_at"`
+	LastUsedAt *time.Time `json:"last_used_at,omitempty" db:"last_used_at"`
+	RevokedAt  *time.Time `json:"revoked_at,omitempty" db:"revoked_at"`
+}
+
+// Allows reports whether the key grants scope.
+func (k *APIKey) Allows(scope Scope) bool {
+	if k.RevokedAt != nil {
+		return false
+	}
+	if slices.Contains(k.Scopes, ScopeAdmin) || slices.Contains(k.Scopes, scope) {
+		return true
+	}
+	return scope == ScopeSourcesRead && slices.Contains(k.Scopes, ScopeSourcesWrite)
+}
+
+// Validate checks the key's name and scopes.
+func (k *APIKey) Validate() error {
+	var errs ValidationErrors
+
+	if strings.TrimSpace(k.Name) == "" {
+		errs.add("name", "is required")
+	}
+
+	if len(k.Scopes) == 0 {
+		errs.add("scopes", "must list at least one scope")
+	}
+	for i, scope := range k.Scopes {
+		if !slices.Contains(Scopes, scope) {
+			errs.add(fmt.Sprintf("scopes[%d]", i), fmt.Sprintf("unknown scope %q", scope))
+		}
+	}
+
+	return errs.err()
+}
@@ -0,0 +1,152 @@
+package models
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// City is a place gopost publishes to. Sources belong to a city by setting
+// city_name to its Name, which cannot change once the city is created.
+type City struct {
+	ID           string    `json:"id" db:"id"`
+	Name         string    `json:"name" db:"name"` // Key referenced by sources.city_name, e.g. "sudbury_com"
+	DisplayName  string    `json:"display_name" db:"display_name"`
+	Region       string    `json:"region,omitempty" db:"region"`     // Province, state or region
+	Timezone     string    `json:"timezone,omitempty" db:"timezone"` // IANA name such as "America/Toronto"
+	GroupID      string    `json:"group_id,omitempty" db:"group_id"` // Drupal group UUID for sources without their own
+	DefaultIndex string    `json:"default_index,omitempty" db:"default_index"`
+	CreatedAt    time.Time `json:"created_at" db:"created_at"`
+	UpdatedAt    time.Time `json:"updated_at" db:"updated_at"`
+}
+
+// CityIndex is a city as gopost reads it from GET /api/v1/cities: its
+// enabled sources, the indexes their articles are stored in and the index
+// alias gopost searches them all through.
+type CityIndex struct {
+	Name        string       `json:"name"`
+	Index       string       `json:"index"`       // The first source's article index, as when a city had one source
+	IndexAlias  string       `json:"index_alias"` // Alias over Indexes; see City.IndexAlias
+	Indexes     []string     `json:"indexes"`     // Distinct article indexes of Sources, sorted
+	GroupID     string       `json:"group_id,omitempty"`
+	DisplayName string       `json:"display_name,omitempty"`
+	Region      string       `json:"region,omitempty"`
+	Timezone    string       `json:"timezone,omitempty"`
+	Sources     []CitySource `json:"sources"`
+}
+
+// CitySource is an enabled source of a CityIndex.
+type CitySource struct {
+	ID           string `json:"id"`
+	Name         string `json:"name"`
+	ArticleIndex string `json:"article_index"`
+	GroupID      string `json:"group_id,omitempty"` // The source's group, or the city's
+}
+
+// NewCityIndex describes the city with the given enabled sources, which
+// should be sorted by name. Index and GroupID are the first source's, as
+// they were when a city had a single source; GroupID is the city's when it
+// has no sources.
+func NewCityIndex(city *City, sources []Source) CityIndex {
+	ci := CityIndex{
+		Name:        city.Name,
+		GroupID:     city.GroupID,
+		DisplayName: city.DisplayName,
+		Region:      city.Region,
+		Timezone:    city.Timezone,
+		IndexAlias:  city.IndexAlias(),
+		Indexes:     []string{},
+		Sources:     make([]CitySource, 0, len(sources)),
+	}
+
+	for i := range sources {
+		source := CitySource{
+			ID:           sources[i].ID,
+			Name:         sources[i].Name,
+			ArticleIndex: sources[i].ArticleIndex,
+			GroupID:      city.GroupID,
+		}
+		if sources[i].GroupID != nil {
+			source.GroupID = *sources[i].GroupID
+		}
+		if i == 0 {
+			ci.Index = source.ArticleIndex
+			ci.GroupID = source.GroupID
+		}
+		ci.Sources = append(ci.Sources, source)
+
+		if !slices.Contains(ci.Indexes, source.ArticleIndex) {
+			ci.Indexes = append(ci.Indexes, source.ArticleIndex)
+		}
+	}
+	slices.Sort(ci.Indexes)
+
+	return ci
+}
+
+// IndexAlias names the alias gopost searches all of the city's article
+// indexes through: the city's DefaultIndex when set, and otherwise the
+// city's name as an index name followed by "_articles", such as
+// "sudbury_com_articles". gosources only reports the name; the alias is
+// created over the indexes in Elasticsearch.
+func (c *City) IndexAlias() string {
+	if c.DefaultIndex != "" {
+		return c.DefaultIndex
+	}
+
+	alias := strings.Map(func(r rune) rune {
+		if strings.ContainsRune(invalidIndexChars, r) {
+			return '_'
+		}
+		return r
+	}, strings.ToLower(c.Name))
+	return strings.TrimLeft(alias, "-_+") + "_articles"
+}
+
+// Validate checks the city's fields.
+func (c *City) Validate() error {
+	var errs ValidationErrors
+
+	switch name := strings.TrimSpace(c.Name); {
+	case name == "":
+		errs.add("name", "is required")
+	case name != c.Name:
+		errs.add("name", "must not start or end with spaces")
+	case len(c.Name) > maxNameLength:
+		errs.add("name", fmt.Sprintf("must be at most %d characters", maxNameLength))
+	}
+
+	switch name := strings.TrimSpace(c.DisplayName); {
+	case name == "":
+		errs.add("display_name", "is required")
+	case len(c.DisplayName) > maxNameLength:
+		errs.add("display_name", fmt.Sprintf("must be at most %d characters", maxNameLength))
+	}
+
+	if len(c.Region) > maxNameLength {
+		errs.add("region", fmt.Sprintf("must be at most %d characters", maxNameLength))
+	}
+
+	if c.Timezone != "" {
+		if _, err := time.LoadLocation(c.Timezone); err != nil {
+			errs.add("timezone", "must be an IANA time zone such as \"America/Toronto\"")
+		}
+	}
+
+	if c.GroupID != "" {
+		if _, err := uuid.Parse(c.GroupID); err != nil {
+			errs.add("group_id", "must be a UUID")
+		}
+	}
+
+	if c.DefaultIndex != "" {
+		if msg := validateIndexName(c.DefaultIndex); msg != "" {
+			errs.add("default_index", msg)
+		}
+	}
+
+	return errs.err()
+}
@@ -0,0 +1,93 @@
+package models
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestNewCityIndex(t *testing.T) {
+	group := "550e8400-e29b-41d4-a716-446655440000"
+	source := func(name, index string) Source {
+		return Source{ID: name, Name: name, ArticleIndex: index}
+	}
+
+	tests := []struct {
+		name        string
+		city        City
+		sources     []Source
+		wantIndex   string
+		wantAlias   string
+		wantIndexes []string
+		wantGroup   string
+	}{
+		{
+			name:        "one source",
+			city:        City{Name: "sudbury_com", GroupID: group},
+			sources:     []Source{source("Sudbury.com", "sudbury_com_articles_a")},
+			wantIndex:   "sudbury_com_articles_a",
+			wantAlias:   "sudbury_com_articles",
+			wantIndexes: []string{"sudbury_com_articles_a"},
+			wantGroup:   group,
+		},
+		{
+			name: "several outlets",
+			city: City{Name: "sudbury_com"},
+			sources: []Source{
+				source("Sudbury Star", "sudbury_star_articles"),
+				source("Sudbury.com", "sudbury_com_articles_a"),
+				source("Sudbury.com opinion", "sudbury_com_articles_a"),
+			},
+			wantIndex:   "sudbury_star_articles",
+			wantAlias:   "sudbury_com_articles",
+			wantIndexes: []string{"sudbury_com_articles_a", "sudbury_star_articles"},
+		},
+		{
+			name: "default index names the alias",
+			city: City{Name: "sudbury_com", DefaultIndex: "sudbury_articles"},
+			sources: []Source{
+				source("Sudbury.com", "sudbury_com_articles_a"),
+				source("Sudbury Star", "sudbury_star_articles"),
+			},
+			wantIndex:   "sudbury_com_articles_a",
+			wantAlias:   "sudbury_articles",
+			wantIndexes: []string{"sudbury_com_articles_a", "sudbury_star_articles"},
+		},
+		{
+			name:        "alias from a name with invalid characters",
+			city:        City{Name: "_Grand Sudbury/Nord"},
+			sources:     []Source{source("Sudbury.com", "sudbury_com_articles_a")},
+			wantIndex:   "sudbury_com_articles_a",
+			wantAlias:   "grand_sudbury_nord_articles",
+			wantIndexes: []string{"sudbury_com_articles_a"},
+		},
+		{
+			name:        "no sources",
+			city:        City{Name: "sudbury_com", GroupID: group},
+			wantIndex:   "",
+			wantAlias:   "sudbury_com_articles",
+			wantIndexes: []string{},
+			wantGroup:   group,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ci := NewCityIndex(&tt.city, tt.sources)
+			if ci.Index != tt.wantIndex {
+				t.Errorf("Index = %q, want %q", ci.Index, tt.wantIndex)
+			}
+			if ci.IndexAlias != tt.wantAlias {
+				t.Errorf("IndexAlias = %q, want %q", ci.IndexAlias, tt.wantAlias)
+			}
+			if !slices.Equal(ci.Indexes, tt.wantIndexes) || ci.Indexes == nil {
+				t.Errorf("Indexes = %#v, want %#v", ci.Indexes, tt.wantIndexes)
+			}
+			if ci.GroupID != tt.wantGroup {
+				t.Errorf("GroupID = %q, want %q", ci.GroupID, tt.wantGroup)
+			}
+			if len(ci.Sources) != len(tt.sources) {
+				t.Errorf("got %d sources, want %d", len(ci.Sources), len(tt.sources))
+			}
+		})
+	}
+}
@@ -0,0 +1,40 @@
+package models
+
+import "time"
+
+// EventType names a change that downstream consumers can subscribe to.
+type EventType string
+
+const (
+	EventSourceCreated  EventType = "source.created"
+	EventSourceUpdated  EventType = "source.updated"
+	EventSourceDeleted  EventType = "source.deleted"
+	EventSourceEnabled  EventType = "source.enabled"
+	EventSourceDisabled EventType = "source.disabled"
+)
+
+// SourceEventTypes lists every source event in a stable order.
+var SourceEventTypes = []EventType{
+	EventSourceCreated,
+	EventSourceUpdated,
+	EventSourceDeleted,
+	EventSourceEnabled,
+	EventSourceDisabled,
+}
+
+// SourceEvent describes one change to a source. Source is the state after the
+// change and is nil for deletions; Previous is the state before it and is nil
+// for creations.
+//
+// Events read from the event log carry their Sequence, and ID is that
+// sequence in decimal. Actor is empty for changes made outside the API.
+type SourceEvent struct {
+	ID         string    `json:"id"`
+	Sequence   int64     `json:"-"`
+	Type       EventType `json:"event"`
+	SourceID   string    `json:"source_id"`
+	Actor      string    `json:"actor"`
+	OccurredAt time.Time `json:"occurred_at"`
+	Source     *Source   `json:"source"`
+	Previous   *Source   `json:"previous"`
+}
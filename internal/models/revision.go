@@ -0,0 +1,24 @@
+package models
+
+import "time"
+
+// RevisionAction records what kind of change produced a revision.
+type RevisionAction string
+
+const (
+	RevisionCreate  RevisionAction = "create"
+	RevisionUpdate  RevisionAction = "update"
+	RevisionDelete  RevisionAction = "delete"
+	RevisionRestore RevisionAction = "restore"
+)
+
+// SourceRevision is a full snapshot of a source taken after each change.
+// Delete revisions hold the source as it was just before deletion.
+type SourceRevision struct {
+	SourceID  string         `json:"source_id" db:"source_id"`
+	Revision  int            `json:"revision" db:"revision"`
+	Action    RevisionAction `json:"action" db:"action"`
+	Actor     string         `json:"actor" db:"actor"`
+	Snapshot  Source         `json:"snapshot" db:"snapshot"`
+	CreatedAt time.Time      `json:"created_at" db:"created_at"`
+}
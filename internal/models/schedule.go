@@ -0,0 +1,257 @@
+package models
+
+import (
+	"fmt"
+	"iter"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/robfig/cron/v3"
+)
+
+const (
+	minScheduleInterval = time.Minute
+	maxScheduleInterval = 24 * time.Hour
+
+	// scheduleSearchDays is how many days without a run end Runs. Any valid
+	// times or interval schedule runs at least once a week.
+	scheduleSearchDays = 8
+)
+
+// cronParser accepts standard five-field expressions and descriptors such as
+// @daily. @every is rejected by Validate in favor of Interval.
+var cronParser = cron.NewParser(
+	cron.Minute | cron.Hour | cron.Dom | cron.Month | cron.Dow | cron.Descriptor,
+)
+
+var weekdays = map[string]time.Weekday{
+	"sun": time.Sunday,
+	"mon": time.Monday,
+	"tue": time.Tuesday,
+	"wed": time.Wednesday,
+	"thu": time.Thursday,
+	"fri": time.Friday,
+	"sat": time.Saturday,
+}
+
+// Schedule describes when a source is crawled. Exactly one of Cron, Interval
+// and Times is set. Weekdays limits Interval and Times to those days, and
+// every time is read in Timezone, which defaults to UTC.
+type Schedule struct {
+	Cron     string   `json:"cron,omitempty" yaml:"cron,omitempty"`         // Five-field cron expression, e.g. "30 6 * * 1-5"
+	Interval string   `json:"interval,omitempty" yaml:"interval,omitempty"` // Runs every interval of wall-clock time from midnight, e.g. "6h"
+	Times    []string `json:"times,omitempty" yaml:"times,omitempty"`       // Times of day in HH:MM format
+	Weekdays []string `json:"weekdays,omitempty" yaml:"weekdays,omitempty"` // mon, tue, ... sun; empty means every day
+	Timezone string   `json:"timezone,omitempty" yaml:"timezone,omitempty"` // IANA name such as "America/Toronto"
+}
+
+// EffectiveSchedule returns the source's schedule. Sources that only set the
+// legacy time list run at those times every day in UTC; nil means the
+// source has no schedule.
+func (s *Source) EffectiveSchedule() *Schedule {
+	if s.Schedule != nil {
+		return s.Schedule
+	}
+	if len(s.Time) == 0 {
+		return nil
+	}
+	return &Schedule{Times: slices.Clone(s.Time)}
+}
+
+// Location returns the schedule's time zone.
+func (s *Schedule) Location() (*time.Location, error) {
+	if s.Timezone == "" {
+		return time.UTC, nil
+	}
+	loc, err := time.LoadLocation(s.Timezone)
+	if err != nil {
+		return nil, fmt.Errorf("load timezone %q: %w", s.Timezone, err)
+	}
+	return loc, nil
+}
+
+// Next returns up to n run times after the given time, in the schedule's
+// time zone. A cron expression that never matches, such as February 30th,
+// yields no runs.
+func (s *Schedule) Next(after time.Time, n int) ([]time.Time, error) {
+	runs, err := s.Runs(after)
+	if err != nil {
+		return nil, err
+	}
+
+	next := make([]time.Time, 0, n)
+	for t := range runs {
+		if len(next) == n {
+			break
+		}
+		next = append(next, t)
+	}
+	return next, nil
+}
+
+// Between returns the run times in [from, to), in the schedule's time zone.
+func (s *Schedule) Between(from, to time.Time) ([]time.Time, error) {
+	runs, err := s.Runs(from.Add(-time.Nanosecond))
+	if err != nil {
+		return nil, err
+	}
+
+	var between []time.Time
+	for t := range runs {
+		if !t.Before(to) {
+			break
+		}
+		between = append(between, t)
+	}
+	return between, nil
+}
+
+// CronSpec parses Cron into its per-field bit sets, for rendering the
+// schedule in other formats such as iCalendar recurrence rules.
+func (s *Schedule) CronSpec() (*cron.SpecSchedule, error) {
+	parsed, err := cronParser.Parse(s.Cron)
+	if err != nil {
+		return nil, fmt.Errorf("parse cron: %w", err)
+	}
+	spec, ok := parsed.(*cron.SpecSchedule)
+	if !ok {
+		return nil, fmt.Errorf("parse cron: %q is not a field-based expression", s.Cron)
+	}
+	return spec, nil
+}
+
+// Runs iterates over the run times after the given time, in order and in the
+// schedule's time zone. The sequence ends when the schedule has no more runs.
+func (s *Schedule) Runs(after time.Time) (iter.Seq[time.Time], error) {
+	loc, err := s.Location()
+	if err != nil {
+		return nil, err
+	}
+	after = after.In(loc)
+
+	if s.Cron != "" {
+		spec, parseErr := cronParser.Parse(s.Cron)
+		if parseErr != nil {
+			return nil, fmt.Errorf("parse cron: %w", parseErr)
+		}
+
+		return func(yield func(time.Time) bool) {
+			for t := spec.Next(after); !t.IsZero(); t = spec.Next(t) {
+				if !yield(t) {
+					return
+				}
+			}
+		}, nil
+	}
+
+	days := make(map[time.Weekday]bool, len(s.Weekdays))
+	for _, day := range s.Weekdays {
+		days[weekdays[strings.ToLower(day)]] = true
+	}
+
+	return func(yield func(time.Time) bool) {
+		midnight := time.Date(after.Year(), after.Month(), after.Day(), 0, 0, 0, 0, loc)
+		for day, idle := 0, 0; idle <= scheduleSearchDays; day++ {
+			date := midnight.AddDate(0, 0, day)
+			idle++
+			if len(days) > 0 && !days[date.Weekday()] {
+				continue
+			}
+			for _, t := range s.runsOn(date) {
+				idle = 0
+				if t.After(after) && !yield(t) {
+					return
+				}
+			}
+		}
+	}, nil
+}
+
+// runsOn lists the day's Interval or Times runs in order. date is midnight
+// in the schedule's time zone.
+func (s *Schedule) runsOn(date time.Time) []time.Time {
+	var runs []time.Time
+
+	if s.Interval != "" {
+		interval, err := time.ParseDuration(s.Interval)
+		if err != nil || interval < minScheduleInterval {
+			return nil
+		}
+		// Steps are wall-clock times, so a daylight saving change does not
+		// shift the rest of the day's runs.
+		for offset := time.Duration(0); offset < 24*time.Hour; offset += interval {
+			runs = append(runs, time.Date(date.Year(), date.Month(), date.Day(),
+				0, 0, int(offset/time.Second), 0, date.Location()))
+		}
+		return slices.CompactFunc(runs, time.Time.Equal)
+	}
+
+	for _, hhmm := range s.Times {
+		clock, err := time.Parse("15:04", hhmm)
+		if err != nil {
+			continue
+		}
+		runs = append(runs, time.Date(date.Year(), date.Month(), date.Day(),
+			clock.Hour(), clock.Minute(), 0, 0, date.Location()))
+	}
+	slices.SortFunc(runs, func(a, b time.Time) int { return a.Compare(b) })
+
+	return slices.CompactFunc(runs, time.Time.Equal)
+}
+
+// validate reports problems with the schedule's fields as "schedule.*".
+func (s *Schedule) validate() ValidationErrors {
+	var errs ValidationErrors
+
+	set := 0
+	for _, present := range []bool{s.Cron != "", s.Interval != "", len(s.Times) > 0} {
+		if present {
+			set++
+		}
+	}
+	if set != 1 {
+		errs.add("schedule", "must set exactly one of cron, interval and times")
+	}
+
+	if s.Cron != "" {
+		switch trimmed := strings.TrimSpace(s.Cron); {
+		case strings.HasPrefix(trimmed, "@every"):
+			errs.add("schedule.cron", "must not use @every; set interval instead")
+		case strings.HasPrefix(trimmed, "TZ=") || strings.HasPrefix(trimmed, "CRON_TZ="):
+			errs.add("schedule.cron", "must not set a time zone; set timezone instead")
+		default:
+			if _, err := cronParser.Parse(s.Cron); err != nil {
+				errs.add("schedule.cron", "must be a five-field cron expression: "+err.Error())
+			}
+		}
+		if len(s.Weekdays) > 0 {
+			errs.add("schedule.weekdays", "must not be set with cron; use its day-of-week field")
+		}
+	}
+
+	if s.Interval != "" {
+		d, err := time.ParseDuration(s.Interval)
+		if err != nil || d < minScheduleInterval || d > maxScheduleInterval {
+			errs.add("schedule.interval", "must be a duration between 1m and 24h such as \"6h\"")
+		}
+	}
+
+	for i, t := range s.Times {
+		if !timeOfDayRe.MatchString(t) {
+			errs.add(fmt.Sprintf("schedule.times[%d]", i), "must be a time of day in HH:MM format")
+		}
+	}
+
+	for i, day := range s.Weekdays {
+		if _, ok := weekdays[strings.ToLower(day)]; !ok {
+			errs.add(fmt.Sprintf("schedule.weekdays[%d]", i), "must be one of mon, tue, wed, thu, fri, sat, sun")
+		}
+	}
+
+	if _, err := s.Location(); err != nil {
+		errs.add("schedule.timezone", "must be an IANA time zone such as \"America/Toronto\"")
+	}
+
+	return errs
+}
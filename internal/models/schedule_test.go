@@ -0,0 +1,202 @@
+package models
+
+import (
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestScheduleNext(t *testing.T) {
+	toronto, err := time.LoadLocation("America/Toronto")
+	if err != nil {
+		t.Fatal(err)
+	}
+	at := func(loc *time.Location, month time.Month, day, hour, minute int) time.Time {
+		return time.Date(2026, month, day, hour, minute, 0, 0, loc)
+	}
+
+	tests := []struct {
+		name     string
+		schedule Schedule
+		after    time.Time
+		n        int
+		want     []time.Time
+	}{
+		{
+			name:     "times default to UTC",
+			schedule: Schedule{Times: []string{"18:00", "06:30"}},
+			after:    at(time.UTC, time.January, 5, 12, 0),
+			n:        3,
+			want: []time.Time{
+				at(time.UTC, time.January, 5, 18, 0),
+				at(time.UTC, time.January, 6, 6, 30),
+				at(time.UTC, time.January, 6, 18, 0),
+			},
+		},
+		{
+			name:     "duplicate times run once",
+			schedule: Schedule{Times: []string{"06:00", "06:00"}},
+			after:    at(time.UTC, time.January, 5, 0, 0),
+			n:        2,
+			want: []time.Time{
+				at(time.UTC, time.January, 5, 6, 0),
+				at(time.UTC, time.January, 6, 6, 0),
+			},
+		},
+		{
+			name:     "weekdays skip other days",
+			schedule: Schedule{Times: []string{"09:00"}, Weekdays: []string{"Mon", "fri"}},
+			after:    at(time.UTC, time.January, 5, 9, 0), // Monday
+			n:        3,
+			want: []time.Time{
+				at(time.UTC, time.January, 9, 9, 0),
+				at(time.UTC, time.January, 12, 9, 0),
+				at(time.UTC, time.January, 16, 9, 0),
+			},
+		},
+		{
+			name:     "times are read in the time zone",
+			schedule: Schedule{Times: []string{"06:00"}, Timezone: "America/Toronto"},
+			after:    at(time.UTC, time.January, 5, 12, 0),
+			n:        1,
+			want:     []time.Time{at(toronto, time.January, 6, 6, 0)},
+		},
+		{
+			name:     "times keep the wall clock across a DST change",
+			schedule: Schedule{Times: []string{"06:00"}, Timezone: "America/Toronto"},
+			after:    at(toronto, time.March, 7, 12, 0),
+			n:        2,
+			want: []time.Time{
+				at(toronto, time.March, 8, 6, 0),
+				at(toronto, time.March, 9, 6, 0),
+			},
+		},
+		{
+			name:     "interval starts at midnight",
+			schedule: Schedule{Interval: "6h"},
+			after:    at(time.UTC, time.January, 5, 7, 0),
+			n:        3,
+			want: []time.Time{
+				at(time.UTC, time.January, 5, 12, 0),
+				at(time.UTC, time.January, 5, 18, 0),
+				at(time.UTC, time.January, 6, 0, 0),
+			},
+		},
+		{
+			name:     "interval keeps the wall clock across a DST change",
+			schedule: Schedule{Interval: "6h", Timezone: "America/Toronto"},
+			after:    at(toronto, time.March, 7, 23, 0),
+			n:        4,
+			want: []time.Time{
+				at(toronto, time.March, 8, 0, 0),
+				at(toronto, time.March, 8, 6, 0),
+				at(toronto, time.March, 8, 12, 0),
+				at(toronto, time.March, 8, 18, 0),
+			},
+		},
+		{
+			name:     "interval that does not divide a day restarts at midnight",
+			schedule: Schedule{Interval: "10h"},
+			after:    at(time.UTC, time.January, 5, 12, 0),
+			n:        3,
+			want: []time.Time{
+				at(time.UTC, time.January, 5, 20, 0),
+				at(time.UTC, time.January, 6, 0, 0),
+				at(time.UTC, time.January, 6, 10, 0),
+			},
+		},
+		{
+			name:     "cron in a time zone",
+			schedule: Schedule{Cron: "30 6 * * 1-5", Timezone: "America/Toronto"},
+			after:    at(toronto, time.January, 9, 7, 0), // Friday
+			n:        2,
+			want: []time.Time{
+				at(toronto, time.January, 12, 6, 30),
+				at(toronto, time.January, 13, 6, 30),
+			},
+		},
+		{
+			name:     "cron that never matches",
+			schedule: Schedule{Cron: "0 0 30 2 *"},
+			after:    at(time.UTC, time.January, 1, 0, 0),
+			n:        1,
+			want:     []time.Time{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tt.schedule.Next(tt.after, tt.n)
+			if err != nil {
+				t.Fatalf("Next() error = %v", err)
+			}
+			if !slices.EqualFunc(got, tt.want, time.Time.Equal) {
+				t.Errorf("Next() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestScheduleBetween(t *testing.T) {
+	schedule := Schedule{Times: []string{"00:00", "12:00"}}
+	from := time.Date(2026, time.January, 5, 0, 0, 0, 0, time.UTC)
+
+	got, err := schedule.Between(from, from.Add(24*time.Hour))
+	if err != nil {
+		t.Fatalf("Between() error = %v", err)
+	}
+	want := []time.Time{from, from.Add(12 * time.Hour)}
+	if !slices.EqualFunc(got, want, time.Time.Equal) {
+		t.Errorf("Between() = %v, want %v (from inclusive, to exclusive)", got, want)
+	}
+}
+
+func TestScheduleValidate(t *testing.T) {
+	tests := []struct {
+		name     string
+		schedule Schedule
+		want     []string
+	}{
+		{name: "times", schedule: Schedule{Times: []string{"06:00"}, Weekdays: []string{"mon"}}},
+		{name: "interval", schedule: Schedule{Interval: "90m", Timezone: "Europe/Paris"}},
+		{name: "cron", schedule: Schedule{Cron: "@daily"}},
+		{name: "nothing set", schedule: Schedule{}, want: []string{"schedule"}},
+		{
+			name:     "two kinds set",
+			schedule: Schedule{Cron: "0 * * * *", Interval: "1h"},
+			want:     []string{"schedule"},
+		},
+		{name: "bad cron", schedule: Schedule{Cron: "* * *"}, want: []string{"schedule.cron"}},
+		{name: "cron @every", schedule: Schedule{Cron: "@every 1h"}, want: []string{"schedule.cron"}},
+		{name: "cron time zone", schedule: Schedule{Cron: "CRON_TZ=UTC 0 * * * *"}, want: []string{"schedule.cron"}},
+		{
+			name:     "cron with weekdays",
+			schedule: Schedule{Cron: "0 6 * * *", Weekdays: []string{"mon"}},
+			want:     []string{"schedule.weekdays"},
+		},
+		{name: "interval too short", schedule: Schedule{Interval: "30s"}, want: []string{"schedule.interval"}},
+		{name: "interval too long", schedule: Schedule{Interval: "25h"}, want: []string{"schedule.interval"}},
+		{
+			name:     "bad time and weekday",
+			schedule: Schedule{Times: []string{"06:00", "24:00"}, Weekdays: []string{"mon", "someday"}},
+			want:     []string{"schedule.times[1]", "schedule.weekdays[1]"},
+		},
+		{
+			name:     "bad time zone",
+			schedule: Schedule{Times: []string{"06:00"}, Timezone: "Mars/Olympus"},
+			want:     []string{"schedule.timezone"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var fields []string
+			for _, fe := range tt.schedule.validate() {
+				fields = append(fields, fe.Field)
+			}
+			if !slices.Equal(fields, tt.want) {
+				t.Errorf("validate() fields = %v, want %v", fields, tt.want)
+			}
+		})
+	}
+}
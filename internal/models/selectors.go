@@ -0,0 +1,73 @@
+package models
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+
+	"github.com/andybalholm/cascadia"
+)
+
+// SelectorField is a single selector string within a SelectorConfig together
+// with its JSON path, e.g. "selectors.article.exclude[0]".
+type SelectorField struct {
+	Path     string
+	Selector string
+}
+
+// Fields returns every non-empty selector in the config, including each
+// Exclude and ExcludeFromList entry, in declaration order.
+func (c *SelectorConfig) Fields() []SelectorField {
+	var fields []SelectorField
+	fields = appendSelectorFields(fields, "selectors.article", reflect.ValueOf(c.Article))
+	fields = appendSelectorFields(fields, "selectors.list", reflect.ValueOf(c.List))
+	fields = appendSelectorFields(fields, "selectors.page", reflect.ValueOf(c.Page))
+	return fields
+}
+
+func appendSelectorFields(fields []SelectorField, prefix string, v reflect.Value) []SelectorField {
+	t := v.Type()
+	for i := range t.NumField() {
+		name, _, _ := strings.Cut(t.Field(i).Tag.Get("json"), ",")
+		path := prefix + "." + name
+
+		switch value := v.Field(i).Interface().(type) {
+		case string:
+			if value != "" {
+				fields = append(fields, SelectorField{Path: path, Selector: value})
+			}
+		case []string:
+			for j, selector := range value {
+				fields = append(fields, SelectorField{Path: fmt.Sprintf("%s[%d]", path, j), Selector: selector})
+			}
+		}
+	}
+	return fields
+}
+
+// ValidateSelector parses a CSS selector group such as "h1, .title" and
+// returns the parse error, if any.
+func ValidateSelector(selector string) error {
+	if strings.TrimSpace(selector) == "" {
+		return errors.New("selector is empty")
+	}
+	_, err := cascadia.ParseGroup(selector)
+	return err
+}
+
+// Validate checks every selector against the CSS selector grammar and returns
+// ValidationErrors naming each invalid selector, or nil.
+func (c *SelectorConfig) Validate() error {
+	return c.validate().err()
+}
+
+func (c *SelectorConfig) validate() ValidationErrors {
+	var errs ValidationErrors
+	for _, field := range c.Fields() {
+		if err := ValidateSelector(field.Selector); err != nil {
+			errs.add(field.Path, "invalid CSS selector: "+err.Error())
+		}
+	}
+	return errs
+}
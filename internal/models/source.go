@@ -16,71 +16,73 @@ type Source struct {
 	PageIndex    string         `json:"page_index" db:"page_index"`
 	RateLimit    string         `json:"rate_limit" db:"rate_limit"`
 	MaxDepth     int            `json:"max_depth" db:"max_depth"`
-	Time         StringArray    `json:"time" db:"time"`
+	Time         StringArray    `json:"time" db:"time"`                   // Legacy daily HH:MM times in UTC; see Schedule
+	Schedule     *Schedule      `json:"schedule,omitempty" db:"schedule"` // Replaces Time when set
 	Selectors    SelectorConfig `json:"selectors" db:"selectors"`
-	CityName     *string        `json:"city_name,omitempty" db:"city_name"` // Optional mapping to gopost city
+	CityName     *string        `json:"city_name,omitempty" db:"city_name"` // Optional; the name of a City
 	GroupID      *string        `json:"group_id,omitempty" db:"group_id"`   // Optional Drupal group UUID
 	Enabled      bool           `json:"enabled" db:"enabled"`
+	Version      int            `json:"version" db:"version"` // Incremented on every write; exposed as the ETag
 	CreatedAt    time.Time      `json:"created_at" db:"created_at"`
 	UpdatedAt    time.Time      `json:"updated_at" db:"updated_at"`
 }
 
 // SelectorConfig represents CSS selector configuration
 type SelectorConfig struct {
-	Article ArticleSelectors `json:"article"`
-	List    ListSelectors    `json:"list"`
-	Page    PageSelectors    `json:"page"`
+	Article ArticleSelectors `json:"article" yaml:"article"`
+	List    ListSelectors    `json:"list" yaml:"list"`
+	Page    PageSelectors    `json:"page" yaml:"page"`
 }
 
 // ArticleSelectors defines CSS selectors for article extraction
 type ArticleSelectors struct {
-	Container     string   `json:"container,omitempty"`
-	Title         string   `json:"title,omitempty"`
-	Body          string   `json:"body,omitempty"`
-	Intro         string   `json:"intro,omitempty"`
-	Link          string   `json:"link,omitempty"`
-	Image         string   `json:"image,omitempty"`
-	Byline        string   `json:"byline,omitempty"`
-	PublishedTime string   `json:"published_time,omitempty"`
-	TimeAgo       string   `json:"time_ago,omitempty"`
-	Section       string   `json:"section,omitempty"`
-	Category      string   `json:"category,omitempty"`
-	ArticleID     string   `json:"article_id,omitempty"`
-	JSONLD        string   `json:"json_ld,omitempty"`
-	Keywords      string   `json:"keywords,omitempty"`
-	Description   string   `json:"description,omitempty"`
-	OGTitle       string   `json:"og_title,omitempty"`
-	OGDescription string   `json:"og_description,omitempty"`
-	OGImage       string   `json:"og_image,omitempty"`
-	OGURL         string   `json:"og_url,omitempty"`
-	OGType        string   `json:"og_type,omitempty"`
-	OGSiteName    string   `json:"og_site_name,omitempty"`
-	Canonical     string   `json:"canonical,omitempty"`
-	Author        string   `json:"author,omitempty"`
-	Exclude       []string `json:"exclude,omitempty"`
+	Container     string   `json:"container,omitempty" yaml:"container,omitempty"`
+	Title         string   `json:"title,omitempty" yaml:"title,omitempty"`
+	Body          string   `json:"body,omitempty" yaml:"body,omitempty"`
+	Intro         string   `json:"intro,omitempty" yaml:"intro,omitempty"`
+	Link          string   `json:"link,omitempty" yaml:"link,omitempty"`
+	Image         string   `json:"image,omitempty" yaml:"image,omitempty"`
+	Byline        string   `json:"byline,omitempty" yaml:"byline,omitempty"`
+	PublishedTime string   `json:"published_time,omitempty" yaml:"published_time,omitempty"`
+	TimeAgo       string   `json:"time_ago,omitempty" yaml:"time_ago,omitempty"`
+	Section       string   `json:"section,omitempty" yaml:"section,omitempty"`
+	Category      string   `json:"category,omitempty" yaml:"category,omitempty"`
+	ArticleID     string   `json:"article_id,omitempty" yaml:"article_id,omitempty"`
+	JSONLD        string   `json:"json_ld,omitempty" yaml:"json_ld,omitempty"`
+	Keywords      string   `json:"keywords,omitempty" yaml:"keywords,omitempty"`
+	Description   string   `json:"description,omitempty" yaml:"description,omitempty"`
+	OGTitle       string   `json:"og_title,omitempty" yaml:"og_title,omitempty"`
+	OGDescription string   `json:"og_description,omitempty" yaml:"og_description,omitempty"`
+	OGImage       string   `json:"og_image,omitempty" yaml:"og_image,omitempty"`
+	OGURL         string   `json:"og_url,omitempty" yaml:"og_url,omitempty"`
+	OGType        string   `json:"og_type,omitempty" yaml:"og_type,omitempty"`
+	OGSiteName    string   `json:"og_site_name,omitempty" yaml:"og_site_name,omitempty"`
+	Canonical     string   `json:"canonical,omitempty" yaml:"canonical,omitempty"`
+	Author        string   `json:"author,omitempty" yaml:"author,omitempty"`
+	Exclude       []string `json:"exclude,omitempty" yaml:"exclude,omitempty"`
 }
 
 // ListSelectors defines CSS selectors for list page extraction
 type ListSelectors struct {
-	Container       string   `json:"container,omitempty"`
-	ArticleCards    string   `json:"article_cards,omitempty"`
-	ArticleList     string   `json:"article_list,omitempty"`
-	ExcludeFromList []string `json:"exclude_from_list,omitempty"`
+	Container       string   `json:"container,omitempty" yaml:"container,omitempty"`
+	ArticleCards    string   `json:"article_cards,omitempty" yaml:"article_cards,omitempty"`
+	ArticleList     string   `json:"article_list,omitempty" yaml:"article_list,omitempty"`
+	ExcludeFromList []string `json:"exclude_from_list,omitempty" yaml:"exclude_from_list,omitempty"`
 }
 
 // PageSelectors defines CSS selectors for page content extraction
 type PageSelectors struct {
-	Container     string   `json:"container,omitempty"`
-	Title         string   `json:"title,omitempty"`
-	Content       string   `json:"content,omitempty"`
-	Description   string   `json:"description,omitempty"`
-	Keywords      string   `json:"keywords,omitempty"`
-	OGTitle       string   `json:"og_title,omitempty"`
-	OGDescription string   `json:"og_description,omitempty"`
-	OGImage       string   `json:"og_image,omitempty"`
-	OGURL         string   `json:"og_url,omitempty"`
-	Canonical     string   `json:"canonical,omitempty"`
-	Exclude       []string `json:"exclude,omitempty"`
+	Container     string   `json:"container,omitempty" yaml:"container,omitempty"`
+	Title         string   `json:"title,omitempty" yaml:"title,omitempty"`
+	Content       string   `json:"content,omitempty" yaml:"content,omitempty"`
+	Description   string   `json:"description,omitempty" yaml:"description,omitempty"`
+	Keywords      string   `json:"keywords,omitempty" yaml:"keywords,omitempty"`
+	OGTitle       string   `json:"og_title,omitempty" yaml:"og_title,omitempty"`
+	OGDescription string   `json:"og_description,omitempty" yaml:"og_description,omitempty"`
+	OGImage       string   `json:"og_image,omitempty" yaml:"og_image,omitempty"`
+	OGURL         string   `json:"og_url,omitempty" yaml:"og_url,omitempty"`
+	Canonical     string   `json:"canonical,omitempty" yaml:"canonical,omitempty"`
+	Exclude       []string `json:"exclude,omitempty" yaml:"exclude,omitempty"`
 }
 
 // StringArray is a custom type for PostgreSQL string arrays
@@ -111,10 +113,3 @@ func (a *StringArray) Scan(value any) error {
 	}
 	return json.Unmarshal(bytes, a)
 }
-
-// City represents a city configuration for gopost
-type City struct {
-	Name    string `json:"name"`
-	Index   string `json:"index"`
-	GroupID string `json:"group_id,omitempty"`
-}
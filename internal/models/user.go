@@ -0,0 +1,99 @@
+package models
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+)
+
+// Role sets what a user may do with the sources of their cities.
+type Role string
+
+const (
+	RoleViewer Role = "viewer" // Reads sources in their cities
+	RoleEditor Role = "editor" // Also creates, edits and deletes them
+	RoleAdmin  Role = "admin"  // Everything, in every city
+)
+
+// Roles lists every role from least to most privileged.
+var Roles = []Role{RoleViewer, RoleEditor, RoleAdmin}
+
+// Grants reports whether the role allows a key scope. A user's API keys never
+// grant more than the user's role.
+func (r Role) Grants(scope Scope) bool {
+	switch r {
+	case RoleAdmin:
+		return true
+	case RoleEditor:
+		return scope == ScopeSourcesRead || scope == ScopeSourcesWrite || scope == ScopeCitiesRead
+	case RoleViewer:
+		return scope == ScopeSourcesRead || scope == ScopeCitiesRead
+	default:
+		return false
+	}
+}
+
+// User is a person whose API keys act with their role, limited to the
+// sources of their cities unless they are an admin.
+type User struct {
+	ID        string    `json:"id" db:"id"`
+	Name      string    `json:"name" db:"name"`
+	Role      Role      `json:"role" db:"role"`
+	Cities    []string  `json:"cities" db:"cities"` // city_name values the user may access
+	CreatedAt time.Time `json:"created_at" db:"created_at"`
+	UpdatedAt time.Time `json:"updated_at" db:"updated_at"`
+}
+
+// CanView reports whether the user may see a source with the given city.
+// Sources without a city are only visible to admins.
+func (u *User) CanView(city *string) bool {
+	if u.Role == RoleAdmin {
+		return true
+	}
+	return city != nil && slices.Contains(u.Cities, *city)
+}
+
+// CanEdit reports whether the user may create, change or delete a source with
+// the given city.
+func (u *User) CanEdit(city *string) bool {
+	return u.Role.Grants(ScopeSourcesWrite) && u.CanView(city)
+}
+
+// Validate checks the user's name, role and cities.
+func (u *User) Validate() error {
+	var errs ValidationErrors
+
+	switch name := strings.TrimSpace(u.Name); {
+	case name == "":
+		errs.add("name", "is required")
+	case len(u.Name) > maxNameLength:
+		errs.add("name", fmt.Sprintf("must be at most %d characters", maxNameLength))
+	}
+
+	if !slices.Contains(Roles, u.Role) {
+		errs.add("role", fmt.Sprintf("must be one of %s, %s or %s", RoleViewer, RoleEditor, RoleAdmin))
+	}
+
+	for i, city := range u.Cities {
+		if strings.TrimSpace(city) == "" {
+			errs.add(fmt.Sprintf("cities[%d]", i), "must not be empty")
+		}
+	}
+
+	return errs.err()
+}
+
+// ValidateKey checks that a key issued to the user grants nothing beyond the
+// user's role.
+func (u *User) ValidateKey(key *APIKey) error {
+	var errs ValidationErrors
+
+	for i, scope := range key.Scopes {
+		if slices.Contains(Scopes, scope) && !u.Role.Grants(scope) {
+			errs.add(fmt.Sprintf("scopes[%d]", i), fmt.Sprintf("%s is not granted by the %s role", scope, u.Role))
+		}
+	}
+
+	return errs.err()
+}
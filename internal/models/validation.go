@@ -0,0 +1,148 @@
+package models
+
+import (
+	"fmt"
+	"net/url"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	maxNameLength  = 255
+	maxIndexLength = 255
+)
+
+var (
+	timeOfDayRe = regexp.MustCompile(`^([01]\d|2[0-3]):[0-5]\d$`)
+
+	// Characters Elasticsearch rejects in index names.
+	invalidIndexChars = `\/*?"<>| ,#:`
+)
+
+// FieldError describes a single invalid field. Field is the JSON path of the
+// offending value, e.g. "time[1]" or "selectors.article.title".
+type FieldError struct {
+	Field   string `json:"field"`
+	Message string `json:"message"`
+}
+
+// ValidationErrors collects every problem found in a payload.
+type ValidationErrors []FieldError
+
+func (v ValidationErrors) Error() string {
+	messages := make([]string, len(v))
+	for i, fe := range v {
+		messages[i] = fe.Field + ": " + fe.Message
+	}
+	return "validation failed: " + strings.Join(messages, "; ")
+}
+
+func (v *ValidationErrors) add(field, message string) {
+	*v = append(*v, FieldError{Field: field, Message: message})
+}
+
+// err returns nil when no problems were recorded so callers can return it
+// directly as an error.
+func (v ValidationErrors) err() error {
+	if len(v) == 0 {
+		return nil
+	}
+	return v
+}
+
+// Validate checks the source for values the crawler cannot use. It returns
+// ValidationErrors listing every problem, or nil when the source is valid.
+func (s *Source) Validate() error {
+	var errs ValidationErrors
+
+	switch name := strings.TrimSpace(s.Name); {
+	case name == "":
+		errs.add("name", "is required")
+	case len(s.Name) > maxNameLength:
+		errs.add("name", fmt.Sprintf("must be at most %d characters", maxNameLength))
+	}
+
+	if msg := validateURL(s.URL); msg != "" {
+		errs.add("url", msg)
+	}
+
+	if msg := validateIndexName(s.ArticleIndex); msg != "" {
+		errs.add("article_index", msg)
+	}
+	if msg := validateIndexName(s.PageIndex); msg != "" {
+		errs.add("page_index", msg)
+	}
+
+	if s.RateLimit != "" {
+		if d, err := time.ParseDuration(s.RateLimit); err != nil || d <= 0 {
+			errs.add("rate_limit", "must be a positive duration such as \"1s\" or \"500ms\"")
+		}
+	}
+
+	if s.MaxDepth < 0 {
+		errs.add("max_depth", "must not be negative")
+	}
+
+	for i, t := range s.Time {
+		if !timeOfDayRe.MatchString(t) {
+			errs.add(fmt.Sprintf("time[%d]", i), "must be a time of day in HH:MM format")
+		}
+	}
+
+	if s.Schedule != nil {
+		if len(s.Time) > 0 {
+			errs.add("schedule", "must not be set together with time")
+		}
+		errs = append(errs, s.Schedule.validate()...)
+	}
+
+	if s.CityName != nil && strings.TrimSpace(*s.CityName) == "" {
+		errs.add("city_name", "must not be empty when set")
+	}
+
+	if s.GroupID != nil {
+		if _, err := uuid.Parse(*s.GroupID); err != nil {
+			errs.add("group_id", "must be a UUID")
+		}
+	}
+
+	errs = append(errs, s.Selectors.validate()...)
+
+	return errs.err()
+}
+
+func validateURL(raw string) string {
+	if raw == "" {
+		return "is required"
+	}
+	u, err := url.Parse(raw)
+	if err != nil || u.Host == "" {
+		return "must be an absolute URL"
+	}
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return "must use http or https"
+	}
+	return ""
+}
+
+// validateIndexName applies Elasticsearch's index naming rules.
+func validateIndexName(name string) string {
+	switch {
+	case name == "":
+		return "is required"
+	case len(name) > maxIndexLength:
+		return fmt.Sprintf("must be at most %d bytes", maxIndexLength)
+	case name == "." || name == "..":
+		return "must not be \".\" or \"..\""
+	case strings.ToLower(name) != name:
+		return "must be lowercase"
+	case strings.ContainsAny(name[:1], "-_+"):
+		return "must not start with -, _ or +"
+	case strings.ContainsAny(name, invalidIndexChars):
+		return "must not contain spaces or any of \\ / * ? \" < > | , # :"
+	}
+	return ""
+}
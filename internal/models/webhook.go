@@ -0,0 +1,76 @@
+package models
+
+import (
+	"encoding/json"
+	"fmt"
+	"slices"
+	"time"
+)
+
+// EventAll subscribes a webhook to every event.
+const EventAll EventType = "*"
+
+// EventPing is sent by POST /api/v1/webhooks/:id/ping to test a subscription.
+const EventPing EventType = "ping"
+
+// Webhook is a subscription that receives signed event deliveries.
+type Webhook struct {
+	ID          string      `json:"id" db:"id"`
+	URL         string      `json:"url" db:"url"`
+	Secret      string      `json:"secret,omitempty" db:"secret"` // Only returned when the webhook is created
+	Events      []EventType `json:"events" db:"events"`
+	Description string      `json:"description,omitempty" db:"description"`
+	Enabled     bool        `json:"enabled" db:"enabled"`
+	CreatedAt   time.Time   `json:"created_at" db:"created_at"`
+	UpdatedAt   time.Time   `json:"updated_at" db:"updated_at"`
+}
+
+// Subscribes reports whether the webhook should receive events of type t.
+func (w *Webhook) Subscribes(t EventType) bool {
+	return w.Enabled && (t == EventPing || slices.Contains(w.Events, EventAll) || slices.Contains(w.Events, t))
+}
+
+// Validate checks the webhook's URL and event list.
+func (w *Webhook) Validate() error {
+	var errs ValidationErrors
+
+	if msg := validateURL(w.URL); msg != "" {
+		errs.add("url", msg)
+	}
+
+	if len(w.Events) == 0 {
+		errs.add("events", "must list at least one event")
+	}
+	for i, event := range w.Events {
+		if event != EventAll && !slices.Contains(SourceEventTypes, event) {
+			errs.add(fmt.Sprintf("events[%d]", i), fmt.Sprintf("unknown event %q", event))
+		}
+	}
+
+	return errs.err()
+}
+
+// DeliveryStatus is the state of a webhook delivery in the retry queue.
+type DeliveryStatus string
+
+const (
+	DeliveryPending   DeliveryStatus = "pending"
+	DeliverySucceeded DeliveryStatus = "succeeded"
+	DeliveryFailed    DeliveryStatus = "failed" // Gave up after the last attempt
+)
+
+// WebhookDelivery is one event queued for one webhook. Payload is the exact
+// request body, so retries send identical bytes.
+type WebhookDelivery struct {
+	ID             string          `json:"id" db:"id"`
+	WebhookID      string          `json:"webhook_id" db:"webhook_id"`
+	Event          EventType       `json:"event" db:"event"`
+	Payload        json.RawMessage `json:"payload" db:"payload"`
+	Status         DeliveryStatus  `json:"status" db:"status"`
+	Attempts       int             `json:"attempts" db:"attempts"`
+	NextAttemptAt  time.Time       `json:"next_attempt_at" db:"next_attempt_at"`
+	ResponseStatus int             `json:"response_status,omitempty" db:"response_status"`
+	LastError      string          `json:"last_error,omitempty" db:"last_error"`
+	CreatedAt      time.Time       `json:"created_at" db:"created_at"`
+	UpdatedAt      time.Time       `json:"updated_at" db:"updated_at"`
+}
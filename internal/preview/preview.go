@@ -0,0 +1,339 @@
+// Package preview runs a source's SelectorConfig against supplied HTML and
+// reports what the crawler would extract.
+package preview
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/url"
+	"strings"
+
+	"github.com/PuerkitoBio/goquery"
+	"github.com/jonesrussell/gosources/internal/models"
+)
+
+// Type selects which group of selectors is applied.
+type Type string
+
+const (
+	TypeArticle Type = "article"
+	TypeList    Type = "list"
+	TypePage    Type = "page"
+)
+
+// ErrInvalidType is returned for an unknown preview type.
+var ErrInvalidType = errors.New("invalid preview type")
+
+// ParseType parses a preview type, defaulting to TypeArticle.
+func ParseType(s string) (Type, error) {
+	switch t := Type(s); t {
+	case "":
+		return TypeArticle, nil
+	case TypeArticle, TypeList, TypePage:
+		return t, nil
+	default:
+		return "", fmt.Errorf("%w: %q", ErrInvalidType, s)
+	}
+}
+
+// Result is the outcome of a preview. Exactly one of Article, List or Page is
+// set, matching Type. Unmatched lists the JSON paths of configured selectors
+// that matched nothing, which is usually what needs tuning.
+type Result struct {
+	Type      Type           `json:"type"`
+	Article   *ArticleResult `json:"article,omitempty"`
+	List      *ListResult    `json:"list,omitempty"`
+	Page      *PageResult    `json:"page,omitempty"`
+	Unmatched []string       `json:"unmatched"`
+}
+
+// OpenGraph holds the og:* values found in a document.
+type OpenGraph struct {
+	Title       string `json:"title,omitempty"`
+	Description string `json:"description,omitempty"`
+	Image       string `json:"image,omitempty"`
+	URL         string `json:"url,omitempty"`
+	Type        string `json:"type,omitempty"`
+	SiteName    string `json:"site_name,omitempty"`
+}
+
+// ArticleResult is what ArticleSelectors extract from an article page.
+type ArticleResult struct {
+	Title         string    `json:"title,omitempty"`
+	Body          string    `json:"body,omitempty"`
+	Intro         string    `json:"intro,omitempty"`
+	Link          string    `json:"link,omitempty"`
+	Image         string    `json:"image,omitempty"`
+	Byline        string    `json:"byline,omitempty"`
+	Author        string    `json:"author,omitempty"`
+	PublishedTime string    `json:"published_time,omitempty"`
+	TimeAgo       string    `json:"time_ago,omitempty"`
+	Section       string    `json:"section,omitempty"`
+	Category      string    `json:"category,omitempty"`
+	ArticleID     string    `json:"article_id,omitempty"`
+	Keywords      string    `json:"keywords,omitempty"`
+	Description   string    `json:"description,omitempty"`
+	Canonical     string    `json:"canonical,omitempty"`
+	OpenGraph     OpenGraph `json:"og"`
+	JSONLD        []any     `json:"json_ld,omitempty"`
+}
+
+// ListLink is an article link found on a list page.
+type ListLink struct {
+	URL   string `json:"url"`
+	Title string `json:"title,omitempty"`
+}
+
+// ListResult is what ListSelectors extract from a section or index page.
+type ListResult struct {
+	Links []ListLink `json:"links"`
+	Count int        `json:"count"`
+}
+
+// PageResult is what PageSelectors extract from a non-article page.
+type PageResult struct {
+	Title       string    `json:"title,omitempty"`
+	Content     string    `json:"content,omitempty"`
+	Description string    `json:"description,omitempty"`
+	Keywords    string    `json:"keywords,omitempty"`
+	Canonical   string    `json:"canonical,omitempty"`
+	OpenGraph   OpenGraph `json:"og"`
+}
+
+// Run parses html and applies the selectors for the given type. baseURL is
+// used to resolve relative links and may be nil.
+func Run(html io.Reader, selectors *models.SelectorConfig, typ Type, baseURL *url.URL) (*Result, error) {
+	doc, err := goquery.NewDocumentFromReader(html)
+	if err != nil {
+		return nil, fmt.Errorf("parse html: %w", err)
+	}
+
+	ex := &extractor{doc: doc, base: baseURL, unmatched: []string{}}
+	result := &Result{Type: typ}
+
+	switch typ {
+	case TypeArticle:
+		result.Article = ex.article(&selectors.Article)
+	case TypeList:
+		result.List = ex.list(&selectors.List)
+	case TypePage:
+		result.Page = ex.page(&selectors.Page)
+	default:
+		return nil, fmt.Errorf("%w: %q", ErrInvalidType, typ)
+	}
+
+	result.Unmatched = ex.unmatched
+
+	return result, nil
+}
+
+type extractor struct {
+	doc       *goquery.Document
+	base      *url.URL
+	unmatched []string
+}
+
+// scope returns the container selection with excluded elements removed. The
+// document is cloned so exclusions do not affect document-wide lookups such
+// as meta tags.
+func (e *extractor) scope(path, container string, exclude []string, excludePath string) *goquery.Selection {
+	root := goquery.NewDocumentFromNode(e.doc.Selection.Clone().Nodes[0]).Selection
+
+	if container != "" {
+		found := root.Find(container)
+		if found.Length() == 0 {
+			e.unmatched = append(e.unmatched, path)
+		} else {
+			root = found.First()
+		}
+	}
+
+	for i, selector := range exclude {
+		if selector == "" {
+			continue
+		}
+		excluded := root.Find(selector)
+		if excluded.Length() == 0 {
+			e.unmatched = append(e.unmatched, fmt.Sprintf("%s[%d]", excludePath, i))
+		}
+		excluded.Remove()
+	}
+
+	return root
+}
+
+// text returns the whitespace-normalised text of the first match.
+func (e *extractor) text(root *goquery.Selection, path, selector string) string {
+	if selector == "" {
+		return ""
+	}
+	found := root.Find(selector)
+	if found.Length() == 0 {
+		e.unmatched = append(e.unmatched, path)
+		return ""
+	}
+	return normalizeSpace(found.First().Text())
+}
+
+// value returns the most useful value of the first match: the content of a
+// meta tag, the href of a link, the src of an image, the datetime of a time
+// element, otherwise its text. URLs are resolved against the base URL.
+func (e *extractor) value(root *goquery.Selection, path, selector string) string {
+	if selector == "" {
+		return ""
+	}
+	found := root.Find(selector)
+	if found.Length() == 0 {
+		e.unmatched = append(e.unmatched, path)
+		return ""
+	}
+
+	node := found.First()
+	switch goquery.NodeName(node) {
+	case "meta":
+		return strings.TrimSpace(node.AttrOr("content", ""))
+	case "link", "a":
+		return e.resolve(node.AttrOr("href", ""))
+	case "img":
+		return e.resolve(node.AttrOr("src", ""))
+	case "time":
+		if datetime, ok := node.Attr("datetime"); ok {
+			return strings.TrimSpace(datetime)
+		}
+	}
+	return normalizeSpace(node.Text())
+}
+
+func (e *extractor) openGraph(prefix string, title, description, image, ogURL, ogType, siteName string) OpenGraph {
+	root := e.doc.Selection
+	return OpenGraph{
+		Title:       e.value(root, prefix+".og_title", title),
+		Description: e.value(root, prefix+".og_description", description),
+		Image:       e.value(root, prefix+".og_image", image),
+		URL:         e.value(root, prefix+".og_url", ogURL),
+		Type:        e.value(root, prefix+".og_type", ogType),
+		SiteName:    e.value(root, prefix+".og_site_name", siteName),
+	}
+}
+
+func (e *extractor) article(s *models.ArticleSelectors) *ArticleResult {
+	const prefix = "selectors.article"
+	root := e.scope(prefix+".container", s.Container, s.Exclude, prefix+".exclude")
+	doc := e.doc.Selection
+
+	result := &ArticleResult{
+		Title:         e.text(root, prefix+".title", s.Title),
+		Body:          e.text(root, prefix+".body", s.Body),
+		Intro:         e.text(root, prefix+".intro", s.Intro),
+		Link:          e.value(root, prefix+".link", s.Link),
+		Image:         e.value(root, prefix+".image", s.Image),
+		Byline:        e.text(root, prefix+".byline", s.Byline),
+		Author:        e.text(root, prefix+".author", s.Author),
+		PublishedTime: e.value(root, prefix+".published_time", s.PublishedTime),
+		TimeAgo:       e.text(root, prefix+".time_ago", s.TimeAgo),
+		Section:       e.text(root, prefix+".section", s.Section),
+		Category:      e.text(root, prefix+".category", s.Category),
+		ArticleID:     e.value(root, prefix+".article_id", s.ArticleID),
+		Keywords:      e.value(doc, prefix+".keywords", s.Keywords),
+		Description:   e.value(doc, prefix+".description", s.Description),
+		Canonical:     e.value(doc, prefix+".canonical", s.Canonical),
+		OpenGraph: e.openGraph(prefix,
+			s.OGTitle, s.OGDescription, s.OGImage, s.OGURL, s.OGType, s.OGSiteName),
+	}
+
+	if s.JSONLD != "" {
+		scripts := doc.Find(s.JSONLD)
+		if scripts.Length() == 0 {
+			e.unmatched = append(e.unmatched, prefix+".json_ld")
+		}
+		scripts.Each(func(_ int, script *goquery.Selection) {
+			var data any
+			if err := json.Unmarshal([]byte(script.Text()), &data); err == nil {
+				result.JSONLD = append(result.JSONLD, data)
+			}
+		})
+	}
+
+	return result
+}
+
+func (e *extractor) list(s *models.ListSelectors) *ListResult {
+	const prefix = "selectors.list"
+	root := e.scope(prefix+".container", s.Container, s.ExcludeFromList, prefix+".exclude_from_list")
+
+	result := &ListResult{Links: []ListLink{}}
+	seen := make(map[string]bool)
+
+	collect := func(path, selector string) {
+		if selector == "" {
+			return
+		}
+		cards := root.Find(selector)
+		if cards.Length() == 0 {
+			e.unmatched = append(e.unmatched, path)
+			return
+		}
+		cards.Each(func(_ int, card *goquery.Selection) {
+			anchor := card
+			if goquery.NodeName(card) != "a" {
+				anchor = card.Find("a[href]").First()
+			}
+			href, ok := anchor.Attr("href")
+			if !ok {
+				return
+			}
+			link := e.resolve(href)
+			if link == "" || seen[link] {
+				return
+			}
+			seen[link] = true
+
+			title := normalizeSpace(card.Find("h1, h2, h3, h4").First().Text())
+			if title == "" {
+				title = normalizeSpace(anchor.Text())
+			}
+			result.Links = append(result.Links, ListLink{URL: link, Title: title})
+		})
+	}
+
+	collect(prefix+".article_cards", s.ArticleCards)
+	collect(prefix+".article_list", s.ArticleList)
+
+	result.Count = len(result.Links)
+
+	return result
+}
+
+func (e *extractor) page(s *models.PageSelectors) *PageResult {
+	const prefix = "selectors.page"
+	root := e.scope(prefix+".container", s.Container, s.Exclude, prefix+".exclude")
+	doc := e.doc.Selection
+
+	return &PageResult{
+		Title:       e.text(root, prefix+".title", s.Title),
+		Content:     e.text(root, prefix+".content", s.Content),
+		Description: e.value(doc, prefix+".description", s.Description),
+		Keywords:    e.value(doc, prefix+".keywords", s.Keywords),
+		Canonical:   e.value(doc, prefix+".canonical", s.Canonical),
+		OpenGraph: e.openGraph(prefix,
+			s.OGTitle, s.OGDescription, s.OGImage, s.OGURL, "", ""),
+	}
+}
+
+func (e *extractor) resolve(ref string) string {
+	ref = strings.TrimSpace(ref)
+	if ref == "" || e.base == nil {
+		return ref
+	}
+	u, err := e.base.Parse(ref)
+	if err != nil {
+		return ref
+	}
+	return u.String()
+}
+
+func normalizeSpace(s string) string {
+	return strings.Join(strings.Fields(s), " ")
+}
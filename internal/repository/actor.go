@@ -0,0 +1,21 @@
+package repository
+
+import "context"
+
+// DefaultActor is recorded when a change is made without an identified caller.
+const DefaultActor = "anonymous"
+
+type actorKey struct{}
+
+// WithActor returns a context that attributes repository changes to actor.
+func WithActor(ctx context.Context, actor string) context.Context {
+	return context.WithValue(ctx, actorKey{}, actor)
+}
+
+// ActorFromContext returns the actor set by WithActor, or DefaultActor.
+func ActorFromContext(ctx context.Context) string {
+	if actor, ok := ctx.Value(actorKey{}).(string); ok && actor != "" {
+		return actor
+	}
+	return DefaultActor
+}
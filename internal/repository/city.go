@@ -0,0 +1,193 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jonesrussell/gosources/internal/logger"
+	"github.com/jonesrussell/gosources/internal/models"
+)
+
+// CityRepository is the SQL implementation of CityStore, shared by PostgreSQL
+// and SQLite like SourceRepository.
+type CityRepository struct {
+	db     *sql.DB
+	logger logger.Logger
+}
+
+func NewCityRepository(db *sql.DB, log logger.Logger) *CityRepository {
+	return &CityRepository{
+		db:     db,
+		logger: log,
+	}
+}
+
+const cityColumns = `id, name, display_name, region, timezone, group_id, default_index, created_at, updated_at`
+
+func (r *CityRepository) Create(ctx context.Context, city *models.City) error {
+	city.ID = uuid.New().String()
+	city.CreatedAt = time.Now().UTC()
+	city.UpdatedAt = city.CreatedAt
+
+	query := `
+		INSERT INTO cities (` + cityColumns + `)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+	`
+
+	_, err := r.db.ExecContext(ctx,
+		query,
+		city.ID,
+		city.Name,
+		city.DisplayName,
+		nullString(city.Region),
+		nullString(city.Timezone),
+		nullString(city.GroupID),
+		nullString(city.DefaultIndex),
+		city.CreatedAt,
+		city.UpdatedAt,
+	)
+	if err != nil {
+		return fmt.Errorf("insert city: %w", classifyError(err, map[string]string{"name": city.Name}))
+	}
+
+	return nil
+}
+
+func (r *CityRepository) GetByID(ctx context.Context, id string) (*models.City, error) {
+	query := `
+		SELECT ` + cityColumns + `
+		FROM cities
+		WHERE id = $1
+	`
+
+	city, err := scanCity(r.db.QueryRowContext(ctx, query, id))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, fmt.Errorf("city %s: %w", id, ErrNotFound)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return city, nil
+}
+
+func (r *CityRepository) List(ctx context.Context) ([]models.City, error) {
+	query := `
+		SELECT ` + cityColumns + `
+		FROM cities
+		ORDER BY name
+	`
+
+	rows, err := r.db.QueryContext(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("query cities: %w", err)
+	}
+	defer rows.Close()
+
+	cities := []models.City{}
+	for rows.Next() {
+		city, scanErr := scanCity(rows)
+		if scanErr != nil {
+			return nil, scanErr
+		}
+		cities = append(cities, *city)
+	}
+
+	if rowsErr := rows.Err(); rowsErr != nil {
+		return nil, fmt.Errorf("iterate cities: %w", rowsErr)
+	}
+
+	return cities, nil
+}
+
+// Update changes everything but the city's name, which sources refer to.
+func (r *CityRepository) Update(ctx context.Context, city *models.City) error {
+	city.UpdatedAt = time.Now().UTC()
+
+	query := `
+		UPDATE cities
+		SET display_name = $2, region = $3, timezone = $4, group_id = $5, default_index = $6, updated_at = $7
+		WHERE id = $1
+		RETURNING name, created_at
+	`
+
+	err := r.db.QueryRowContext(ctx,
+		query,
+		city.ID,
+		city.DisplayName,
+		nullString(city.Region),
+		nullString(city.Timezone),
+		nullString(city.GroupID),
+		nullString(city.DefaultIndex),
+		city.UpdatedAt,
+	).Scan(&city.Name, &city.CreatedAt)
+
+	if errors.Is(err, sql.ErrNoRows) {
+		return fmt.Errorf("city %s: %w", city.ID, ErrNotFound)
+	}
+	if err != nil {
+		return fmt.Errorf("update city: %w", classifyError(err, map[string]string{"name": city.Name}))
+	}
+
+	return nil
+}
+
+// Delete fails with ErrConflict while sources still belong to the city.
+func (r *CityRepository) Delete(ctx context.Context, id string) error {
+	result, err := r.db.ExecContext(ctx, `DELETE FROM cities WHERE id = $1`, id)
+	if err != nil {
+		if isForeignKeyViolation(err) {
+			return fmt.Errorf("city %s still has sources: %w", id, ErrConflict)
+		}
+		return fmt.Errorf("delete city: %w", err)
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("get rows affected: %w", err)
+	}
+	if rows == 0 {
+		return fmt.Errorf("city %s: %w", id, ErrNotFound)
+	}
+
+	return nil
+}
+
+// nullString stores an empty optional field as NULL.
+func nullString(s string) sql.NullString {
+	return sql.NullString{String: s, Valid: s != ""}
+}
+
+func scanCity(row rowScanner) (*models.City, error) {
+	var city models.City
+	var region, timezone, groupID, defaultIndex sql.NullString
+
+	err := row.Scan(
+		&city.ID,
+		&city.Name,
+		&city.DisplayName,
+		&region,
+		&timezone,
+		&groupID,
+		&defaultIndex,
+		&city.CreatedAt,
+		&city.UpdatedAt,
+	)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, err
+	}
+	if err != nil {
+		return nil, fmt.Errorf("scan city: %w", err)
+	}
+
+	city.Region = region.String
+	city.Timezone = timezone.String
+	city.GroupID = groupID.String
+	city.DefaultIndex = defaultIndex.String
+
+	return &city, nil
+}
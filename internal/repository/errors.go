@@ -0,0 +1,160 @@
+package repository
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+
+	"github.com/jonesrussell/gosources/internal/models"
+	"github.com/lib/pq"
+	"modernc.org/sqlite"
+	sqlite3 "modernc.org/sqlite/lib"
+)
+
+var (
+	// ErrNotFound is returned when the requested record does not exist.
+	ErrNotFound = errors.New("not found")
+
+	// ErrConflict is matched by every *ConflictError.
+	ErrConflict = errors.New("conflict")
+
+	// ErrPreconditionFailed is matched by every *VersionMismatchError.
+	ErrPreconditionFailed = errors.New("precondition failed")
+
+	// ErrConstraint is returned when the database rejects a write for a
+	// reason other than uniqueness, such as a NOT NULL or foreign key
+	// violation.
+	ErrConstraint = errors.New("constraint violation")
+)
+
+// ConflictError reports a write that would violate a uniqueness constraint.
+type ConflictError struct {
+	Field string // JSON field whose value must be unique, e.g. "name"
+	Value string
+}
+
+func (e *ConflictError) Error() string {
+	return fmt.Sprintf("%s %q already exists", e.Field, e.Value)
+}
+
+// Is makes errors.Is(err, ErrConflict) true for any ConflictError.
+func (e *ConflictError) Is(target error) bool {
+	return target == ErrConflict
+}
+
+// VersionMismatchError reports a conditional write made against a stale
+// version.
+type VersionMismatchError struct {
+	Expected int
+	Current  int
+}
+
+func (e *VersionMismatchError) Error() string {
+	return fmt.Sprintf("expected version %d but current version is %d", e.Expected, e.Current)
+}
+
+// Is makes errors.Is(err, ErrPreconditionFailed) true for any VersionMismatchError.
+func (e *VersionMismatchError) Is(target error) bool {
+	return target == ErrPreconditionFailed
+}
+
+// PostgreSQL SQLSTATE codes, see https://www.postgresql.org/docs/current/errcodes-appendix.html
+const (
+	pgUniqueViolation     = "23505"
+	pgForeignKeyViolation = "23503"
+	pgNotNullViolation    = "23502"
+	pgCheckViolation      = "23514"
+)
+
+// constraintFields maps unique constraint names (PostgreSQL) and column names
+// (SQLite) to the JSON field reported to clients.
+var constraintFields = map[string]string{
+	"unique_source_name": "name",
+	"unique_user_name":   "name",
+	"unique_cities_name": "name",
+	"sources_pkey":       "id",
+	"name":               "name",
+	"id":                 "id",
+}
+
+var sqliteUniqueColumnRe = regexp.MustCompile(`UNIQUE constraint failed: \w+\.(\w+)`)
+
+// classifyError translates driver-specific constraint errors into
+// ErrConstraint or a *ConflictError, taking the offending value from values.
+// Other errors are returned unchanged.
+func classifyError(err error, values map[string]string) error {
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) {
+		switch pqErr.Code {
+		case pgUniqueViolation:
+			return newConflictError(constraintFields[pqErr.Constraint], values, err)
+		case pgForeignKeyViolation, pgNotNullViolation, pgCheckViolation:
+			return fmt.Errorf("%w: %s", ErrConstraint, pqErr.Message)
+		}
+		return err
+	}
+
+	var sqliteErr *sqlite.Error
+	if errors.As(err, &sqliteErr) {
+		switch sqliteErr.Code() {
+		case sqlite3.SQLITE_CONSTRAINT_UNIQUE, sqlite3.SQLITE_CONSTRAINT_PRIMARYKEY:
+			var column string
+			if match := sqliteUniqueColumnRe.FindStringSubmatch(sqliteErr.Error()); match != nil {
+				column = match[1]
+			}
+			return newConflictError(constraintFields[column], values, err)
+		case sqlite3.SQLITE_CONSTRAINT_FOREIGNKEY, sqlite3.SQLITE_CONSTRAINT_NOTNULL,
+			sqlite3.SQLITE_CONSTRAINT_CHECK:
+			return fmt.Errorf("%w: %s", ErrConstraint, sqliteErr.Error())
+		}
+	}
+
+	return err
+}
+
+// isForeignKeyViolation reports whether the database rejected a write because
+// it would leave a reference dangling.
+func isForeignKeyViolation(err error) bool {
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) {
+		return pqErr.Code == pgForeignKeyViolation
+	}
+	var sqliteErr *sqlite.Error
+	if errors.As(err, &sqliteErr) {
+		return sqliteErr.Code() == sqlite3.SQLITE_CONSTRAINT_FOREIGNKEY
+	}
+	return false
+}
+
+func newConflictError(field string, values map[string]string, cause error) error {
+	if field == "" {
+		return fmt.Errorf("%w: %w", ErrConflict, cause)
+	}
+	return &ConflictError{Field: field, Value: values[field]}
+}
+
+// classifySourceError is classifyError for writes of source. Its only
+// foreign key is city_name, so a violation means the city does not exist.
+func classifySourceError(err error, source *models.Source) error {
+	if source.CityName != nil && isForeignKeyViolation(err) {
+		return UnknownCityError(*source.CityName)
+	}
+	return classifyError(err, sourceValues(source.ID, source.Name))
+}
+
+// UnknownCityError reports a source whose city_name names no city, as a
+// field error so clients can point at the field.
+func UnknownCityError(cityName string) error {
+	return models.ValidationErrors{{
+		Field:   "city_name",
+		Message: fmt.Sprintf("must be the name of an existing city; %q is not a city", cityName),
+	}}
+}
+
+// sourceValues returns the unique fields of a source for conflict reporting.
+func sourceValues(id, name string) map[string]string {
+	return map[string]string{
+		"id":   id,
+		"name": name,
+	}
+}
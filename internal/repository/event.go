@@ -0,0 +1,119 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/jonesrussell/gosources/internal/logger"
+	"github.com/jonesrussell/gosources/internal/models"
+)
+
+// EventLogRepository reads the source_events table that triggers on sources
+// fill in both PostgreSQL and SQLite.
+type EventLogRepository struct {
+	db     *sql.DB
+	logger logger.Logger
+}
+
+func NewEventLogRepository(db *sql.DB, log logger.Logger) *EventLogRepository {
+	return &EventLogRepository{
+		db:     db,
+		logger: log,
+	}
+}
+
+func (r *EventLogRepository) Since(ctx context.Context, after int64, limit int) ([]models.SourceEvent, error) {
+	query := `
+		SELECT id, event, source_id, actor, source, previous, occurred_at
+		FROM source_events
+		WHERE id > $1
+		ORDER BY id
+		LIMIT $2
+	`
+
+	rows, err := r.db.QueryContext(ctx, query, after, limit)
+	if err != nil {
+		return nil, fmt.Errorf("query events: %w", err)
+	}
+	defer rows.Close()
+
+	events := []models.SourceEvent{}
+	for rows.Next() {
+		var event models.SourceEvent
+		var actor sql.NullString
+		var source, previous []byte
+
+		scanErr := rows.Scan(
+			&event.Sequence,
+			&event.Type,
+			&event.SourceID,
+			&actor,
+			&source,
+			&previous,
+			&event.OccurredAt,
+		)
+		if scanErr != nil {
+			return nil, fmt.Errorf("scan event: %w", scanErr)
+		}
+
+		event.ID = strconv.FormatInt(event.Sequence, 10)
+		event.Actor = actor.String
+		event.OccurredAt = event.OccurredAt.UTC()
+
+		if event.Source, err = unmarshalSnapshot(source); err != nil {
+			return nil, fmt.Errorf("unmarshal event %d source: %w", event.Sequence, err)
+		}
+		if event.Previous, err = unmarshalSnapshot(previous); err != nil {
+			return nil, fmt.Errorf("unmarshal event %d previous: %w", event.Sequence, err)
+		}
+
+		events = append(events, event)
+	}
+
+	if rowsErr := rows.Err(); rowsErr != nil {
+		return nil, fmt.Errorf("iterate events: %w", rowsErr)
+	}
+
+	return events, nil
+}
+
+func (r *EventLogRepository) LastID(ctx context.Context) (int64, error) {
+	var id int64
+	if err := r.db.QueryRowContext(ctx, `SELECT COALESCE(MAX(id), 0) FROM source_events`).Scan(&id); err != nil {
+		return 0, fmt.Errorf("query last event: %w", err)
+	}
+	return id, nil
+}
+
+func (r *EventLogRepository) Prune(ctx context.Context, cutoff time.Time) (int64, error) {
+	result, err := r.db.ExecContext(ctx, `DELETE FROM source_events WHERE occurred_at < $1`, cutoff.UTC())
+	if err != nil {
+		return 0, fmt.Errorf("prune events: %w", err)
+	}
+
+	deleted, err := result.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("prune events: %w", err)
+	}
+
+	return deleted, nil
+}
+
+// unmarshalSnapshot decodes a source snapshot written by the triggers. A NULL
+// column is returned as nil.
+func unmarshalSnapshot(data []byte) (*models.Source, error) {
+	if data == nil {
+		return nil, nil //nolint:nilnil // No snapshot for this side of the change
+	}
+
+	var source models.Source
+	if err := json.Unmarshal(data, &source); err != nil {
+		return nil, err
+	}
+
+	return &source, nil
+}
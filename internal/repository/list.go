@@ -0,0 +1,215 @@
+package repository
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/jonesrussell/gosources/internal/models"
+)
+
+const (
+	// MaxListLimit caps the page size a caller may request.
+	MaxListLimit = 500
+
+	cursorTimeLayout = time.RFC3339Nano
+)
+
+var (
+	// ErrInvalidCursor is returned when a pagination cursor cannot be decoded
+	// or was issued for a different sort order.
+	ErrInvalidCursor = errors.New("invalid cursor")
+
+	// ErrInvalidSort is returned when an unknown sort field is requested.
+	ErrInvalidSort = errors.New("invalid sort")
+)
+
+// SortField identifies a column sources can be ordered by.
+type SortField string
+
+const (
+	SortByName      SortField = "name"
+	SortByCreatedAt SortField = "created_at"
+	SortByUpdatedAt SortField = "updated_at"
+)
+
+// ListOptions controls filtering, ordering and pagination of source listings.
+// The zero value lists every source ordered by name.
+type ListOptions struct {
+	Enabled      *bool
+	CityName     string
+	ArticleIndex string
+	Search       string // case-insensitive substring match on name or URL
+	UpdatedSince *time.Time
+
+	// Cities, when not nil, restricts the listing to sources whose city_name
+	// is one of them. An empty, non-nil slice matches nothing.
+	Cities []string
+
+	Sort       SortField
+	Descending bool
+
+	Limit  int    // 0 means no limit
+	Cursor string // opaque cursor returned as ListResult.NextCursor
+}
+
+// ListResult is a single page of sources.
+type ListResult struct {
+	Sources    []models.Source
+	Total      int    // number of rows matching the filters, ignoring pagination
+	NextCursor string // empty when there are no more pages
+}
+
+// ParseSort parses a sort expression such as "name" or "-updated_at".
+func ParseSort(s string) (SortField, bool, error) {
+	descending := strings.HasPrefix(s, "-")
+	field := SortField(strings.TrimPrefix(s, "-"))
+
+	switch field {
+	case "":
+		return SortByName, descending, nil
+	case SortByName, SortByCreatedAt, SortByUpdatedAt:
+		return field, descending, nil
+	default:
+		return "", false, fmt.Errorf("%w: %q", ErrInvalidSort, s)
+	}
+}
+
+func (o ListOptions) sortField() SortField {
+	if o.Sort == "" {
+		return SortByName
+	}
+	return o.Sort
+}
+
+func (o ListOptions) sortKey() string {
+	if o.Descending {
+		return "-" + string(o.sortField())
+	}
+	return string(o.sortField())
+}
+
+// listCursor is the decoded form of a pagination cursor. It records the sort
+// key of the last row on the previous page so the next page can resume from
+// there regardless of inserts or deletes in between.
+type listCursor struct {
+	Sort  string `json:"s"`
+	Value string `json:"v"`
+	ID    string `json:"id"`
+}
+
+func encodeCursor(opts ListOptions, last *models.Source) (string, error) {
+	cur := listCursor{
+		Sort: opts.sortKey(),
+		ID:   last.ID,
+	}
+
+	switch opts.sortField() {
+	case SortByName:
+		cur.Value = last.Name
+	case SortByCreatedAt:
+		cur.Value = last.CreatedAt.UTC().Format(cursorTimeLayout)
+	case SortByUpdatedAt:
+		cur.Value = last.UpdatedAt.UTC().Format(cursorTimeLayout)
+	}
+
+	data, err := json.Marshal(cur)
+	if err != nil {
+		return "", fmt.Errorf("marshal cursor: %w", err)
+	}
+
+	return base64.RawURLEncoding.EncodeToString(data), nil
+}
+
+// decodeCursor returns the sort value and ID encoded in the cursor.
+func decodeCursor(opts ListOptions) (any, string, error) {
+	data, err := base64.RawURLEncoding.DecodeString(opts.Cursor)
+	if err != nil {
+		return nil, "", ErrInvalidCursor
+	}
+
+	var cur listCursor
+	if unmarshalErr := json.Unmarshal(data, &cur); unmarshalErr != nil {
+		return nil, "", ErrInvalidCursor
+	}
+
+	if cur.Sort != opts.sortKey() || cur.ID == "" {
+		return nil, "", ErrInvalidCursor
+	}
+
+	if opts.sortField() == SortByName {
+		return cur.Value, cur.ID, nil
+	}
+
+	t, err := time.Parse(cursorTimeLayout, cur.Value)
+	if err != nil {
+		return nil, "", ErrInvalidCursor
+	}
+
+	// SQLite stores times as text and compares them as text, so every
+	// timestamp in a query must use the same offset as the stored values.
+	return t.UTC(), cur.ID, nil
+}
+
+// listQuery accumulates WHERE clauses and positional arguments.
+type listQuery struct {
+	where []string
+	args  []any
+}
+
+func (q *listQuery) add(clause string, args ...any) {
+	for _, arg := range args {
+		q.args = append(q.args, arg)
+		clause = strings.Replace(clause, "?", fmt.Sprintf("$%d", len(q.args)), 1)
+	}
+	q.where = append(q.where, clause)
+}
+
+func (q *listQuery) whereSQL() string {
+	if len(q.where) == 0 {
+		return ""
+	}
+	return " WHERE " + strings.Join(q.where, " AND ")
+}
+
+// filterQuery builds the WHERE clauses shared by the count and page queries.
+func filterQuery(opts ListOptions) *listQuery {
+	q := &listQuery{}
+
+	if opts.Enabled != nil {
+		q.add("enabled = ?", *opts.Enabled)
+	}
+	if opts.CityName != "" {
+		q.add("city_name = ?", opts.CityName)
+	}
+	if opts.ArticleIndex != "" {
+		q.add("article_index = ?", opts.ArticleIndex)
+	}
+	if opts.Search != "" {
+		pattern := "%" + escapeLike(strings.ToLower(opts.Search)) + "%"
+		q.add(`(LOWER(name) LIKE ? ESCAPE '\' OR LOWER(url) LIKE ? ESCAPE '\')`, pattern, pattern)
+	}
+	if opts.UpdatedSince != nil {
+		q.add("updated_at >= ?", opts.UpdatedSince.UTC())
+	}
+	if opts.Cities != nil {
+		if len(opts.Cities) == 0 {
+			q.add("1 = 0")
+		} else {
+			args := make([]any, len(opts.Cities))
+			for i, city := range opts.Cities {
+				args[i] = city
+			}
+			q.add("city_name IN ("+strings.TrimSuffix(strings.Repeat("?, ", len(args)), ", ")+")", args...)
+		}
+	}
+
+	return q
+}
+
+func escapeLike(s string) string {
+	return strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`).Replace(s)
+}
@@ -0,0 +1,249 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/jonesrussell/gosources/internal/config"
+	"github.com/jonesrussell/gosources/internal/database"
+	"github.com/jonesrussell/gosources/internal/logger"
+	"github.com/jonesrussell/gosources/internal/models"
+)
+
+func TestCursorRoundTrip(t *testing.T) {
+	toronto, err := time.LoadLocation("America/Toronto")
+	if err != nil {
+		t.Fatal(err)
+	}
+	at := time.Date(2026, 3, 8, 1, 30, 0, 123456789, toronto)
+	last := &models.Source{ID: "b", Name: "Sudbury", CreatedAt: at, UpdatedAt: at.Add(time.Hour)}
+
+	tests := []struct {
+		name string
+		opts ListOptions
+		want any
+	}{
+		{name: "name", opts: ListOptions{Sort: SortByName}, want: "Sudbury"},
+		{name: "default sort is name", opts: ListOptions{}, want: "Sudbury"},
+		{name: "name descending", opts: ListOptions{Sort: SortByName, Descending: true}, want: "Sudbury"},
+		{name: "created_at in UTC", opts: ListOptions{Sort: SortByCreatedAt}, want: at.UTC()},
+		{name: "updated_at descending in UTC", opts: ListOptions{Sort: SortByUpdatedAt, Descending: true}, want: at.Add(time.Hour).UTC()},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cursor, err := encodeCursor(tt.opts, last)
+			if err != nil {
+				t.Fatalf("encodeCursor() error = %v", err)
+			}
+
+			tt.opts.Cursor = cursor
+			value, id, err := decodeCursor(tt.opts)
+			if err != nil {
+				t.Fatalf("decodeCursor() error = %v", err)
+			}
+			if id != last.ID {
+				t.Errorf("id = %q, want %q", id, last.ID)
+			}
+
+			switch want := tt.want.(type) {
+			case time.Time:
+				got, ok := value.(time.Time)
+				if !ok || !got.Equal(want) || got.Location() != time.UTC {
+					t.Errorf("value = %v, want %v in UTC", value, want)
+				}
+			default:
+				if value != want {
+					t.Errorf("value = %v, want %v", value, want)
+				}
+			}
+		})
+	}
+}
+
+func TestDecodeCursorInvalid(t *testing.T) {
+	byName, err := encodeCursor(ListOptions{}, &models.Source{ID: "a", Name: "a"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name string
+		opts ListOptions
+	}{
+		{name: "not base64", opts: ListOptions{Cursor: "!!!"}},
+		{name: "not JSON", opts: ListOptions{Cursor: "bm90IGpzb24"}},
+		{name: "other direction", opts: ListOptions{Cursor: byName, Descending: true}},
+		{name: "other field", opts: ListOptions{Cursor: byName, Sort: SortByCreatedAt}},
+		{name: "missing id", opts: ListOptions{Cursor: "eyJzIjoibmFtZSIsInYiOiJhIn0"}},
+		{name: "bad time", opts: ListOptions{Cursor: "eyJzIjoiY3JlYXRlZF9hdCIsInYiOiJhIiwiaWQiOiJhIn0", Sort: SortByCreatedAt}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, _, err := decodeCursor(tt.opts); !errors.Is(err, ErrInvalidCursor) {
+				t.Errorf("decodeCursor() error = %v, want ErrInvalidCursor", err)
+			}
+		})
+	}
+}
+
+// TestListPages walks every sort order a page at a time over sources that
+// share timestamps, so the ID tie-break decides where pages split.
+func TestListPages(t *testing.T) {
+	toronto, err := time.LoadLocation("America/Toronto")
+	if err != nil {
+		t.Fatal(err)
+	}
+	base := time.Date(2026, 1, 2, 15, 0, 0, 0, time.UTC)
+	stamps := []time.Time{base, base.Add(time.Minute), base.Add(time.Minute), base.Add(time.Minute), base.Add(time.Hour)}
+
+	for name, store := range listStores(t) {
+		t.Run(name, func(t *testing.T) {
+			ctx := context.Background()
+			var all []models.Source
+			for i, at := range stamps {
+				source := &models.Source{
+					Name:         fmt.Sprintf("source-%d", len(stamps)-i),
+					URL:          fmt.Sprintf("https://example.com/%d", i),
+					ArticleIndex: "articles",
+					PageIndex:    "pages",
+				}
+				if createErr := store.create(ctx, source, at); createErr != nil {
+					t.Fatalf("create: %v", createErr)
+				}
+				all = append(all, *source)
+			}
+
+			tests := []struct {
+				name string
+				opts ListOptions
+			}{
+				{name: "name", opts: ListOptions{Sort: SortByName}},
+				{name: "-name", opts: ListOptions{Sort: SortByName, Descending: true}},
+				{name: "created_at", opts: ListOptions{Sort: SortByCreatedAt}},
+				{name: "-updated_at", opts: ListOptions{Sort: SortByUpdatedAt, Descending: true}},
+				{
+					name: "updated_since in another zone",
+					opts: ListOptions{Sort: SortByUpdatedAt, UpdatedSince: ptr(base.Add(time.Minute).In(toronto))},
+				},
+			}
+
+			for _, tt := range tests {
+				t.Run(tt.name, func(t *testing.T) {
+					want := expectedOrder(all, tt.opts)
+
+					var got []string
+					opts := tt.opts
+					opts.Limit = 2
+					for page := 0; ; page++ {
+						if page > len(all) {
+							t.Fatal("pagination did not finish")
+						}
+						result, listErr := store.List(ctx, opts)
+						if listErr != nil {
+							t.Fatalf("List() error = %v", listErr)
+						}
+						if result.Total != len(want) {
+							t.Errorf("Total = %d, want %d", result.Total, len(want))
+						}
+						for i := range result.Sources {
+							got = append(got, result.Sources[i].ID)
+						}
+						if result.NextCursor == "" {
+							break
+						}
+						opts.Cursor = result.NextCursor
+					}
+
+					if !slices.Equal(got, want) {
+						t.Errorf("order = %v, want %v", got, want)
+					}
+				})
+			}
+		})
+	}
+}
+
+// listStore is a SourceStore whose timestamps a test can set.
+type listStore struct {
+	SourceStore
+	create func(ctx context.Context, source *models.Source, at time.Time) error
+}
+
+func listStores(t *testing.T) map[string]listStore {
+	t.Helper()
+
+	memory := NewMemorySourceStore(NewMemoryEventLog(logger.NewNopLogger()), logger.NewNopLogger())
+
+	cfg := &config.Config{Database: config.DatabaseConfig{
+		Driver: config.DriverSQLite,
+		Path:   filepath.Join(t.TempDir(), "sources.db"),
+	}}
+	db, err := database.New(cfg, logger.NewNopLogger())
+	if err != nil {
+		t.Fatalf("open sqlite: %v", err)
+	}
+	t.Cleanup(func() { _ = db.Close() })
+	sqlite := NewSourceRepository(db.DB(), logger.NewNopLogger())
+
+	return map[string]listStore{
+		"memory": {
+			SourceStore: memory,
+			create: func(ctx context.Context, source *models.Source, at time.Time) error {
+				if err := memory.Create(ctx, source); err != nil {
+					return err
+				}
+				source.CreatedAt, source.UpdatedAt = at, at
+				memory.mu.Lock()
+				memory.sources[source.ID] = cloneSource(source)
+				memory.mu.Unlock()
+				return nil
+			},
+		},
+		"sqlite": {
+			SourceStore: sqlite,
+			create: func(ctx context.Context, source *models.Source, at time.Time) error {
+				if err := sqlite.Create(ctx, source); err != nil {
+					return err
+				}
+				source.CreatedAt, source.UpdatedAt = at, at
+				_, err := db.DB().ExecContext(ctx,
+					`UPDATE sources SET created_at = $1, updated_at = $1 WHERE id = $2`, at, source.ID)
+				return err
+			},
+		},
+	}
+}
+
+// expectedOrder returns the IDs of the sources opts selects, in order.
+func expectedOrder(sources []models.Source, opts ListOptions) []string {
+	var matched []models.Source
+	for i := range sources {
+		if matchesFilter(opts, &sources[i]) {
+			matched = append(matched, sources[i])
+		}
+	}
+	slices.SortFunc(matched, func(a, b models.Source) int {
+		c := compareSources(opts.sortField(), &a, &b)
+		if opts.Descending {
+			return -c
+		}
+		return c
+	})
+
+	ids := make([]string, len(matched))
+	for i := range matched {
+		ids[i] = matched[i].ID
+	}
+	return ids
+}
+
+func ptr[T any](v T) *T {
+	return &v
+}
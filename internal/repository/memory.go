@@ -0,0 +1,417 @@
+package repository
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jonesrussell/gosources/internal/logger"
+	"github.com/jonesrussell/gosources/internal/models"
+)
+
+// MemorySourceStore keeps sources in process memory. It enforces the same
+// uniqueness rules and city references as the SQL schema and is intended for
+// local development and tests. The cities themselves are managed through a
+// MemoryCityStore. Every change is appended to events under the same lock,
+// as the SQL triggers write source_events in the same transaction.
+type MemorySourceStore struct {
+	mu        sync.RWMutex
+	sources   map[string]models.Source
+	revisions map[string][]models.SourceRevision // oldest first
+	cities    map[string]models.City             // by ID
+	events    *MemoryEventLog
+	logger    logger.Logger
+}
+
+func NewMemorySourceStore(events *MemoryEventLog, log logger.Logger) *MemorySourceStore {
+	return &MemorySourceStore{
+		sources:   make(map[string]models.Source),
+		revisions: make(map[string][]models.SourceRevision),
+		cities:    make(map[string]models.City),
+		events:    events,
+		logger:    log,
+	}
+}
+
+func (s *MemorySourceStore) Create(ctx context.Context, source *models.Source) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	source.ID = uuid.New().String()
+	source.Version = 1
+	source.CreatedAt = time.Now().UTC()
+	source.UpdatedAt = source.CreatedAt
+
+	if err := s.checkUnique(source); err != nil {
+		return err
+	}
+	if err := s.checkCity(source); err != nil {
+		return err
+	}
+
+	s.sources[source.ID] = cloneSource(source)
+	s.recordRevision(ctx, source, models.RevisionCreate)
+	s.events.record(ctx, source.ID, source, nil)
+
+	return nil
+}
+
+func (s *MemorySourceStore) GetByID(_ context.Context, id string) (*models.Source, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	source, ok := s.sources[id]
+	if !ok {
+		return nil, fmt.Errorf("source %s: %w", id, ErrNotFound)
+	}
+
+	clone := cloneSource(&source)
+	return &clone, nil
+}
+
+func (s *MemorySourceStore) List(_ context.Context, opts ListOptions) (*ListResult, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var matched []models.Source
+	for id := range s.sources {
+		source := s.sources[id]
+		if matchesFilter(opts, &source) {
+			matched = append(matched, cloneSource(&source))
+		}
+	}
+
+	slices.SortFunc(matched, func(a, b models.Source) int {
+		c := compareSources(opts.sortField(), &a, &b)
+		if opts.Descending {
+			return -c
+		}
+		return c
+	})
+
+	result := &ListResult{Total: len(matched)}
+
+	if opts.Cursor != "" {
+		value, id, err := decodeCursor(opts)
+		if err != nil {
+			return nil, err
+		}
+		start := len(matched)
+		for i := range matched {
+			if afterCursor(opts, &matched[i], value, id) {
+				start = i
+				break
+			}
+		}
+		matched = matched[start:]
+	}
+
+	if opts.Limit > 0 && len(matched) > opts.Limit {
+		matched = matched[:opts.Limit]
+		cursor, err := encodeCursor(opts, &matched[opts.Limit-1])
+		if err != nil {
+			return nil, err
+		}
+		result.NextCursor = cursor
+	}
+
+	result.Sources = matched
+
+	return result, nil
+}
+
+func (s *MemorySourceStore) Update(ctx context.Context, source *models.Source) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	existing, ok := s.sources[source.ID]
+	if !ok {
+		return fmt.Errorf("source %s: %w", source.ID, ErrNotFound)
+	}
+
+	if source.Version != 0 && source.Version != existing.Version {
+		return &VersionMismatchError{Expected: source.Version, Current: existing.Version}
+	}
+
+	if err := s.checkUnique(source); err != nil {
+		return err
+	}
+	if err := s.checkCity(source); err != nil {
+		return err
+	}
+
+	source.Version = existing.Version + 1
+	source.CreatedAt = existing.CreatedAt
+	source.UpdatedAt = time.Now().UTC()
+	s.sources[source.ID] = cloneSource(source)
+	s.recordRevision(ctx, source, models.RevisionUpdate)
+	s.events.record(ctx, source.ID, source, &existing)
+
+	return nil
+}
+
+func (s *MemorySourceStore) Delete(ctx context.Context, id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	source, ok := s.sources[id]
+	if !ok {
+		return fmt.Errorf("source %s: %w", id, ErrNotFound)
+	}
+
+	delete(s.sources, id)
+	s.recordRevision(ctx, &source, models.RevisionDelete)
+	s.events.record(ctx, id, nil, &source)
+
+	return nil
+}
+
+func (s *MemorySourceStore) ListRevisions(_ context.Context, sourceID string) ([]models.SourceRevision, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	history := s.revisions[sourceID]
+	if len(history) == 0 {
+		return nil, fmt.Errorf("source %s: %w", sourceID, ErrNotFound)
+	}
+
+	revisions := make([]models.SourceRevision, 0, len(history))
+	for i := len(history) - 1; i >= 0; i-- {
+		revisions = append(revisions, cloneRevision(&history[i]))
+	}
+
+	return revisions, nil
+}
+
+func (s *MemorySourceStore) GetRevision(_ context.Context, sourceID string, revision int) (*models.SourceRevision, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return s.getRevision(sourceID, revision)
+}
+
+func (s *MemorySourceStore) Restore(ctx context.Context, sourceID string, revision int) (*models.Source, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	rev, err := s.getRevision(sourceID, revision)
+	if err != nil {
+		return nil, err
+	}
+
+	source := rev.Snapshot
+	source.ID = sourceID
+	source.UpdatedAt = time.Now().UTC()
+	// previous stays nil when restoring a deleted source, which is logged as
+	// a creation.
+	var previous *models.Source
+	if existing, ok := s.sources[sourceID]; ok {
+		previous = &existing
+		source.CreatedAt = existing.CreatedAt
+		source.Version = existing.Version + 1
+	} else {
+		history := s.revisions[sourceID]
+		source.Version = history[len(history)-1].Snapshot.Version + 1
+	}
+
+	if uniqueErr := s.checkUnique(&source); uniqueErr != nil {
+		return nil, uniqueErr
+	}
+	if cityErr := s.checkCity(&source); cityErr != nil {
+		return nil, cityErr
+	}
+
+	s.sources[sourceID] = cloneSource(&source)
+	s.recordRevision(ctx, &source, models.RevisionRestore)
+	s.events.record(ctx, sourceID, &source, previous)
+
+	return &source, nil
+}
+
+// getRevision returns a copy of a stored revision. Callers must hold the lock.
+func (s *MemorySourceStore) getRevision(sourceID string, revision int) (*models.SourceRevision, error) {
+	history := s.revisions[sourceID]
+	if revision < 1 || revision > len(history) {
+		return nil, fmt.Errorf("revision %d of source %s: %w", revision, sourceID, ErrNotFound)
+	}
+
+	rev := cloneRevision(&history[revision-1])
+	return &rev, nil
+}
+
+// recordRevision appends a snapshot of source. Callers must hold the write lock.
+func (s *MemorySourceStore) recordRevision(ctx context.Context, source *models.Source, action models.RevisionAction) {
+	history := s.revisions[source.ID]
+	s.revisions[source.ID] = append(history, models.SourceRevision{
+		SourceID:  source.ID,
+		Revision:  len(history) + 1,
+		Action:    action,
+		Actor:     ActorFromContext(ctx),
+		Snapshot:  cloneSource(source),
+		CreatedAt: time.Now().UTC(),
+	})
+}
+
+func (s *MemorySourceStore) GetCities(_ context.Context) ([]models.CityIndex, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	byCity := make(map[string][]models.Source)
+	for id := range s.sources {
+		source := s.sources[id]
+		if source.Enabled && source.CityName != nil {
+			byCity[*source.CityName] = append(byCity[*source.CityName], cloneSource(&source))
+		}
+	}
+
+	var cities []models.CityIndex
+	for name, sources := range byCity {
+		city, ok := s.cityByName(name)
+		if !ok {
+			continue
+		}
+		slices.SortFunc(sources, func(a, b models.Source) int {
+			if c := cmp.Compare(a.Name, b.Name); c != 0 {
+				return c
+			}
+			return cmp.Compare(a.ID, b.ID)
+		})
+		cities = append(cities, models.NewCityIndex(&city, sources))
+	}
+
+	slices.SortFunc(cities, func(a, b models.CityIndex) int {
+		return cmp.Compare(a.Name, b.Name)
+	})
+
+	return cities, nil
+}
+
+// checkUnique mirrors the unique_source_name constraint. Callers must hold the
+// write lock.
+func (s *MemorySourceStore) checkUnique(source *models.Source) error {
+	for id := range s.sources {
+		if id != source.ID && s.sources[id].Name == source.Name {
+			return &ConflictError{Field: "name", Value: source.Name}
+		}
+	}
+	return nil
+}
+
+// checkCity mirrors the foreign key from sources.city_name to cities. Callers
+// must hold the write lock.
+func (s *MemorySourceStore) checkCity(source *models.Source) error {
+	if source.CityName == nil {
+		return nil
+	}
+	if _, ok := s.cityByName(*source.CityName); !ok {
+		return UnknownCityError(*source.CityName)
+	}
+	return nil
+}
+
+// cityByName finds a city by its name. Callers must hold the lock.
+func (s *MemorySourceStore) cityByName(name string) (models.City, bool) {
+	for id := range s.cities {
+		if s.cities[id].Name == name {
+			return s.cities[id], true
+		}
+	}
+	return models.City{}, false
+}
+
+func matchesFilter(opts ListOptions, source *models.Source) bool {
+	if opts.Enabled != nil && source.Enabled != *opts.Enabled {
+		return false
+	}
+	if opts.CityName != "" && (source.CityName == nil || *source.CityName != opts.CityName) {
+		return false
+	}
+	if opts.ArticleIndex != "" && source.ArticleIndex != opts.ArticleIndex {
+		return false
+	}
+	if opts.Search != "" {
+		search := strings.ToLower(opts.Search)
+		if !strings.Contains(strings.ToLower(source.Name), search) &&
+			!strings.Contains(strings.ToLower(source.URL), search) {
+			return false
+		}
+	}
+	if opts.UpdatedSince != nil && source.UpdatedAt.Before(*opts.UpdatedSince) {
+		return false
+	}
+	if opts.Cities != nil && (source.CityName == nil || !slices.Contains(opts.Cities, *source.CityName)) {
+		return false
+	}
+	return true
+}
+
+// compareSources orders by the sort field and then by ID, matching the SQL
+// ORDER BY used by SourceRepository.
+func compareSources(field SortField, a, b *models.Source) int {
+	var c int
+	switch field {
+	case SortByName:
+		c = cmp.Compare(a.Name, b.Name)
+	case SortByCreatedAt:
+		c = a.CreatedAt.Compare(b.CreatedAt)
+	case SortByUpdatedAt:
+		c = a.UpdatedAt.Compare(b.UpdatedAt)
+	}
+	if c != 0 {
+		return c
+	}
+	return cmp.Compare(a.ID, b.ID)
+}
+
+// afterCursor reports whether source sorts strictly after the cursor position.
+func afterCursor(opts ListOptions, source *models.Source, value any, id string) bool {
+	pivot := models.Source{ID: id}
+	switch v := value.(type) {
+	case string:
+		pivot.Name = v
+	case time.Time:
+		pivot.CreatedAt = v
+		pivot.UpdatedAt = v
+	}
+
+	c := compareSources(opts.sortField(), source, &pivot)
+	if opts.Descending {
+		return c < 0
+	}
+	return c > 0
+}
+
+// cloneSource returns a deep copy so callers cannot mutate stored state.
+func cloneSource(source *models.Source) models.Source {
+	clone := *source
+	clone.Time = slices.Clone(source.Time)
+	if source.Schedule != nil {
+		schedule := *source.Schedule
+		schedule.Times = slices.Clone(source.Schedule.Times)
+		schedule.Weekdays = slices.Clone(source.Schedule.Weekdays)
+		clone.Schedule = &schedule
+	}
+	clone.Selectors.Article.Exclude = slices.Clone(source.Selectors.Article.Exclude)
+	clone.Selectors.List.ExcludeFromList = slices.Clone(source.Selectors.List.ExcludeFromList)
+	clone.Selectors.Page.Exclude = slices.Clone(source.Selectors.Page.Exclude)
+	if source.CityName != nil {
+		cityName := *source.CityName
+		clone.CityName = &cityName
+	}
+	if source.GroupID != nil {
+		groupID := *source.GroupID
+		clone.GroupID = &groupID
+	}
+	return clone
+}
+
+func cloneRevision(rev *models.SourceRevision) models.SourceRevision {
+	clone := *rev
+	clone.Snapshot = cloneSource(&rev.Snapshot)
+	return clone
+}
@@ -0,0 +1,134 @@
+package repository
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jonesrussell/gosources/internal/logger"
+	"github.com/jonesrussell/gosources/internal/models"
+)
+
+// MemoryAPIKeyStore keeps API keys in process memory, for use with
+// MemorySourceStore.
+type MemoryAPIKeyStore struct {
+	mu     sync.Mutex
+	keys   map[string]models.APIKey
+	logger logger.Logger
+}
+
+func NewMemoryAPIKeyStore(log logger.Logger) *MemoryAPIKeyStore {
+	return &MemoryAPIKeyStore{
+		keys:   make(map[string]models.APIKey),
+		logger: log,
+	}
+}
+
+func (s *MemoryAPIKeyStore) Create(_ context.Context, key *models.APIKey) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for id := range s.keys {
+		if s.keys[id].Hash == key.Hash {
+			return &ConflictError{Field: "key", Value: key.Prefix}
+		}
+	}
+
+	key.ID = uuid.New().String()
+	key.CreatedAt = time.Now().UTC()
+	s.keys[key.ID] = cloneAPIKey(key)
+
+	return nil
+}
+
+func (s *MemoryAPIKeyStore) GetByHash(_ context.Context, hash string) (*models.APIKey, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for id := range s.keys {
+		if key := s.keys[id]; key.Hash == hash {
+			clone := cloneAPIKey(&key)
+			return &clone, nil
+		}
+	}
+
+	return nil, fmt.Errorf("api key: %w", ErrNotFound)
+}
+
+func (s *MemoryAPIKeyStore) List(_ context.Context) ([]models.APIKey, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	keys := make([]models.APIKey, 0, len(s.keys))
+	for id := range s.keys {
+		key := s.keys[id]
+		keys = append(keys, cloneAPIKey(&key))
+	}
+
+	slices.SortFunc(keys, func(a, b models.APIKey) int {
+		if c := a.CreatedAt.Compare(b.CreatedAt); c != 0 {
+			return c
+		}
+		return cmp.Compare(a.ID, b.ID)
+	})
+
+	return keys, nil
+}
+
+func (s *MemoryAPIKeyStore) Revoke(_ context.Context, id string) (*models.APIKey, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	key, ok := s.keys[id]
+	if !ok {
+		return nil, fmt.Errorf("api key %s: %w", id, ErrNotFound)
+	}
+
+	if key.RevokedAt == nil {
+		now := time.Now().UTC()
+		key.RevokedAt = &now
+		s.keys[id] = key
+	}
+
+	clone := cloneAPIKey(&key)
+	return &clone, nil
+}
+
+func (s *MemoryAPIKeyStore) Touch(_ context.Context, id string, usedAt time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if key, ok := s.keys[id]; ok {
+		usedAt = usedAt.UTC()
+		key.LastUsedAt = &usedAt
+		s.keys[id] = key
+	}
+
+	return nil
+}
+
+// deleteForUser removes the keys of a deleted user.
+func (s *MemoryAPIKeyStore) deleteForUser(userID string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for id := range s.keys {
+		if owner := s.keys[id].UserID; owner != nil && *owner == userID {
+			delete(s.keys, id)
+		}
+	}
+}
+
+func cloneAPIKey(key *models.APIKey) models.APIKey {
+	clone := *key
+	clone.Scopes = slices.Clone(key.Scopes)
+	if key.UserID != nil {
+		userID := *key.UserID
+		clone.UserID = &userID
+	}
+	return clone
+}
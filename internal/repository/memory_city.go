@@ -0,0 +1,108 @@
+package repository
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jonesrussell/gosources/internal/logger"
+	"github.com/jonesrussell/gosources/internal/models"
+)
+
+// MemoryCityStore keeps cities in process memory alongside the sources of a
+// MemorySourceStore, so sources can only name existing cities and cities in
+// use cannot be deleted, as in the SQL schema.
+type MemoryCityStore struct {
+	sources *MemorySourceStore
+	logger  logger.Logger
+}
+
+func NewMemoryCityStore(sources *MemorySourceStore, log logger.Logger) *MemoryCityStore {
+	return &MemoryCityStore{
+		sources: sources,
+		logger:  log,
+	}
+}
+
+func (s *MemoryCityStore) Create(_ context.Context, city *models.City) error {
+	s.sources.mu.Lock()
+	defer s.sources.mu.Unlock()
+
+	if _, ok := s.sources.cityByName(city.Name); ok {
+		return &ConflictError{Field: "name", Value: city.Name}
+	}
+
+	city.ID = uuid.New().String()
+	city.CreatedAt = time.Now().UTC()
+	city.UpdatedAt = city.CreatedAt
+	s.sources.cities[city.ID] = *city
+
+	return nil
+}
+
+func (s *MemoryCityStore) GetByID(_ context.Context, id string) (*models.City, error) {
+	s.sources.mu.RLock()
+	defer s.sources.mu.RUnlock()
+
+	city, ok := s.sources.cities[id]
+	if !ok {
+		return nil, fmt.Errorf("city %s: %w", id, ErrNotFound)
+	}
+
+	return &city, nil
+}
+
+func (s *MemoryCityStore) List(_ context.Context) ([]models.City, error) {
+	s.sources.mu.RLock()
+	defer s.sources.mu.RUnlock()
+
+	cities := make([]models.City, 0, len(s.sources.cities))
+	for id := range s.sources.cities {
+		cities = append(cities, s.sources.cities[id])
+	}
+
+	slices.SortFunc(cities, func(a, b models.City) int {
+		return cmp.Compare(a.Name, b.Name)
+	})
+
+	return cities, nil
+}
+
+func (s *MemoryCityStore) Update(_ context.Context, city *models.City) error {
+	s.sources.mu.Lock()
+	defer s.sources.mu.Unlock()
+
+	existing, ok := s.sources.cities[city.ID]
+	if !ok {
+		return fmt.Errorf("city %s: %w", city.ID, ErrNotFound)
+	}
+
+	city.Name = existing.Name
+	city.CreatedAt = existing.CreatedAt
+	city.UpdatedAt = time.Now().UTC()
+	s.sources.cities[city.ID] = *city
+
+	return nil
+}
+
+func (s *MemoryCityStore) Delete(_ context.Context, id string) error {
+	s.sources.mu.Lock()
+	defer s.sources.mu.Unlock()
+
+	city, ok := s.sources.cities[id]
+	if !ok {
+		return fmt.Errorf("city %s: %w", id, ErrNotFound)
+	}
+	for sourceID := range s.sources.sources {
+		if name := s.sources.sources[sourceID].CityName; name != nil && *name == city.Name {
+			return fmt.Errorf("city %s still has sources: %w", id, ErrConflict)
+		}
+	}
+
+	delete(s.sources.cities, id)
+
+	return nil
+}
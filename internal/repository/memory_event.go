@@ -0,0 +1,113 @@
+package repository
+
+import (
+	"context"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/jonesrussell/gosources/internal/logger"
+	"github.com/jonesrussell/gosources/internal/models"
+)
+
+// MemoryEventLog keeps the event log in process memory, for use with
+// MemorySourceStore. Having no triggers, it is written by the store itself.
+type MemoryEventLog struct {
+	mu     sync.Mutex
+	events []models.SourceEvent
+	lastID int64
+	logger logger.Logger
+}
+
+func NewMemoryEventLog(log logger.Logger) *MemoryEventLog {
+	return &MemoryEventLog{
+		logger: log,
+	}
+}
+
+// record appends the events for a change from previous to source, as the
+// source_events triggers do: source.created when previous is nil,
+// source.deleted when source is nil and otherwise source.updated, followed by
+// source.enabled or source.disabled when the enabled flag flipped.
+func (l *MemoryEventLog) record(ctx context.Context, sourceID string, source, previous *models.Source) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := time.Now().UTC()
+	for _, eventType := range eventTypes(source, previous) {
+		l.lastID++
+		l.events = append(l.events, models.SourceEvent{
+			ID:         strconv.FormatInt(l.lastID, 10),
+			Sequence:   l.lastID,
+			Type:       eventType,
+			SourceID:   sourceID,
+			Actor:      ActorFromContext(ctx),
+			OccurredAt: now,
+			Source:     cloneSnapshot(source),
+			Previous:   cloneSnapshot(previous),
+		})
+	}
+}
+
+func eventTypes(source, previous *models.Source) []models.EventType {
+	switch {
+	case previous == nil:
+		return []models.EventType{models.EventSourceCreated}
+	case source == nil:
+		return []models.EventType{models.EventSourceDeleted}
+	case source.Enabled && !previous.Enabled:
+		return []models.EventType{models.EventSourceUpdated, models.EventSourceEnabled}
+	case !source.Enabled && previous.Enabled:
+		return []models.EventType{models.EventSourceUpdated, models.EventSourceDisabled}
+	default:
+		return []models.EventType{models.EventSourceUpdated}
+	}
+}
+
+func (l *MemoryEventLog) Since(_ context.Context, after int64, limit int) ([]models.SourceEvent, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	events := []models.SourceEvent{}
+	for i := range l.events {
+		if l.events[i].Sequence > after {
+			events = append(events, l.events[i])
+			if len(events) == limit {
+				break
+			}
+		}
+	}
+
+	return events, nil
+}
+
+func (l *MemoryEventLog) LastID(_ context.Context) (int64, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	return l.lastID, nil
+}
+
+func (l *MemoryEventLog) Prune(_ context.Context, cutoff time.Time) (int64, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	kept := l.events[:0]
+	for i := range l.events {
+		if !l.events[i].OccurredAt.Before(cutoff) {
+			kept = append(kept, l.events[i])
+		}
+	}
+	deleted := int64(len(l.events) - len(kept))
+	l.events = kept
+
+	return deleted, nil
+}
+
+func cloneSnapshot(source *models.Source) *models.Source {
+	if source == nil {
+		return nil
+	}
+	clone := cloneSource(source)
+	return &clone
+}
@@ -0,0 +1,135 @@
+package repository
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jonesrussell/gosources/internal/logger"
+	"github.com/jonesrussell/gosources/internal/models"
+)
+
+// MemoryUserStore keeps users in process memory, for use with
+// MemorySourceStore.
+type MemoryUserStore struct {
+	mu     sync.Mutex
+	users  map[string]models.User
+	keys   *MemoryAPIKeyStore
+	logger logger.Logger
+}
+
+// NewMemoryUserStore returns a store that deletes a user's keys from keys
+// along with the user, as the SQL foreign key does.
+func NewMemoryUserStore(keys *MemoryAPIKeyStore, log logger.Logger) *MemoryUserStore {
+	return &MemoryUserStore{
+		users:  make(map[string]models.User),
+		keys:   keys,
+		logger: log,
+	}
+}
+
+func (s *MemoryUserStore) Create(_ context.Context, user *models.User) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err := s.checkName(user); err != nil {
+		return err
+	}
+
+	user.ID = uuid.New().String()
+	user.CreatedAt = time.Now().UTC()
+	user.UpdatedAt = user.CreatedAt
+	s.users[user.ID] = cloneUser(user)
+
+	return nil
+}
+
+func (s *MemoryUserStore) GetByID(_ context.Context, id string) (*models.User, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	user, ok := s.users[id]
+	if !ok {
+		return nil, fmt.Errorf("user %s: %w", id, ErrNotFound)
+	}
+
+	clone := cloneUser(&user)
+	return &clone, nil
+}
+
+func (s *MemoryUserStore) List(_ context.Context) ([]models.User, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	users := make([]models.User, 0, len(s.users))
+	for id := range s.users {
+		user := s.users[id]
+		users = append(users, cloneUser(&user))
+	}
+
+	slices.SortFunc(users, func(a, b models.User) int {
+		if c := cmp.Compare(a.Name, b.Name); c != 0 {
+			return c
+		}
+		return cmp.Compare(a.ID, b.ID)
+	})
+
+	return users, nil
+}
+
+func (s *MemoryUserStore) Update(_ context.Context, user *models.User) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	existing, ok := s.users[user.ID]
+	if !ok {
+		return fmt.Errorf("user %s: %w", user.ID, ErrNotFound)
+	}
+	if err := s.checkName(user); err != nil {
+		return err
+	}
+
+	user.CreatedAt = existing.CreatedAt
+	user.UpdatedAt = time.Now().UTC()
+	s.users[user.ID] = cloneUser(user)
+
+	return nil
+}
+
+func (s *MemoryUserStore) Delete(_ context.Context, id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.users[id]; !ok {
+		return fmt.Errorf("user %s: %w", id, ErrNotFound)
+	}
+
+	delete(s.users, id)
+	s.keys.deleteForUser(id)
+
+	return nil
+}
+
+// checkName reports a conflict when another user has the same name. The
+// caller holds s.mu.
+func (s *MemoryUserStore) checkName(user *models.User) error {
+	for id := range s.users {
+		if id != user.ID && s.users[id].Name == user.Name {
+			return &ConflictError{Field: "name", Value: user.Name}
+		}
+	}
+	return nil
+}
+
+func cloneUser(user *models.User) models.User {
+	clone := *user
+	clone.Cities = slices.Clone(user.Cities)
+	if clone.Cities == nil {
+		clone.Cities = []string{}
+	}
+	return clone
+}
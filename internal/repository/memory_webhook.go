@@ -0,0 +1,252 @@
+package repository
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jonesrussell/gosources/internal/logger"
+	"github.com/jonesrussell/gosources/internal/models"
+)
+
+// MemoryWebhookStore keeps webhooks and their delivery queue in process
+// memory, for use with MemorySourceStore.
+type MemoryWebhookStore struct {
+	mu         sync.Mutex
+	webhooks   map[string]models.Webhook
+	deliveries map[string]models.WebhookDelivery
+	cursor     int64
+	logger     logger.Logger
+}
+
+func NewMemoryWebhookStore(log logger.Logger) *MemoryWebhookStore {
+	return &MemoryWebhookStore{
+		webhooks:   make(map[string]models.Webhook),
+		deliveries: make(map[string]models.WebhookDelivery),
+		logger:     log,
+	}
+}
+
+func (s *MemoryWebhookStore) Create(_ context.Context, webhook *models.Webhook) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	webhook.ID = uuid.New().String()
+	webhook.CreatedAt = time.Now().UTC()
+	webhook.UpdatedAt = webhook.CreatedAt
+	s.webhooks[webhook.ID] = cloneWebhook(webhook)
+
+	return nil
+}
+
+func (s *MemoryWebhookStore) GetByID(_ context.Context, id string) (*models.Webhook, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	webhook, ok := s.webhooks[id]
+	if !ok {
+		return nil, fmt.Errorf("webhook %s: %w", id, ErrNotFound)
+	}
+
+	clone := cloneWebhook(&webhook)
+	return &clone, nil
+}
+
+func (s *MemoryWebhookStore) List(_ context.Context) ([]models.Webhook, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	webhooks := make([]models.Webhook, 0, len(s.webhooks))
+	for id := range s.webhooks {
+		webhook := s.webhooks[id]
+		webhooks = append(webhooks, cloneWebhook(&webhook))
+	}
+
+	slices.SortFunc(webhooks, func(a, b models.Webhook) int {
+		if c := a.CreatedAt.Compare(b.CreatedAt); c != 0 {
+			return c
+		}
+		return cmp.Compare(a.ID, b.ID)
+	})
+
+	return webhooks, nil
+}
+
+func (s *MemoryWebhookStore) Update(_ context.Context, webhook *models.Webhook) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	existing, ok := s.webhooks[webhook.ID]
+	if !ok {
+		return fmt.Errorf("webhook %s: %w", webhook.ID, ErrNotFound)
+	}
+
+	webhook.CreatedAt = existing.CreatedAt
+	webhook.UpdatedAt = time.Now().UTC()
+	s.webhooks[webhook.ID] = cloneWebhook(webhook)
+
+	return nil
+}
+
+func (s *MemoryWebhookStore) Delete(_ context.Context, id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.webhooks[id]; !ok {
+		return fmt.Errorf("webhook %s: %w", id, ErrNotFound)
+	}
+
+	delete(s.webhooks, id)
+	for deliveryID, delivery := range s.deliveries {
+		if delivery.WebhookID == id {
+			delete(s.deliveries, deliveryID)
+		}
+	}
+
+	return nil
+}
+
+func (s *MemoryWebhookStore) Enqueue(_ context.Context, deliveries []models.WebhookDelivery) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.enqueue(deliveries)
+}
+
+func (s *MemoryWebhookStore) EventCursor(_ context.Context) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.cursor, nil
+}
+
+func (s *MemoryWebhookStore) QueueEvents(
+	_ context.Context, cursor, last int64, deliveries []models.WebhookDelivery,
+) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.cursor != cursor {
+		return fmt.Errorf("event cursor moved past %d: %w", cursor, ErrConflict)
+	}
+	if err := s.enqueue(deliveries); err != nil {
+		return err
+	}
+	s.cursor = last
+
+	return nil
+}
+
+// enqueue stores deliveries, all or none. The caller holds s.mu.
+func (s *MemoryWebhookStore) enqueue(deliveries []models.WebhookDelivery) error {
+	for i := range deliveries {
+		if _, ok := s.webhooks[deliveries[i].WebhookID]; !ok {
+			return fmt.Errorf("webhook %s: %w", deliveries[i].WebhookID, ErrConstraint)
+		}
+	}
+
+	now := time.Now().UTC()
+	for i := range deliveries {
+		d := &deliveries[i]
+		d.ID = uuid.New().String()
+		d.Status = models.DeliveryPending
+		d.CreatedAt = now
+		d.UpdatedAt = now
+		if d.NextAttemptAt.IsZero() {
+			d.NextAttemptAt = now
+		}
+		s.deliveries[d.ID] = cloneDelivery(d)
+	}
+
+	return nil
+}
+
+func (s *MemoryWebhookStore) Claim(
+	_ context.Context, now, leaseUntil time.Time, limit int,
+) ([]models.WebhookDelivery, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var due []models.WebhookDelivery
+	for id := range s.deliveries {
+		d := s.deliveries[id]
+		if d.Status == models.DeliveryPending && !d.NextAttemptAt.After(now) {
+			due = append(due, d)
+		}
+	}
+
+	slices.SortFunc(due, func(a, b models.WebhookDelivery) int {
+		return a.NextAttemptAt.Compare(b.NextAttemptAt)
+	})
+	if len(due) > limit {
+		due = due[:limit]
+	}
+
+	claimed := make([]models.WebhookDelivery, 0, len(due))
+	for i := range due {
+		d := &due[i]
+		d.NextAttemptAt = leaseUntil.UTC()
+		d.UpdatedAt = now.UTC()
+		s.deliveries[d.ID] = cloneDelivery(d)
+		claimed = append(claimed, cloneDelivery(d))
+	}
+
+	return claimed, nil
+}
+
+func (s *MemoryWebhookStore) SaveDelivery(_ context.Context, delivery *models.WebhookDelivery) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.deliveries[delivery.ID]; !ok {
+		return fmt.Errorf("delivery %s: %w", delivery.ID, ErrNotFound)
+	}
+
+	delivery.UpdatedAt = time.Now().UTC()
+	s.deliveries[delivery.ID] = cloneDelivery(delivery)
+
+	return nil
+}
+
+func (s *MemoryWebhookStore) ListDeliveries(
+	_ context.Context, webhookID string, limit int,
+) ([]models.WebhookDelivery, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	deliveries := []models.WebhookDelivery{}
+	for id := range s.deliveries {
+		d := s.deliveries[id]
+		if d.WebhookID == webhookID {
+			deliveries = append(deliveries, cloneDelivery(&d))
+		}
+	}
+
+	slices.SortFunc(deliveries, func(a, b models.WebhookDelivery) int {
+		if c := b.CreatedAt.Compare(a.CreatedAt); c != 0 {
+			return c
+		}
+		return cmp.Compare(b.ID, a.ID)
+	})
+	if len(deliveries) > limit {
+		deliveries = deliveries[:limit]
+	}
+
+	return deliveries, nil
+}
+
+func cloneWebhook(webhook *models.Webhook) models.Webhook {
+	clone := *webhook
+	clone.Events = slices.Clone(webhook.Events)
+	return clone
+}
+
+func cloneDelivery(delivery *models.WebhookDelivery) models.WebhookDelivery {
+	clone := *delivery
+	clone.Payload = slices.Clone(delivery.Payload)
+	return clone
+}
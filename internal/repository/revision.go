@@ -0,0 +1,212 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/jonesrussell/gosources/internal/models"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+// recordRevision appends a snapshot of source to its history. Callers must
+// have inserted, updated or deleted the source's row earlier in the same
+// transaction: the row lock taken by that write is held until commit, so
+// writers of one source number their revisions one after another instead of
+// reading the same MAX(revision).
+func recordRevision(ctx context.Context, q queryer, source *models.Source, action models.RevisionAction) error {
+	snapshot, err := json.Marshal(source)
+	if err != nil {
+		return fmt.Errorf("marshal snapshot: %w", err)
+	}
+
+	query := `
+		INSERT INTO source_revisions (source_id, revision, action, actor, snapshot, created_at)
+		SELECT $1, COALESCE(MAX(revision), 0) + 1, $2, $3, $4, $5
+		FROM source_revisions
+		WHERE source_id = $1
+	`
+
+	_, err = q.ExecContext(ctx,
+		query,
+		source.ID,
+		string(action),
+		ActorFromContext(ctx),
+		snapshot,
+		time.Now().UTC(),
+	)
+	if err != nil {
+		return fmt.Errorf("insert revision: %w", err)
+	}
+
+	return nil
+}
+
+// ListRevisions returns a source's history, newest first. It works for
+// deleted sources too.
+func (r *SourceRepository) ListRevisions(
+	ctx context.Context, sourceID string,
+) (_ []models.SourceRevision, err error) {
+	ctx, span := startSpan(ctx, "SourceRepository.ListRevisions", attribute.String("source.id", sourceID))
+	defer span.End()
+	defer func() { recordSpanError(ctx, span, err) }()
+
+	query := `
+		SELECT source_id, revision, action, actor, snapshot, created_at
+		FROM source_revisions
+		WHERE source_id = $1
+		ORDER BY revision DESC
+	`
+
+	rows, err := r.db.QueryContext(ctx, query, sourceID)
+	if err != nil {
+		return nil, fmt.Errorf("query revisions: %w", err)
+	}
+	defer rows.Close()
+
+	var revisions []models.SourceRevision
+	for rows.Next() {
+		revision, scanErr := scanRevision(rows)
+		if scanErr != nil {
+			return nil, scanErr
+		}
+		revisions = append(revisions, *revision)
+	}
+
+	if rowsErr := rows.Err(); rowsErr != nil {
+		return nil, fmt.Errorf("iterate revisions: %w", rowsErr)
+	}
+
+	if len(revisions) == 0 {
+		return nil, fmt.Errorf("source %s: %w", sourceID, ErrNotFound)
+	}
+
+	return revisions, nil
+}
+
+func (r *SourceRepository) GetRevision(
+	ctx context.Context, sourceID string, revision int,
+) (_ *models.SourceRevision, err error) {
+	ctx, span := startSpan(ctx,
+		"SourceRepository.GetRevision",
+		attribute.String("source.id", sourceID),
+		attribute.Int("source.revision", revision),
+	)
+	defer span.End()
+	defer func() { recordSpanError(ctx, span, err) }()
+
+	return getRevision(ctx, r.db, sourceID, revision)
+}
+
+// Restore writes the snapshot from the given revision back to the source,
+// recreating it if it has since been deleted, and records a restore revision.
+func (r *SourceRepository) Restore(
+	ctx context.Context, sourceID string, revision int,
+) (_ *models.Source, err error) {
+	ctx, span := startSpan(ctx,
+		"SourceRepository.Restore",
+		attribute.String("source.id", sourceID),
+		attribute.Int("source.revision", revision),
+	)
+	defer span.End()
+	defer func() { recordSpanError(ctx, span, err) }()
+
+	var restored *models.Source
+
+	err = r.withTx(ctx, func(tx *sql.Tx) error {
+		rev, err := getRevision(ctx, tx, sourceID, revision)
+		if err != nil {
+			return err
+		}
+
+		source := rev.Snapshot
+		source.ID = sourceID
+		source.UpdatedAt = time.Now().UTC()
+		source.Version = 0
+
+		err = updateSource(ctx, tx, &source)
+		if errors.Is(err, ErrNotFound) {
+			// Continue the version sequence so stale ETags from before the
+			// deletion cannot match the recreated source.
+			source.Version = latestVersion(ctx, tx, sourceID) + 1
+			err = insertSource(ctx, tx, &source)
+		}
+		if err != nil {
+			return err
+		}
+
+		restored = &source
+		return recordRevision(ctx, tx, &source, models.RevisionRestore)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return restored, nil
+}
+
+func getRevision(ctx context.Context, q queryer, sourceID string, revision int) (*models.SourceRevision, error) {
+	query := `
+		SELECT source_id, revision, action, actor, snapshot, created_at
+		FROM source_revisions
+		WHERE source_id = $1 AND revision = $2
+	`
+
+	rev, err := scanRevision(q.QueryRowContext(ctx, query, sourceID, revision))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, fmt.Errorf("revision %d of source %s: %w", revision, sourceID, ErrNotFound)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return rev, nil
+}
+
+func scanRevision(row rowScanner) (*models.SourceRevision, error) {
+	var rev models.SourceRevision
+	var action string
+	var snapshot []byte
+
+	err := row.Scan(&rev.SourceID, &rev.Revision, &action, &rev.Actor, &snapshot, &rev.CreatedAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, err
+	}
+	if err != nil {
+		return nil, fmt.Errorf("scan revision: %w", err)
+	}
+
+	rev.Action = models.RevisionAction(action)
+
+	if unmarshalErr := json.Unmarshal(snapshot, &rev.Snapshot); unmarshalErr != nil {
+		return nil, fmt.Errorf("unmarshal snapshot: %w", unmarshalErr)
+	}
+
+	return &rev, nil
+}
+
+// latestVersion returns the highest version recorded in a source's history.
+func latestVersion(ctx context.Context, q queryer, sourceID string) int {
+	query := `
+		SELECT snapshot
+		FROM source_revisions
+		WHERE source_id = $1
+		ORDER BY revision DESC
+		LIMIT 1
+	`
+
+	var snapshot []byte
+	if err := q.QueryRowContext(ctx, query, sourceID).Scan(&snapshot); err != nil {
+		return 0
+	}
+
+	var source models.Source
+	if err := json.Unmarshal(snapshot, &source); err != nil {
+		return 0
+	}
+
+	return source.Version
+}
@@ -11,8 +11,12 @@ import (
 	"github.com/google/uuid"
 	"github.com/jonesrussell/gosources/internal/logger"
 	"github.com/jonesrussell/gosources/internal/models"
+	"go.opentelemetry.io/otel/attribute"
 )
 
+// SourceRepository is the SQL implementation of SourceStore. Its queries stay
+// within the dialect shared by PostgreSQL and SQLite, so it serves both
+// drivers.
 type SourceRepository struct {
 	db     *sql.DB
 	logger logger.Logger
@@ -25,66 +29,278 @@ func NewSourceRepository(db *sql.DB, log logger.Logger) *SourceRepository {
 	}
 }
 
-func (r *SourceRepository) Create(ctx context.Context, source *models.Source) error {
+// sourceColumns lists the columns read by scanSource, in order.
+const sourceColumns = `id, name, url, article_index, page_index, rate_limit, max_depth,
+		       time, schedule, selectors, city_name, group_id, enabled, version, created_at, updated_at`
+
+// queryer is satisfied by both *sql.DB and *sql.Tx.
+type queryer interface {
+	ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error)
+	QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error)
+	QueryRowContext(ctx context.Context, query string, args ...any) *sql.Row
+}
+
+// rowScanner is satisfied by both *sql.Row and *sql.Rows.
+type rowScanner interface {
+	Scan(dest ...any) error
+}
+
+func (r *SourceRepository) Create(ctx context.Context, source *models.Source) (err error) {
+	ctx, span := startSpan(ctx, "SourceRepository.Create", attribute.String("source.name", source.Name))
+	defer span.End()
+	defer func() { recordSpanError(ctx, span, err) }()
+
 	source.ID = uuid.New().String()
-	source.CreatedAt = time.Now()
-	source.UpdatedAt = time.Now()
+	source.Version = 1
+	source.CreatedAt = time.Now().UTC()
+	source.UpdatedAt = source.CreatedAt
 
-	selectorsJSON, err := json.Marshal(source.Selectors)
-	if err != nil {
-		return fmt.Errorf("marshal selectors: %w", err)
+	return r.withTx(ctx, func(tx *sql.Tx) error {
+		if err := insertSource(ctx, tx, source); err != nil {
+			return err
+		}
+		return recordRevision(ctx, tx, source, models.RevisionCreate)
+	})
+}
+
+func (r *SourceRepository) GetByID(ctx context.Context, id string) (_ *models.Source, err error) {
+	ctx, span := startSpan(ctx, "SourceRepository.GetByID", attribute.String("source.id", id))
+	defer span.End()
+	defer func() { recordSpanError(ctx, span, err) }()
+
+	return getSource(ctx, r.db, id)
+}
+
+// List returns the page of sources selected by opts along with the total
+// number of matching rows.
+func (r *SourceRepository) List(ctx context.Context, opts ListOptions) (_ *ListResult, err error) {
+	ctx, span := startSpan(ctx, "SourceRepository.List", attribute.Int("list.limit", opts.Limit))
+	defer span.End()
+	defer func() { recordSpanError(ctx, span, err) }()
+
+	q := filterQuery(opts)
+
+	var total int
+	countQuery := `SELECT COUNT(*) FROM sources` + q.whereSQL()
+	if err := r.db.QueryRowContext(ctx, countQuery, q.args...).Scan(&total); err != nil {
+		return nil, fmt.Errorf("count sources: %w", err)
 	}
 
-	timeJSON, err := json.Marshal(source.Time)
+	column := string(opts.sortField())
+	direction, comparison := "ASC", ">"
+	if opts.Descending {
+		direction, comparison = "DESC", "<"
+	}
+
+	if opts.Cursor != "" {
+		value, id, err := decodeCursor(opts)
+		if err != nil {
+			return nil, err
+		}
+		q.add(fmt.Sprintf("(%s, id) %s (?, ?)", column, comparison), value, id)
+	}
+
+	query := `
+		SELECT ` + sourceColumns + `
+		FROM sources` + q.whereSQL() + fmt.Sprintf(`
+		ORDER BY %s %s, id %s`, column, direction, direction)
+
+	if opts.Limit > 0 {
+		// Fetch one extra row to learn whether another page follows.
+		q.args = append(q.args, opts.Limit+1)
+		query += fmt.Sprintf(" LIMIT $%d", len(q.args))
+	}
+
+	rows, err := r.db.QueryContext(ctx, query, q.args...)
 	if err != nil {
-		return fmt.Errorf("marshal time: %w", err)
+		return nil, fmt.Errorf("query sources: %w", err)
+	}
+	defer rows.Close()
+
+	var sources []models.Source
+	for rows.Next() {
+		source, scanErr := scanSource(rows)
+		if scanErr != nil {
+			return nil, scanErr
+		}
+		sources = append(sources, *source)
+	}
+
+	if rowsErr := rows.Err(); rowsErr != nil {
+		return nil, fmt.Errorf("iterate sources: %w", rowsErr)
+	}
+
+	result := &ListResult{
+		Sources: sources,
+		Total:   total,
+	}
+
+	if opts.Limit > 0 && len(sources) > opts.Limit {
+		result.Sources = sources[:opts.Limit]
+		cursor, cursorErr := encodeCursor(opts, &result.Sources[opts.Limit-1])
+		if cursorErr != nil {
+			return nil, cursorErr
+		}
+		result.NextCursor = cursor
 	}
 
+	return result, nil
+}
+
+func (r *SourceRepository) Update(ctx context.Context, source *models.Source) (err error) {
+	ctx, span := startSpan(ctx, "SourceRepository.Update", attribute.String("source.id", source.ID))
+	defer span.End()
+	defer func() { recordSpanError(ctx, span, err) }()
+
+	source.UpdatedAt = time.Now().UTC()
+
+	return r.withTx(ctx, func(tx *sql.Tx) error {
+		if err := updateSource(ctx, tx, source); err != nil {
+			return err
+		}
+		return recordRevision(ctx, tx, source, models.RevisionUpdate)
+	})
+}
+
+func (r *SourceRepository) Delete(ctx context.Context, id string) (err error) {
+	ctx, span := startSpan(ctx, "SourceRepository.Delete", attribute.String("source.id", id))
+	defer span.End()
+	defer func() { recordSpanError(ctx, span, err) }()
+
+	return r.withTx(ctx, func(tx *sql.Tx) error {
+		// Deleting and reading the row in one statement locks it first, so
+		// the snapshot is what was deleted and a concurrent delete of the
+		// same source finds nothing to record.
+		query := `DELETE FROM sources WHERE id = $1 RETURNING ` + sourceColumns
+		source, err := scanSource(tx.QueryRowContext(ctx, query, id))
+		if errors.Is(err, sql.ErrNoRows) {
+			return fmt.Errorf("source %s: %w", id, ErrNotFound)
+		}
+		if err != nil {
+			return fmt.Errorf("delete source: %w", err)
+		}
+
+		return recordRevision(ctx, tx, source, models.RevisionDelete)
+	})
+}
+
+// GetCities reads each city with its enabled sources in one query, ordered so
+// a city's rows are adjacent.
+func (r *SourceRepository) GetCities(ctx context.Context) (_ []models.CityIndex, err error) {
+	ctx, span := startSpan(ctx, "SourceRepository.GetCities")
+	defer span.End()
+	defer func() { recordSpanError(ctx, span, err) }()
+
 	query := `
-		INSERT INTO sources (
-			id, name, url, article_index, page_index, rate_limit, max_depth,
-			time, selectors, city_name, group_id, enabled, created_at, updated_at
-		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14)
+		SELECT
+			c.id, c.name, c.display_name, c.region, c.timezone, c.group_id, c.default_index,
+			c.created_at, c.updated_at,
+			s.id, s.name, s.article_index, s.group_id
+		FROM cities c
+		JOIN sources s ON s.city_name = c.name
+		WHERE s.enabled = true
+		ORDER BY c.name, s.name, s.id
 	`
 
-	_, err = r.db.ExecContext(ctx,
-		query,
-		source.ID,
-		source.Name,
-		source.URL,
-		source.ArticleIndex,
-		source.PageIndex,
-		source.RateLimit,
-		source.MaxDepth,
-		timeJSON,
-		selectorsJSON,
-		source.CityName,
-		source.GroupID,
-		source.Enabled,
-		source.CreatedAt,
-		source.UpdatedAt,
-	)
+	rows, err := r.db.QueryContext(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("query cities: %w", err)
+	}
+	defer rows.Close()
 
+	var cities []models.CityIndex
+	var city *models.City
+	var sources []models.Source
+	for rows.Next() {
+		var current models.City
+		var region, timezone, cityGroupID, defaultIndex, sourceGroupID sql.NullString
+		var source models.Source
+
+		scanErr := rows.Scan(
+			&current.ID, &current.Name, &current.DisplayName, &region, &timezone, &cityGroupID, &defaultIndex,
+			&current.CreatedAt, &current.UpdatedAt,
+			&source.ID, &source.Name, &source.ArticleIndex, &sourceGroupID,
+		)
+		if scanErr != nil {
+			return nil, fmt.Errorf("scan city: %w", scanErr)
+		}
+		current.Region = region.String
+		current.Timezone = timezone.String
+		current.GroupID = cityGroupID.String
+		current.DefaultIndex = defaultIndex.String
+		if sourceGroupID.Valid {
+			source.GroupID = &sourceGroupID.String
+		}
+
+		if city == nil || city.ID != current.ID {
+			if city != nil {
+				cities = append(cities, models.NewCityIndex(city, sources))
+			}
+			city, sources = &current, nil
+		}
+		sources = append(sources, source)
+	}
+
+	if rowsErr := rows.Err(); rowsErr != nil {
+		return nil, fmt.Errorf("iterate cities: %w", rowsErr)
+	}
+	if city != nil {
+		cities = append(cities, models.NewCityIndex(city, sources))
+	}
+
+	return cities, nil
+}
+
+// withTx runs fn in a transaction, committing if it returns nil.
+func (r *SourceRepository) withTx(ctx context.Context, fn func(tx *sql.Tx) error) error {
+	return withTx(ctx, r.db, fn)
+}
+
+// withTx runs fn in a transaction that is committed only if fn succeeds.
+func withTx(ctx context.Context, db *sql.DB, fn func(tx *sql.Tx) error) error {
+	tx, err := db.BeginTx(ctx, nil)
 	if err != nil {
-		return fmt.Errorf("insert source: %w", err)
+		return fmt.Errorf("begin transaction: %w", err)
+	}
+	defer func() {
+		_ = tx.Rollback()
+	}()
+
+	if fnErr := fn(tx); fnErr != nil {
+		return fnErr
+	}
+
+	if commitErr := tx.Commit(); commitErr != nil {
+		return fmt.Errorf("commit transaction: %w", classifyError(commitErr, nil))
 	}
 
 	return nil
 }
 
-func (r *SourceRepository) GetByID(ctx context.Context, id string) (*models.Source, error) {
-	var source models.Source
-	var selectorsJSON, timeJSON []byte
-	var cityName, groupID sql.NullString
-
+func getSource(ctx context.Context, q queryer, id string) (*models.Source, error) {
 	query := `
-		SELECT id, name, url, article_index, page_index, rate_limit, max_depth,
-		       time, selectors, city_name, group_id, enabled, created_at, updated_at
+		SELECT ` + sourceColumns + `
 		FROM sources
 		WHERE id = $1
 	`
 
-	err := r.db.QueryRowContext(ctx, query, id).Scan(
+	source, err := scanSource(q.QueryRowContext(ctx, query, id))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, fmt.Errorf("source %s: %w", id, ErrNotFound)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return source, nil
+}
+
+func scanSource(row rowScanner) (*models.Source, error) {
+	var source models.Source
+	var selectorsJSON, timeJSON, scheduleJSON []byte
+	var cityName, groupID sql.NullString
+
+	err := row.Scan(
 		&source.ID,
 		&source.Name,
 		&source.URL,
@@ -93,27 +309,36 @@ func (r *SourceRepository) GetByID(ctx context.Context, id string) (*models.Sour
 		&source.RateLimit,
 		&source.MaxDepth,
 		&timeJSON,
+		&scheduleJSON,
 		&selectorsJSON,
 		&cityName,
 		&groupID,
 		&source.Enabled,
+		&source.Version,
 		&source.CreatedAt,
 		&source.UpdatedAt,
 	)
-
-	if err == sql.ErrNoRows {
-		return nil, fmt.Errorf("source not found: %w", err)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, err
 	}
 	if err != nil {
-		return nil, fmt.Errorf("query source: %w", err)
+		return nil, fmt.Errorf("scan source: %w", err)
 	}
 
 	if unmarshalErr := json.Unmarshal(selectorsJSON, &source.Selectors); unmarshalErr != nil {
 		return nil, fmt.Errorf("unmarshal selectors: %w", unmarshalErr)
 	}
 
-	if unmarshalErr := json.Unmarshal(timeJSON, &source.Time); unmarshalErr != nil {
-		return nil, fmt.Errorf("unmarshal time: %w", unmarshalErr)
+	if len(timeJSON) > 0 {
+		if unmarshalErr := json.Unmarshal(timeJSON, &source.Time); unmarshalErr != nil {
+			return nil, fmt.Errorf("unmarshal time: %w", unmarshalErr)
+		}
+	}
+
+	if len(scheduleJSON) > 0 {
+		if unmarshalErr := json.Unmarshal(scheduleJSON, &source.Schedule); unmarshalErr != nil {
+			return nil, fmt.Errorf("unmarshal schedule: %w", unmarshalErr)
+		}
 	}
 
 	if cityName.Valid {
@@ -126,74 +351,76 @@ func (r *SourceRepository) GetByID(ctx context.Context, id string) (*models.Sour
 	return &source, nil
 }
 
-func (r *SourceRepository) List(ctx context.Context) ([]models.Source, error) {
-	query := `
-		SELECT id, name, url, article_index, page_index, rate_limit, max_depth,
-		       time, selectors, city_name, group_id, enabled, created_at, updated_at
-		FROM sources
-		ORDER BY name
-	`
+// insertSource writes a new row using the ID and timestamps already set on
+// source.
+func insertSource(ctx context.Context, q queryer, source *models.Source) error {
+	selectorsJSON, err := json.Marshal(source.Selectors)
+	if err != nil {
+		return fmt.Errorf("marshal selectors: %w", err)
+	}
 
-	rows, err := r.db.QueryContext(ctx, query)
+	timeJSON, err := json.Marshal(source.Time)
 	if err != nil {
-		return nil, fmt.Errorf("query sources: %w", err)
+		return fmt.Errorf("marshal time: %w", err)
 	}
-	defer rows.Close()
 
-	var sources []models.Source
-	for rows.Next() {
-		var source models.Source
-		var selectorsJSON, timeJSON []byte
-		var cityName, groupID sql.NullString
+	scheduleJSON, err := marshalSchedule(source.Schedule)
+	if err != nil {
+		return err
+	}
 
-		scanErr := rows.Scan(
-			&source.ID,
-			&source.Name,
-			&source.URL,
-			&source.ArticleIndex,
-			&source.PageIndex,
-			&source.RateLimit,
-			&source.MaxDepth,
-			&timeJSON,
-			&selectorsJSON,
-			&cityName,
-			&groupID,
-			&source.Enabled,
-			&source.CreatedAt,
-			&source.UpdatedAt,
-		)
-		if scanErr != nil {
-			return nil, fmt.Errorf("scan source: %w", scanErr)
-		}
+	query := `
+		INSERT INTO sources (
+			id, name, url, article_index, page_index, rate_limit, max_depth,
+			time, selectors, city_name, group_id, enabled, version, created_at, updated_at,
+			schedule
+		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16)
+	`
 
-		if unmarshalErr := json.Unmarshal(selectorsJSON, &source.Selectors); unmarshalErr != nil {
-			return nil, fmt.Errorf("unmarshal selectors: %w", unmarshalErr)
-		}
+	_, err = q.ExecContext(ctx,
+		query,
+		source.ID,
+		source.Name,
+		source.URL,
+		source.ArticleIndex,
+		source.PageIndex,
+		source.RateLimit,
+		source.MaxDepth,
+		timeJSON,
+		selectorsJSON,
+		source.CityName,
+		source.GroupID,
+		source.Enabled,
+		source.Version,
+		source.CreatedAt,
+		source.UpdatedAt,
+		scheduleJSON,
+	)
 
-		if unmarshalErr := json.Unmarshal(timeJSON, &source.Time); unmarshalErr != nil {
-			return nil, fmt.Errorf("unmarshal time: %w", unmarshalErr)
-		}
+	if err != nil {
+		return fmt.Errorf("insert source: %w", classifySourceError(err, source))
+	}
 
-		if cityName.Valid {
-			source.CityName = &cityName.String
-		}
-		if groupID.Valid {
-			source.GroupID = &groupID.String
-		}
+	return nil
+}
 
-		sources = append(sources, source)
+// marshalSchedule encodes a schedule for the schedule column, which is NULL
+// for sources without one.
+func marshalSchedule(schedule *models.Schedule) (sql.Null[[]byte], error) {
+	if schedule == nil {
+		return sql.Null[[]byte]{}, nil
 	}
-
-	if rowsErr := rows.Err(); rowsErr != nil {
-		return nil, fmt.Errorf("iterate sources: %w", rowsErr)
+	data, err := json.Marshal(schedule)
+	if err != nil {
+		return sql.Null[[]byte]{}, fmt.Errorf("marshal schedule: %w", err)
 	}
-
-	return sources, nil
+	return sql.Null[[]byte]{V: data, Valid: true}, nil
 }
 
-func (r *SourceRepository) Update(ctx context.Context, source *models.Source) error {
-	source.UpdatedAt = time.Now()
-
+// updateSource overwrites every column of an existing row, increments its
+// version and fills in source.CreatedAt and source.Version from the stored
+// values. A non-zero source.Version must match the stored version.
+func updateSource(ctx context.Context, q queryer, source *models.Source) error {
 	selectorsJSON, err := json.Marshal(source.Selectors)
 	if err != nil {
 		return fmt.Errorf("marshal selectors: %w", err)
@@ -204,15 +431,22 @@ func (r *SourceRepository) Update(ctx context.Context, source *models.Source) er
 		return fmt.Errorf("marshal time: %w", err)
 	}
 
+	scheduleJSON, err := marshalSchedule(source.Schedule)
+	if err != nil {
+		return err
+	}
+
 	query := `
 		UPDATE sources
 		SET name = $2, url = $3, article_index = $4, page_index = $5,
 		    rate_limit = $6, max_depth = $7, time = $8, selectors = $9,
-		    city_name = $10, group_id = $11, enabled = $12, updated_at = $13
-		WHERE id = $1
+		    city_name = $10, group_id = $11, enabled = $12, updated_at = $13,
+		    schedule = $15, version = version + 1
+		WHERE id = $1 AND ($14 = 0 OR version = $14)
+		RETURNING created_at, version
 	`
 
-	result, err := r.db.ExecContext(ctx,
+	err = q.QueryRowContext(ctx,
 		query,
 		source.ID,
 		source.Name,
@@ -227,81 +461,30 @@ func (r *SourceRepository) Update(ctx context.Context, source *models.Source) er
 		source.GroupID,
 		source.Enabled,
 		source.UpdatedAt,
-	)
+		source.Version,
+		scheduleJSON,
+	).Scan(&source.CreatedAt, &source.Version)
 
-	if err != nil {
-		return fmt.Errorf("update source: %w", err)
+	if errors.Is(err, sql.ErrNoRows) {
+		return versionError(ctx, q, source.ID, source.Version)
 	}
-
-	rowsAffected, err := result.RowsAffected()
 	if err != nil {
-		return fmt.Errorf("get rows affected: %w", err)
-	}
-
-	if rowsAffected == 0 {
-		return errors.New("source not found")
+		return fmt.Errorf("update source: %w", classifySourceError(err, source))
 	}
 
 	return nil
 }
 
-func (r *SourceRepository) Delete(ctx context.Context, id string) error {
-	query := `DELETE FROM sources WHERE id = $1`
-
-	result, err := r.db.ExecContext(ctx, query, id)
-	if err != nil {
-		return fmt.Errorf("delete source: %w", err)
+// versionError explains why a conditional update matched no row: either the
+// source does not exist or its version moved on.
+func versionError(ctx context.Context, q queryer, id string, expected int) error {
+	var current int
+	err := q.QueryRowContext(ctx, `SELECT version FROM sources WHERE id = $1`, id).Scan(&current)
+	if errors.Is(err, sql.ErrNoRows) {
+		return fmt.Errorf("source %s: %w", id, ErrNotFound)
 	}
-
-	rowsAffected, err := result.RowsAffected()
 	if err != nil {
-		return fmt.Errorf("get rows affected: %w", err)
+		return fmt.Errorf("query source version: %w", err)
 	}
-
-	if rowsAffected == 0 {
-		return errors.New("source not found")
-	}
-
-	return nil
-}
-
-func (r *SourceRepository) GetCities(ctx context.Context) ([]models.City, error) {
-	query := `
-		SELECT 
-			COALESCE(city_name, name) as city_name,
-			article_index,
-			COALESCE(group_id, '') as group_id
-		FROM sources
-		WHERE enabled = true AND city_name IS NOT NULL
-		ORDER BY city_name
-	`
-
-	rows, err := r.db.QueryContext(ctx, query)
-	if err != nil {
-		return nil, fmt.Errorf("query cities: %w", err)
-	}
-	defer rows.Close()
-
-	var cities []models.City
-	for rows.Next() {
-		var city models.City
-		var groupID sql.NullString
-
-		scanErr := rows.Scan(&city.Name, &city.Index, &groupID)
-		if scanErr != nil {
-			return nil, fmt.Errorf("scan city: %w", scanErr)
-		}
-
-		if groupID.Valid && groupID.String != "" {
-			city.GroupID = groupID.String
-		}
-
-		cities = append(cities, city)
-	}
-
-	if rowsErr := rows.Err(); rowsErr != nil {
-		return nil, fmt.Errorf("iterate cities: %w", rowsErr)
-	}
-
-	return cities, nil
+	return &VersionMismatchError{Expected: expected, Current: current}
 }
@@ -0,0 +1,61 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/jonesrussell/gosources/internal/models"
+)
+
+func TestWriteUnknownCity(t *testing.T) {
+	for name, store := range listStores(t) {
+		t.Run(name, func(t *testing.T) {
+			ctx := context.Background()
+			unknown := "timmins"
+
+			tests := []struct {
+				name  string
+				write func(source *models.Source) error
+			}{
+				{
+					name: "create",
+					write: func(source *models.Source) error {
+						source.CityName = &unknown
+						return store.Create(ctx, source)
+					},
+				},
+				{
+					name: "update",
+					write: func(source *models.Source) error {
+						if err := store.Create(ctx, source); err != nil {
+							return err
+						}
+						source.CityName = &unknown
+						return store.Update(ctx, source)
+					},
+				},
+			}
+
+			for _, tt := range tests {
+				t.Run(tt.name, func(t *testing.T) {
+					source := &models.Source{
+						Name:         "source-" + tt.name,
+						URL:          "https://example.com/" + tt.name,
+						ArticleIndex: "articles",
+						PageIndex:    "pages",
+					}
+					err := tt.write(source)
+
+					var invalid models.ValidationErrors
+					if !errors.As(err, &invalid) {
+						t.Fatalf("error = %v, want ValidationErrors", err)
+					}
+					if len(invalid) != 1 || invalid[0].Field != "city_name" {
+						t.Errorf("errors = %+v, want one for city_name", invalid)
+					}
+				})
+			}
+		})
+	}
+}
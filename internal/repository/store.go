@@ -0,0 +1,124 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/jonesrussell/gosources/internal/models"
+)
+
+// SourceStore is the persistence contract for sources. Handlers depend on this
+// interface so the backing storage can be chosen in configuration.
+type SourceStore interface {
+	Create(ctx context.Context, source *models.Source) error
+	GetByID(ctx context.Context, id string) (*models.Source, error)
+	List(ctx context.Context, opts ListOptions) (*ListResult, error)
+	// Update overwrites the source. A non-zero source.Version is treated as a
+	// precondition and a stale value fails with *VersionMismatchError. On
+	// success source.Version holds the new version.
+	Update(ctx context.Context, source *models.Source) error
+	Delete(ctx context.Context, id string) error
+	// GetCities lists the cities with an enabled source, as gopost reads
+	// them.
+	GetCities(ctx context.Context) ([]models.CityIndex, error)
+
+	// Every Create, Update, Delete and Restore records a revision attributed
+	// to ActorFromContext(ctx).
+	ListRevisions(ctx context.Context, sourceID string) ([]models.SourceRevision, error)
+	GetRevision(ctx context.Context, sourceID string, revision int) (*models.SourceRevision, error)
+	Restore(ctx context.Context, sourceID string, revision int) (*models.Source, error)
+}
+
+// WebhookStore persists webhook subscriptions and their delivery queue.
+type WebhookStore interface {
+	Create(ctx context.Context, webhook *models.Webhook) error
+	GetByID(ctx context.Context, id string) (*models.Webhook, error)
+	List(ctx context.Context) ([]models.Webhook, error)
+	Update(ctx context.Context, webhook *models.Webhook) error
+	// Delete removes the webhook together with its deliveries.
+	Delete(ctx context.Context, id string) error
+
+	Enqueue(ctx context.Context, deliveries []models.WebhookDelivery) error
+	// EventCursor returns the ID of the last logged source event that
+	// deliveries have been queued for.
+	EventCursor(ctx context.Context) (int64, error)
+	// QueueEvents enqueues the deliveries for the logged events after cursor
+	// up to last and moves the event cursor to last, all or nothing. It fails
+	// with ErrConflict when the cursor is no longer at cursor because another
+	// dispatcher queued those events first.
+	QueueEvents(ctx context.Context, cursor, last int64, deliveries []models.WebhookDelivery) error
+	// Claim returns up to limit pending deliveries due at now and moves their
+	// next attempt to leaseUntil, so other workers skip them while they are
+	// being sent.
+	Claim(ctx context.Context, now, leaseUntil time.Time, limit int) ([]models.WebhookDelivery, error)
+	// SaveDelivery records the outcome of an attempt.
+	SaveDelivery(ctx context.Context, delivery *models.WebhookDelivery) error
+	// ListDeliveries returns a webhook's most recent deliveries, newest first.
+	ListDeliveries(ctx context.Context, webhookID string, limit int) ([]models.WebhookDelivery, error)
+}
+
+// EventLog is the ordered log of source changes behind the event stream. IDs
+// increase in commit order, so a reader can resume after the last ID it saw.
+type EventLog interface {
+	// Since returns up to limit events with an ID above after, oldest first.
+	Since(ctx context.Context, after int64, limit int) ([]models.SourceEvent, error)
+	// LastID returns the ID of the newest event, or 0 when there is none.
+	LastID(ctx context.Context) (int64, error)
+	// Prune deletes events that occurred before cutoff and returns how many
+	// were removed.
+	Prune(ctx context.Context, cutoff time.Time) (int64, error)
+}
+
+// APIKeyStore persists API keys by the hash of the key.
+type APIKeyStore interface {
+	Create(ctx context.Context, key *models.APIKey) error
+	// GetByHash returns the key with the given hash, including revoked keys.
+	GetByHash(ctx context.Context, hash string) (*models.APIKey, error)
+	List(ctx context.Context) ([]models.APIKey, error)
+	// Revoke marks the key revoked. Revoking it again keeps the original
+	// time.
+	Revoke(ctx context.Context, id string) (*models.APIKey, error)
+	// Touch records when the key was last used.
+	Touch(ctx context.Context, id string, usedAt time.Time) error
+}
+
+// UserStore persists users. Deleting a user also deletes their API keys.
+type UserStore interface {
+	Create(ctx context.Context, user *models.User) error
+	GetByID(ctx context.Context, id string) (*models.User, error)
+	List(ctx context.Context) ([]models.User, error)
+	Update(ctx context.Context, user *models.User) error
+	Delete(ctx context.Context, id string) error
+}
+
+// CityStore persists cities. A city's name cannot change, and a city cannot
+// be deleted while sources belong to it.
+type CityStore interface {
+	Create(ctx context.Context, city *models.City) error
+	GetByID(ctx context.Context, id string) (*models.City, error)
+	List(ctx context.Context) ([]models.City, error)
+	// Update keeps the stored name and sets city.Name to it.
+	Update(ctx context.Context, city *models.City) error
+	// Delete fails with ErrConflict while sources belong to the city.
+	Delete(ctx context.Context, id string) error
+}
+
+var (
+	_ SourceStore = (*SourceRepository)(nil)
+	_ SourceStore = (*MemorySourceStore)(nil)
+
+	_ WebhookStore = (*WebhookRepository)(nil)
+	_ WebhookStore = (*MemoryWebhookStore)(nil)
+
+	_ EventLog = (*EventLogRepository)(nil)
+	_ EventLog = (*MemoryEventLog)(nil)
+
+	_ APIKeyStore = (*APIKeyRepository)(nil)
+	_ APIKeyStore = (*MemoryAPIKeyStore)(nil)
+
+	_ UserStore = (*UserRepository)(nil)
+	_ UserStore = (*MemoryUserStore)(nil)
+
+	_ CityStore = (*CityRepository)(nil)
+	_ CityStore = (*MemoryCityStore)(nil)
+)
@@ -0,0 +1,44 @@
+package repository
+
+import (
+	"context"
+	"errors"
+
+	"github.com/jonesrussell/gosources/internal/logger"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+var tracer = otel.Tracer("github.com/jonesrussell/gosources/internal/repository")
+
+// startSpan starts a client span for one repository operation, named after
+// the type and method, e.g. "SourceRepository.List".
+func startSpan(ctx context.Context, name string, attrs ...attribute.KeyValue) (context.Context, trace.Span) {
+	return tracer.Start(ctx, name,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(attrs...),
+	)
+}
+
+// recordSpanError marks the span failed and logs the error with the logger in
+// ctx, which ties it to the request's request_id. Not found, conflicts and
+// version mismatches are answers rather than failures, so they are recorded as
+// events only and not logged.
+func recordSpanError(ctx context.Context, span trace.Span, err error) {
+	if err == nil {
+		return
+	}
+
+	var conflict *ConflictError
+	var mismatch *VersionMismatchError
+	span.RecordError(err)
+	if errors.Is(err, ErrNotFound) || errors.Is(err, ErrConflict) ||
+		errors.As(err, &conflict) || errors.As(err, &mismatch) {
+		return
+	}
+	span.SetStatus(codes.Error, err.Error())
+
+	logger.FromContext(ctx).Warn("Repository operation failed", logger.Error(err))
+}
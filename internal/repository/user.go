@@ -0,0 +1,199 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jonesrussell/gosources/internal/logger"
+	"github.com/jonesrussell/gosources/internal/models"
+)
+
+// UserRepository is the SQL implementation of UserStore, shared by PostgreSQL
+// and SQLite like SourceRepository.
+type UserRepository struct {
+	db     *sql.DB
+	logger logger.Logger
+}
+
+func NewUserRepository(db *sql.DB, log logger.Logger) *UserRepository {
+	return &UserRepository{
+		db:     db,
+		logger: log,
+	}
+}
+
+const userColumns = `id, name, role, cities, created_at, updated_at`
+
+func (r *UserRepository) Create(ctx context.Context, user *models.User) error {
+	user.ID = uuid.New().String()
+	user.CreatedAt = time.Now().UTC()
+	user.UpdatedAt = user.CreatedAt
+
+	cities, err := marshalCities(user.Cities)
+	if err != nil {
+		return err
+	}
+
+	query := `
+		INSERT INTO users (` + userColumns + `)
+		VALUES ($1, $2, $3, $4, $5, $6)
+	`
+
+	_, err = r.db.ExecContext(ctx,
+		query,
+		user.ID,
+		user.Name,
+		string(user.Role),
+		cities,
+		user.CreatedAt,
+		user.UpdatedAt,
+	)
+	if err != nil {
+		return fmt.Errorf("insert user: %w", classifyError(err, map[string]string{"name": user.Name}))
+	}
+
+	return nil
+}
+
+func (r *UserRepository) GetByID(ctx context.Context, id string) (*models.User, error) {
+	query := `
+		SELECT ` + userColumns + `
+		FROM users
+		WHERE id = $1
+	`
+
+	user, err := scanUser(r.db.QueryRowContext(ctx, query, id))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, fmt.Errorf("user %s: %w", id, ErrNotFound)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return user, nil
+}
+
+func (r *UserRepository) List(ctx context.Context) ([]models.User, error) {
+	query := `
+		SELECT ` + userColumns + `
+		FROM users
+		ORDER BY name, id
+	`
+
+	rows, err := r.db.QueryContext(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("query users: %w", err)
+	}
+	defer rows.Close()
+
+	users := []models.User{}
+	for rows.Next() {
+		user, scanErr := scanUser(rows)
+		if scanErr != nil {
+			return nil, scanErr
+		}
+		users = append(users, *user)
+	}
+
+	if rowsErr := rows.Err(); rowsErr != nil {
+		return nil, fmt.Errorf("iterate users: %w", rowsErr)
+	}
+
+	return users, nil
+}
+
+func (r *UserRepository) Update(ctx context.Context, user *models.User) error {
+	user.UpdatedAt = time.Now().UTC()
+
+	cities, err := marshalCities(user.Cities)
+	if err != nil {
+		return err
+	}
+
+	query := `
+		UPDATE users
+		SET name = $2, role = $3, cities = $4, updated_at = $5
+		WHERE id = $1
+		RETURNING created_at
+	`
+
+	err = r.db.QueryRowContext(ctx,
+		query,
+		user.ID,
+		user.Name,
+		string(user.Role),
+		cities,
+		user.UpdatedAt,
+	).Scan(&user.CreatedAt)
+
+	if errors.Is(err, sql.ErrNoRows) {
+		return fmt.Errorf("user %s: %w", user.ID, ErrNotFound)
+	}
+	if err != nil {
+		return fmt.Errorf("update user: %w", classifyError(err, map[string]string{"name": user.Name}))
+	}
+
+	return nil
+}
+
+func (r *UserRepository) Delete(ctx context.Context, id string) error {
+	result, err := r.db.ExecContext(ctx, `DELETE FROM users WHERE id = $1`, id)
+	if err != nil {
+		return fmt.Errorf("delete user: %w", err)
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("get rows affected: %w", err)
+	}
+	if rows == 0 {
+		return fmt.Errorf("user %s: %w", id, ErrNotFound)
+	}
+
+	return nil
+}
+
+// marshalCities stores a nil list as an empty JSON array.
+func marshalCities(cities []string) ([]byte, error) {
+	if cities == nil {
+		cities = []string{}
+	}
+	data, err := json.Marshal(cities)
+	if err != nil {
+		return nil, fmt.Errorf("marshal cities: %w", err)
+	}
+	return data, nil
+}
+
+func scanUser(row rowScanner) (*models.User, error) {
+	var user models.User
+	var role string
+	var cities []byte
+
+	err := row.Scan(
+		&user.ID,
+		&user.Name,
+		&role,
+		&cities,
+		&user.CreatedAt,
+		&user.UpdatedAt,
+	)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, err
+	}
+	if err != nil {
+		return nil, fmt.Errorf("scan user: %w", err)
+	}
+
+	user.Role = models.Role(role)
+	if unmarshalErr := json.Unmarshal(cities, &user.Cities); unmarshalErr != nil {
+		return nil, fmt.Errorf("unmarshal cities: %w", unmarshalErr)
+	}
+
+	return &user, nil
+}
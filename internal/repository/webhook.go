@@ -0,0 +1,393 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jonesrussell/gosources/internal/logger"
+	"github.com/jonesrussell/gosources/internal/models"
+)
+
+// WebhookRepository is the SQL implementation of WebhookStore, shared by
+// PostgreSQL and SQLite like SourceRepository.
+type WebhookRepository struct {
+	db     *sql.DB
+	logger logger.Logger
+}
+
+func NewWebhookRepository(db *sql.DB, log logger.Logger) *WebhookRepository {
+	return &WebhookRepository{
+		db:     db,
+		logger: log,
+	}
+}
+
+const webhookColumns = `id, url, secret, events, description, enabled, created_at, updated_at`
+
+const deliveryColumns = `id, webhook_id, event, payload, status, attempts, next_attempt_at,
+		       response_status, last_error, created_at, updated_at`
+
+func (r *WebhookRepository) Create(ctx context.Context, webhook *models.Webhook) error {
+	webhook.ID = uuid.New().String()
+	webhook.CreatedAt = time.Now().UTC()
+	webhook.UpdatedAt = webhook.CreatedAt
+
+	events, err := json.Marshal(webhook.Events)
+	if err != nil {
+		return fmt.Errorf("marshal events: %w", err)
+	}
+
+	query := `
+		INSERT INTO webhooks (` + webhookColumns + `)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+	`
+
+	_, err = r.db.ExecContext(ctx,
+		query,
+		webhook.ID,
+		webhook.URL,
+		webhook.Secret,
+		events,
+		webhook.Description,
+		webhook.Enabled,
+		webhook.CreatedAt,
+		webhook.UpdatedAt,
+	)
+	if err != nil {
+		return fmt.Errorf("insert webhook: %w", classifyError(err, nil))
+	}
+
+	return nil
+}
+
+func (r *WebhookRepository) GetByID(ctx context.Context, id string) (*models.Webhook, error) {
+	query := `
+		SELECT ` + webhookColumns + `
+		FROM webhooks
+		WHERE id = $1
+	`
+
+	webhook, err := scanWebhook(r.db.QueryRowContext(ctx, query, id))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, fmt.Errorf("webhook %s: %w", id, ErrNotFound)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return webhook, nil
+}
+
+func (r *WebhookRepository) List(ctx context.Context) ([]models.Webhook, error) {
+	query := `
+		SELECT ` + webhookColumns + `
+		FROM webhooks
+		ORDER BY created_at, id
+	`
+
+	rows, err := r.db.QueryContext(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("query webhooks: %w", err)
+	}
+	defer rows.Close()
+
+	webhooks := []models.Webhook{}
+	for rows.Next() {
+		webhook, scanErr := scanWebhook(rows)
+		if scanErr != nil {
+			return nil, scanErr
+		}
+		webhooks = append(webhooks, *webhook)
+	}
+
+	if rowsErr := rows.Err(); rowsErr != nil {
+		return nil, fmt.Errorf("iterate webhooks: %w", rowsErr)
+	}
+
+	return webhooks, nil
+}
+
+func (r *WebhookRepository) Update(ctx context.Context, webhook *models.Webhook) error {
+	webhook.UpdatedAt = time.Now().UTC()
+
+	events, err := json.Marshal(webhook.Events)
+	if err != nil {
+		return fmt.Errorf("marshal events: %w", err)
+	}
+
+	query := `
+		UPDATE webhooks
+		SET url = $2, secret = $3, events = $4, description = $5, enabled = $6, updated_at = $7
+		WHERE id = $1
+		RETURNING created_at
+	`
+
+	err = r.db.QueryRowContext(ctx,
+		query,
+		webhook.ID,
+		webhook.URL,
+		webhook.Secret,
+		events,
+		webhook.Description,
+		webhook.Enabled,
+		webhook.UpdatedAt,
+	).Scan(&webhook.CreatedAt)
+
+	if errors.Is(err, sql.ErrNoRows) {
+		return fmt.Errorf("webhook %s: %w", webhook.ID, ErrNotFound)
+	}
+	if err != nil {
+		return fmt.Errorf("update webhook: %w", classifyError(err, nil))
+	}
+
+	return nil
+}
+
+func (r *WebhookRepository) Delete(ctx context.Context, id string) error {
+	result, err := r.db.ExecContext(ctx, `DELETE FROM webhooks WHERE id = $1`, id)
+	if err != nil {
+		return fmt.Errorf("delete webhook: %w", err)
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("get rows affected: %w", err)
+	}
+	if rows == 0 {
+		return fmt.Errorf("webhook %s: %w", id, ErrNotFound)
+	}
+
+	return nil
+}
+
+func (r *WebhookRepository) Enqueue(ctx context.Context, deliveries []models.WebhookDelivery) error {
+	if len(deliveries) == 0 {
+		return nil
+	}
+
+	return withTx(ctx, r.db, func(tx *sql.Tx) error {
+		return insertDeliveries(ctx, tx, deliveries)
+	})
+}
+
+func (r *WebhookRepository) EventCursor(ctx context.Context) (int64, error) {
+	var cursor int64
+	err := r.db.QueryRowContext(ctx, `SELECT last_event_id FROM webhook_event_cursor WHERE id = 1`).Scan(&cursor)
+	if err != nil {
+		return 0, fmt.Errorf("query event cursor: %w", err)
+	}
+	return cursor, nil
+}
+
+func (r *WebhookRepository) QueueEvents(
+	ctx context.Context, cursor, last int64, deliveries []models.WebhookDelivery,
+) error {
+	return withTx(ctx, r.db, func(tx *sql.Tx) error {
+		// Moving the cursor first locks its row, so a second dispatcher that
+		// read the same cursor waits here and then finds it moved.
+		result, err := tx.ExecContext(ctx,
+			`UPDATE webhook_event_cursor SET last_event_id = $1 WHERE id = 1 AND last_event_id = $2`,
+			last, cursor,
+		)
+		if err != nil {
+			return fmt.Errorf("move event cursor: %w", err)
+		}
+		rows, err := result.RowsAffected()
+		if err != nil {
+			return fmt.Errorf("get rows affected: %w", err)
+		}
+		if rows == 0 {
+			return fmt.Errorf("event cursor moved past %d: %w", cursor, ErrConflict)
+		}
+
+		return insertDeliveries(ctx, tx, deliveries)
+	})
+}
+
+func insertDeliveries(ctx context.Context, tx *sql.Tx, deliveries []models.WebhookDelivery) error {
+	query := `
+		INSERT INTO webhook_deliveries (` + deliveryColumns + `)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)
+	`
+
+	now := time.Now().UTC()
+	for i := range deliveries {
+		d := &deliveries[i]
+		d.ID = uuid.New().String()
+		d.Status = models.DeliveryPending
+		d.CreatedAt = now
+		d.UpdatedAt = now
+		if d.NextAttemptAt.IsZero() {
+			d.NextAttemptAt = now
+		}
+
+		_, err := tx.ExecContext(ctx,
+			query,
+			d.ID,
+			d.WebhookID,
+			string(d.Event),
+			[]byte(d.Payload),
+			string(d.Status),
+			d.Attempts,
+			d.NextAttemptAt,
+			d.ResponseStatus,
+			d.LastError,
+			d.CreatedAt,
+			d.UpdatedAt,
+		)
+		if err != nil {
+			return fmt.Errorf("insert delivery: %w", classifyError(err, nil))
+		}
+	}
+	return nil
+}
+
+func (r *WebhookRepository) Claim(
+	ctx context.Context, now, leaseUntil time.Time, limit int,
+) ([]models.WebhookDelivery, error) {
+	// The outer conditions repeat the inner ones so a row claimed by a
+	// concurrent worker between the subquery and the update is skipped.
+	query := `
+		UPDATE webhook_deliveries
+		SET next_attempt_at = $2, updated_at = $1
+		WHERE id IN (
+			SELECT id
+			FROM webhook_deliveries
+			WHERE status = 'pending' AND next_attempt_at <= $1
+			ORDER BY next_attempt_at
+			LIMIT $3
+		)
+		AND status = 'pending' AND next_attempt_at <= $1
+		RETURNING ` + deliveryColumns
+
+	rows, err := r.db.QueryContext(ctx, query, now.UTC(), leaseUntil.UTC(), limit)
+	if err != nil {
+		return nil, fmt.Errorf("claim deliveries: %w", err)
+	}
+
+	return collectDeliveries(rows)
+}
+
+func (r *WebhookRepository) SaveDelivery(ctx context.Context, delivery *models.WebhookDelivery) error {
+	delivery.UpdatedAt = time.Now().UTC()
+
+	query := `
+		UPDATE webhook_deliveries
+		SET status = $2, attempts = $3, next_attempt_at = $4, response_status = $5,
+		    last_error = $6, updated_at = $7
+		WHERE id = $1
+	`
+
+	result, err := r.db.ExecContext(ctx,
+		query,
+		delivery.ID,
+		string(delivery.Status),
+		delivery.Attempts,
+		delivery.NextAttemptAt.UTC(),
+		delivery.ResponseStatus,
+		delivery.LastError,
+		delivery.UpdatedAt,
+	)
+	if err != nil {
+		return fmt.Errorf("update delivery: %w", err)
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("get rows affected: %w", err)
+	}
+	if rows == 0 {
+		return fmt.Errorf("delivery %s: %w", delivery.ID, ErrNotFound)
+	}
+
+	return nil
+}
+
+func (r *WebhookRepository) ListDeliveries(
+	ctx context.Context, webhookID string, limit int,
+) ([]models.WebhookDelivery, error) {
+	query := `
+		SELECT ` + deliveryColumns + `
+		FROM webhook_deliveries
+		WHERE webhook_id = $1
+		ORDER BY created_at DESC, id DESC
+		LIMIT $2
+	`
+
+	rows, err := r.db.QueryContext(ctx, query, webhookID, limit)
+	if err != nil {
+		return nil, fmt.Errorf("query deliveries: %w", err)
+	}
+
+	return collectDeliveries(rows)
+}
+
+func scanWebhook(row rowScanner) (*models.Webhook, error) {
+	var webhook models.Webhook
+	var events []byte
+
+	err := row.Scan(
+		&webhook.ID,
+		&webhook.URL,
+		&webhook.Secret,
+		&events,
+		&webhook.Description,
+		&webhook.Enabled,
+		&webhook.CreatedAt,
+		&webhook.UpdatedAt,
+	)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, err
+	}
+	if err != nil {
+		return nil, fmt.Errorf("scan webhook: %w", err)
+	}
+
+	if unmarshalErr := json.Unmarshal(events, &webhook.Events); unmarshalErr != nil {
+		return nil, fmt.Errorf("unmarshal events: %w", unmarshalErr)
+	}
+
+	return &webhook, nil
+}
+
+// collectDeliveries scans and closes rows.
+func collectDeliveries(rows *sql.Rows) ([]models.WebhookDelivery, error) {
+	defer rows.Close()
+
+	deliveries := []models.WebhookDelivery{}
+	for rows.Next() {
+		var d models.WebhookDelivery
+		var payload []byte
+
+		scanErr := rows.Scan(
+			&d.ID,
+			&d.WebhookID,
+			&d.Event,
+			&payload,
+			&d.Status,
+			&d.Attempts,
+			&d.NextAttemptAt,
+			&d.ResponseStatus,
+			&d.LastError,
+			&d.CreatedAt,
+			&d.UpdatedAt,
+		)
+		if scanErr != nil {
+			return nil, fmt.Errorf("scan delivery: %w", scanErr)
+		}
+		d.Payload = payload
+
+		deliveries = append(deliveries, d)
+	}
+
+	if rowsErr := rows.Err(); rowsErr != nil {
+		return nil, fmt.Errorf("iterate deliveries: %w", rowsErr)
+	}
+
+	return deliveries, nil
+}
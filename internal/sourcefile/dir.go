@@ -0,0 +1,54 @@
+package sourcefile
+
+import (
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// ReadDir reads one entry per .yml, .yaml or .json file under dir, in lexical
+// path order. paths[i] is the file entries[i] came from. Any unreadable file
+// fails the whole read, so a typo can never make a source look deleted.
+func ReadDir(dir string) ([]Entry, []string, error) {
+	var entries []Entry
+	var paths []string
+
+	walkErr := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			return nil
+		}
+
+		var format Format
+		switch strings.ToLower(filepath.Ext(path)) {
+		case ".yml", ".yaml":
+			format = FormatYAML
+		case ".json":
+			format = FormatJSON
+		default:
+			return nil
+		}
+
+		data, readErr := os.ReadFile(path)
+		if readErr != nil {
+			return fmt.Errorf("read %s: %w", path, readErr)
+		}
+		entry, decodeErr := DecodeEntry(data, format)
+		if decodeErr != nil {
+			return fmt.Errorf("%s: %w", path, decodeErr)
+		}
+
+		entries = append(entries, *entry)
+		paths = append(paths, path)
+		return nil
+	})
+	if walkErr != nil {
+		return nil, nil, walkErr
+	}
+
+	return entries, paths, nil
+}
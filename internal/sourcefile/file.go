@@ -0,0 +1,219 @@
+// Package sourcefile reads and writes sources in the crawler's sources.yml
+// format and imports them into a SourceStore.
+package sourcefile
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/jonesrussell/gosources/internal/models"
+	"gopkg.in/yaml.v3"
+)
+
+// Format is the encoding of a sources file.
+type Format string
+
+const (
+	FormatYAML Format = "yaml"
+	FormatJSON Format = "json"
+)
+
+// ErrInvalidFormat is returned for an unknown file format.
+var ErrInvalidFormat = errors.New("invalid format")
+
+// ParseFormat parses a format name, defaulting to FormatYAML.
+func ParseFormat(s string) (Format, error) {
+	switch strings.ToLower(s) {
+	case "", "yaml", "yml":
+		return FormatYAML, nil
+	case "json":
+		return FormatJSON, nil
+	default:
+		return "", fmt.Errorf("%w: %q", ErrInvalidFormat, s)
+	}
+}
+
+// File is the top level of a sources file.
+type File struct {
+	Sources []Entry `json:"sources" yaml:"sources"`
+}
+
+// Entry is one source as the crawler reads it. Database bookkeeping such as
+// the ID, version and timestamps is left out so files can move between
+// installations.
+type Entry struct {
+	Name         string                `json:"name" yaml:"name"`
+	URL          string                `json:"url" yaml:"url"`
+	ArticleIndex string                `json:"article_index" yaml:"article_index"`
+	PageIndex    string                `json:"page_index" yaml:"page_index"`
+	RateLimit    string                `json:"rate_limit,omitempty" yaml:"rate_limit,omitempty"`
+	MaxDepth     int                   `json:"max_depth,omitempty" yaml:"max_depth,omitempty"`
+	Time         []string              `json:"time,omitempty" yaml:"time,omitempty"`
+	Schedule     *models.Schedule      `json:"schedule,omitempty" yaml:"schedule,omitempty"`
+	Selectors    models.SelectorConfig `json:"selectors" yaml:"selectors"`
+	CityName     *string               `json:"city_name,omitempty" yaml:"city_name,omitempty"`
+	GroupID      *string               `json:"group_id,omitempty" yaml:"group_id,omitempty"`
+	Enabled      *bool                 `json:"enabled,omitempty" yaml:"enabled,omitempty"` // Defaults to true
+}
+
+// FromSource converts a stored source to a file entry.
+func FromSource(source *models.Source) Entry {
+	enabled := source.Enabled
+	return Entry{
+		Name:         source.Name,
+		URL:          source.URL,
+		ArticleIndex: source.ArticleIndex,
+		PageIndex:    source.PageIndex,
+		RateLimit:    source.RateLimit,
+		MaxDepth:     source.MaxDepth,
+		Time:         source.Time,
+		Schedule:     source.Schedule,
+		Selectors:    source.Selectors,
+		CityName:     source.CityName,
+		GroupID:      source.GroupID,
+		Enabled:      &enabled,
+	}
+}
+
+// ApplyTo copies the entry's fields onto source, leaving its ID, version and
+// timestamps alone.
+func (e *Entry) ApplyTo(source *models.Source) {
+	source.Name = e.Name
+	source.URL = e.URL
+	source.ArticleIndex = e.ArticleIndex
+	source.PageIndex = e.PageIndex
+	source.RateLimit = e.RateLimit
+	source.MaxDepth = e.MaxDepth
+	source.Time = e.Time
+	source.Schedule = e.Schedule
+	source.Selectors = e.Selectors
+	source.CityName = e.CityName
+	source.GroupID = e.GroupID
+	source.Enabled = e.Enabled == nil || *e.Enabled
+}
+
+// Equal reports whether applying the entry to source would change nothing.
+func (e *Entry) Equal(source *models.Source) bool {
+	var patched models.Source
+	e.ApplyTo(&patched)
+	a, errA := json.Marshal(FromSource(&patched))
+	b, errB := json.Marshal(FromSource(source))
+	return errA == nil && errB == nil && bytes.Equal(a, b)
+}
+
+// Decode reads entries from data. Both a File with a sources key and a bare
+// list of entries are accepted. Unknown fields are rejected so typos are not
+// silently dropped.
+func Decode(data []byte, format Format) ([]Entry, error) {
+	switch format {
+	case FormatJSON:
+		return decodeJSON(data)
+	case FormatYAML:
+		return decodeYAML(data)
+	default:
+		return nil, fmt.Errorf("%w: %q", ErrInvalidFormat, format)
+	}
+}
+
+func decodeJSON(data []byte) ([]Entry, error) {
+	trimmed := bytes.TrimSpace(data)
+	decoder := json.NewDecoder(bytes.NewReader(trimmed))
+	decoder.DisallowUnknownFields()
+
+	if bytes.HasPrefix(trimmed, []byte("[")) {
+		var entries []Entry
+		if err := decoder.Decode(&entries); err != nil {
+			return nil, fmt.Errorf("decode json: %w", err)
+		}
+		return entries, nil
+	}
+
+	var file File
+	if err := decoder.Decode(&file); err != nil {
+		return nil, fmt.Errorf("decode json: %w", err)
+	}
+	return file.Sources, nil
+}
+
+func decodeYAML(data []byte) ([]Entry, error) {
+	var root yaml.Node
+	if err := yaml.Unmarshal(data, &root); err != nil {
+		return nil, fmt.Errorf("decode yaml: %w", err)
+	}
+	if len(root.Content) == 0 {
+		return nil, nil
+	}
+
+	decoder := yaml.NewDecoder(bytes.NewReader(data))
+	decoder.KnownFields(true)
+
+	if root.Content[0].Kind == yaml.SequenceNode {
+		var entries []Entry
+		if err := decoder.Decode(&entries); err != nil {
+			return nil, fmt.Errorf("decode yaml: %w", err)
+		}
+		return entries, nil
+	}
+
+	var file File
+	if err := decoder.Decode(&file); err != nil {
+		return nil, fmt.Errorf("decode yaml: %w", err)
+	}
+	return file.Sources, nil
+}
+
+// DecodeEntry reads a file holding a single entry, as used by ReadDir.
+func DecodeEntry(data []byte, format Format) (*Entry, error) {
+	var entry Entry
+	switch format {
+	case FormatJSON:
+		decoder := json.NewDecoder(bytes.NewReader(data))
+		decoder.DisallowUnknownFields()
+		if err := decoder.Decode(&entry); err != nil {
+			return nil, fmt.Errorf("decode json: %w", err)
+		}
+	case FormatYAML:
+		decoder := yaml.NewDecoder(bytes.NewReader(data))
+		decoder.KnownFields(true)
+		if err := decoder.Decode(&entry); err != nil {
+			return nil, fmt.Errorf("decode yaml: %w", err)
+		}
+	default:
+		return nil, fmt.Errorf("%w: %q", ErrInvalidFormat, format)
+	}
+	return &entry, nil
+}
+
+// Encode writes sources as a File.
+func Encode(w io.Writer, sources []models.Source, format Format) error {
+	file := File{Sources: make([]Entry, len(sources))}
+	for i := range sources {
+		file.Sources[i] = FromSource(&sources[i])
+	}
+
+	switch format {
+	case FormatJSON:
+		encoder := json.NewEncoder(w)
+		encoder.SetIndent("", "  ")
+		if err := encoder.Encode(file); err != nil {
+			return fmt.Errorf("encode json: %w", err)
+		}
+	case FormatYAML:
+		encoder := yaml.NewEncoder(w)
+		encoder.SetIndent(2)
+		if err := encoder.Encode(file); err != nil {
+			return fmt.Errorf("encode yaml: %w", err)
+		}
+		if err := encoder.Close(); err != nil {
+			return fmt.Errorf("encode yaml: %w", err)
+		}
+	default:
+		return fmt.Errorf("%w: %q", ErrInvalidFormat, format)
+	}
+
+	return nil
+}
@@ -0,0 +1,311 @@
+package sourcefile
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/jonesrussell/gosources/internal/auth"
+	"github.com/jonesrussell/gosources/internal/jsondiff"
+	"github.com/jonesrussell/gosources/internal/logger"
+	"github.com/jonesrussell/gosources/internal/models"
+	"github.com/jonesrussell/gosources/internal/repository"
+)
+
+// Mode decides what happens to an entry whose name already exists.
+type Mode string
+
+const (
+	ModeCreate Mode = "create" // existing names are reported as errors
+	ModeUpsert Mode = "upsert" // existing sources are updated from the entry
+	ModeSkip   Mode = "skip"   // existing sources are left untouched
+)
+
+var (
+	// ErrInvalidMode is returned for an unknown import mode.
+	ErrInvalidMode = errors.New("invalid mode")
+
+	// errEntry marks problems with a single entry that the store would not
+	// report itself, such as duplicates within the file.
+	errEntry = errors.New("invalid entry")
+)
+
+// ParseMode parses an import mode, defaulting to ModeCreate.
+func ParseMode(s string) (Mode, error) {
+	switch m := Mode(s); m {
+	case "":
+		return ModeCreate, nil
+	case ModeCreate, ModeUpsert, ModeSkip:
+		return m, nil
+	default:
+		return "", fmt.Errorf("%w: %q", ErrInvalidMode, s)
+	}
+}
+
+// Action is what the import did, or in a dry run would do, with one entry.
+type Action string
+
+const (
+	ActionCreate    Action = "create"
+	ActionUpdate    Action = "update"
+	ActionUnchanged Action = "unchanged"
+	ActionSkip      Action = "skip"
+	ActionDelete    Action = "delete"
+	ActionError     Action = "error"
+)
+
+// Options control an import.
+type Options struct {
+	Mode Mode
+
+	// DryRun reports what would happen without writing anything.
+	DryRun bool
+
+	// Prune deletes stored sources whose names do not appear in the entries.
+	Prune bool
+}
+
+// Result reports the outcome for one entry. Index is the entry's position in
+// the file, or -1 for a pruned source. Changes lists the fields an update
+// modifies.
+type Result struct {
+	Index   int                     `json:"index"`
+	Name    string                  `json:"name"`
+	Action  Action                  `json:"action"`
+	ID      string                  `json:"id,omitempty"`
+	Changes []jsondiff.Change       `json:"changes,omitempty"`
+	Error   string                  `json:"error,omitempty"`
+	Errors  models.ValidationErrors `json:"errors,omitempty"`
+}
+
+// Report is the outcome of an import.
+type Report struct {
+	Mode    Mode           `json:"mode"`
+	DryRun  bool           `json:"dry_run"`
+	Prune   bool           `json:"prune"`
+	Summary map[Action]int `json:"summary"`
+	Results []Result       `json:"results"`
+}
+
+// Importer writes file entries to a SourceStore, matching existing sources
+// by name. Entries may only name cities that exist in cities.
+type Importer struct {
+	store  repository.SourceStore
+	cities repository.CityStore
+	logger logger.Logger
+}
+
+func NewImporter(store repository.SourceStore, cities repository.CityStore, log logger.Logger) *Importer {
+	return &Importer{
+		store:  store,
+		cities: cities,
+		logger: log,
+	}
+}
+
+// Import processes entries in order. Problems with individual entries are
+// reported in their Result and do not stop the import; only storage failures
+// are returned as an error. With DryRun nothing is written but the report is
+// the same as for a real run, including uniqueness checks between entries.
+func (im *Importer) Import(ctx context.Context, entries []Entry, opts Options) (*Report, error) {
+	plan, err := newPlanner(ctx, im.store, im.cities)
+	if err != nil {
+		return nil, err
+	}
+
+	report := &Report{
+		Mode:    opts.Mode,
+		DryRun:  opts.DryRun,
+		Prune:   opts.Prune,
+		Summary: make(map[Action]int),
+		Results: make([]Result, 0, len(entries)),
+	}
+
+	for i := range entries {
+		result := Result{Index: i, Name: entries[i].Name}
+		source, action, planErr := plan.add(i, &entries[i], opts.Mode)
+		result.Action = action
+		if source != nil {
+			result.ID = source.ID
+		}
+		if action == ActionUpdate {
+			result.Changes = plan.changes[source.Name]
+		}
+
+		if planErr == nil && !opts.DryRun {
+			planErr = im.write(ctx, source, action)
+			if planErr == nil {
+				result.ID = source.ID
+			}
+		}
+
+		if planErr != nil {
+			if !isEntryError(planErr) {
+				return nil, fmt.Errorf("import %q: %w", entries[i].Name, planErr)
+			}
+			im.logger.Debug("Import entry failed",
+				logger.Int("index", i),
+				logger.String("source_name", entries[i].Name),
+				logger.Error(planErr),
+			)
+			result.Action = ActionError
+			result.Error = planErr.Error()
+			errors.As(planErr, &result.Errors)
+		}
+
+		report.Summary[result.Action]++
+		report.Results = append(report.Results, result)
+	}
+
+	if opts.Prune {
+		for _, source := range plan.unseen() {
+			result := Result{Index: -1, Name: source.Name, Action: ActionDelete, ID: source.ID}
+			if !opts.DryRun {
+				if deleteErr := im.store.Delete(ctx, source.ID); deleteErr != nil {
+					if !isEntryError(deleteErr) {
+						return nil, fmt.Errorf("prune %q: %w", source.Name, deleteErr)
+					}
+					result.Action = ActionError
+					result.Error = deleteErr.Error()
+				}
+			}
+			report.Summary[result.Action]++
+			report.Results = append(report.Results, result)
+		}
+	}
+
+	return report, nil
+}
+
+func (im *Importer) write(ctx context.Context, source *models.Source, action Action) error {
+	switch action {
+	case ActionCreate:
+		return im.store.Create(ctx, source)
+	case ActionUpdate:
+		return im.store.Update(ctx, source)
+	default:
+		return nil
+	}
+}
+
+// isEntryError reports whether err concerns a single entry rather than the
+// store as a whole.
+func isEntryError(err error) bool {
+	var invalid models.ValidationErrors
+	return errors.As(err, &invalid) ||
+		errors.Is(err, errEntry) ||
+		errors.Is(err, auth.ErrForbidden) ||
+		errors.Is(err, repository.ErrConflict) ||
+		errors.Is(err, repository.ErrConstraint) ||
+		errors.Is(err, repository.ErrNotFound) ||
+		errors.Is(err, repository.ErrPreconditionFailed)
+}
+
+// planner tracks the names that will exist once the entries seen so far are
+// applied, so that conflicts are found before writing. It also knows the
+// cities and the caller, so entries naming a missing city or one the caller
+// may not edit fail the same way in a dry run as when written.
+type planner struct {
+	principal *auth.Principal
+	existing  []models.Source
+	byName    map[string]*models.Source
+	cities    map[string]bool
+	seen      map[string]int // name -> entry index
+	changes   map[string][]jsondiff.Change
+}
+
+func newPlanner(ctx context.Context, store repository.SourceStore, cityStore repository.CityStore) (*planner, error) {
+	existing, err := store.List(ctx, repository.ListOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("list sources: %w", err)
+	}
+	cities, err := cityStore.List(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("list cities: %w", err)
+	}
+
+	p := &planner{
+		principal: auth.PrincipalFromContext(ctx),
+		existing:  existing.Sources,
+		byName:    make(map[string]*models.Source, len(existing.Sources)),
+		cities:    make(map[string]bool, len(cities)),
+		seen:      make(map[string]int),
+		changes:   make(map[string][]jsondiff.Change),
+	}
+	for i := range cities {
+		p.cities[cities[i].Name] = true
+	}
+	for i := range existing.Sources {
+		// Point at a copy so planned updates never alter p.existing.
+		clone := existing.Sources[i]
+		source := &clone
+		p.byName[source.Name] = source
+	}
+
+	return p, nil
+}
+
+// add decides what to do with the entry at index and returns the source to
+// write.
+func (p *planner) add(index int, entry *Entry, mode Mode) (*models.Source, Action, error) {
+	if first, ok := p.seen[entry.Name]; ok && entry.Name != "" {
+		return nil, ActionError, fmt.Errorf("%w: duplicate of entry %d", errEntry, first)
+	}
+	p.seen[entry.Name] = index
+
+	source := &models.Source{}
+	action := ActionCreate
+
+	if existing, ok := p.byName[entry.Name]; ok {
+		switch {
+		case mode == ModeSkip:
+			return existing, ActionSkip, nil
+		case mode == ModeCreate:
+			return existing, ActionError, &repository.ConflictError{Field: "name", Value: entry.Name}
+		case entry.Equal(existing):
+			return existing, ActionUnchanged, nil
+		}
+		clone := *existing
+		source = &clone
+		action = ActionUpdate
+	}
+
+	before := FromSource(source)
+	entry.ApplyTo(source)
+
+	if err := source.Validate(); err != nil {
+		return nil, ActionError, err
+	}
+	if source.CityName != nil && !p.cities[*source.CityName] {
+		return nil, ActionError, repository.UnknownCityError(*source.CityName)
+	}
+	if !p.principal.CanEdit(source.CityName) {
+		if source.CityName == nil {
+			return nil, ActionError, fmt.Errorf("sources without a city: %w", auth.ErrForbidden)
+		}
+		return nil, ActionError, fmt.Errorf("sources in %s: %w", *source.CityName, auth.ErrForbidden)
+	}
+
+	p.byName[source.Name] = source
+
+	if action == ActionUpdate {
+		changes, err := jsondiff.Diff(before, FromSource(source))
+		if err != nil {
+			return nil, ActionError, fmt.Errorf("diff %q: %w", source.Name, err)
+		}
+		p.changes[source.Name] = changes
+	}
+
+	return source, action, nil
+}
+
+// unseen returns the stored sources that no entry named, in name order.
+func (p *planner) unseen() []models.Source {
+	var sources []models.Source
+	for _, source := range p.existing {
+		if _, ok := p.seen[source.Name]; !ok {
+			sources = append(sources, source)
+		}
+	}
+	return sources
+}
@@ -0,0 +1,84 @@
+package sourcefile
+
+import (
+	"context"
+	"testing"
+
+	"github.com/jonesrussell/gosources/internal/logger"
+	"github.com/jonesrussell/gosources/internal/models"
+	"github.com/jonesrussell/gosources/internal/repository"
+)
+
+func TestImportUnknownCity(t *testing.T) {
+	entry := func(name, city string) Entry {
+		e := Entry{
+			Name:         name,
+			URL:          "https://example.com/" + name,
+			ArticleIndex: "articles",
+			PageIndex:    "pages",
+		}
+		if city != "" {
+			e.CityName = &city
+		}
+		return e
+	}
+	entries := []Entry{
+		entry("sudbury", "sudbury_com"),
+		entry("timmins", "timmins"),
+		entry("no-city", ""),
+	}
+	want := []struct {
+		action Action
+		field  string
+	}{
+		{action: ActionCreate},
+		{action: ActionError, field: "city_name"},
+		{action: ActionCreate},
+	}
+
+	for _, dryRun := range []bool{true, false} {
+		name := "apply"
+		if dryRun {
+			name = "dry run"
+		}
+		t.Run(name, func(t *testing.T) {
+			ctx := context.Background()
+			log := logger.NewNopLogger()
+			sources := repository.NewMemorySourceStore(repository.NewMemoryEventLog(log), log)
+			cities := repository.NewMemoryCityStore(sources, log)
+			if err := cities.Create(ctx, &models.City{Name: "sudbury_com", DisplayName: "Sudbury"}); err != nil {
+				t.Fatal(err)
+			}
+
+			report, err := NewImporter(sources, cities, log).Import(ctx, entries, Options{Mode: ModeCreate, DryRun: dryRun})
+			if err != nil {
+				t.Fatalf("Import() error = %v", err)
+			}
+
+			for i, result := range report.Results {
+				if result.Action != want[i].action {
+					t.Errorf("%s: Action = %s, want %s (%s)", result.Name, result.Action, want[i].action, result.Error)
+				}
+				if want[i].field != "" {
+					if len(result.Errors) != 1 || result.Errors[0].Field != want[i].field {
+						t.Errorf("%s: Errors = %+v, want one for %s", result.Name, result.Errors, want[i].field)
+					}
+					if result.ID != "" {
+						t.Errorf("%s: ID = %q for a source that was not stored", result.Name, result.ID)
+					}
+				}
+				if !dryRun && result.Action == ActionCreate && result.ID == "" {
+					t.Errorf("%s: created without an ID", result.Name)
+				}
+			}
+
+			stored, err := sources.List(ctx, repository.ListOptions{})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if wantTotal := map[bool]int{true: 0, false: 2}[dryRun]; stored.Total != wantTotal {
+				t.Errorf("stored %d sources, want %d", stored.Total, wantTotal)
+			}
+		})
+	}
+}
@@ -0,0 +1,90 @@
+package sourcefile
+
+import (
+	"context"
+	"time"
+
+	"github.com/jonesrussell/gosources/internal/logger"
+	"github.com/jonesrussell/gosources/internal/repository"
+)
+
+// SyncActor is the revision actor recorded for changes made by sync.
+const SyncActor = "sync"
+
+// Reconciler periodically applies the files in a directory to a store, the
+// same way as `gosources sync --apply`.
+type Reconciler struct {
+	importer *Importer
+	dir      string
+	interval time.Duration
+	prune    bool
+	logger   logger.Logger
+}
+
+func NewReconciler(
+	store repository.SourceStore, cities repository.CityStore,
+	dir string, interval time.Duration, prune bool, log logger.Logger,
+) *Reconciler {
+	return &Reconciler{
+		importer: NewImporter(store, cities, log),
+		dir:      dir,
+		interval: interval,
+		prune:    prune,
+		logger:   log.With(logger.String("sync_dir", dir)),
+	}
+}
+
+// Run reconciles immediately and then every interval until ctx is done.
+func (r *Reconciler) Run(ctx context.Context) {
+	ticker := time.NewTicker(r.interval)
+	defer ticker.Stop()
+
+	for {
+		r.reconcile(ctx)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (r *Reconciler) reconcile(ctx context.Context) {
+	entries, _, err := ReadDir(r.dir)
+	if err != nil {
+		r.logger.Error("Failed to read source files", logger.Error(err))
+		return
+	}
+	if len(entries) == 0 && r.prune {
+		// An empty or unmounted directory would otherwise delete everything.
+		r.logger.Warn("No source files found; skipping sync")
+		return
+	}
+
+	opts := Options{Mode: ModeUpsert, Prune: r.prune}
+	report, err := r.importer.Import(repository.WithActor(ctx, SyncActor), entries, opts)
+	if err != nil {
+		r.logger.Error("Failed to sync sources", logger.Error(err))
+		return
+	}
+
+	for _, result := range report.Results {
+		if result.Action == ActionError {
+			r.logger.Warn("Source not synced",
+				logger.String("source_name", result.Name),
+				logger.String("error", result.Error),
+			)
+		}
+	}
+
+	changed := report.Summary[ActionCreate] + report.Summary[ActionUpdate] + report.Summary[ActionDelete]
+	if changed > 0 || report.Summary[ActionError] > 0 {
+		r.logger.Info("Sources synced",
+			logger.Int("created", report.Summary[ActionCreate]),
+			logger.Int("updated", report.Summary[ActionUpdate]),
+			logger.Int("deleted", report.Summary[ActionDelete]),
+			logger.Int("failed", report.Summary[ActionError]),
+		)
+	}
+}
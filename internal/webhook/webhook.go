@@ -0,0 +1,344 @@
+// Package webhook delivers source events to subscribed webhooks. Events are
+// read from the event log, queued as deliveries in the WebhookStore, signed
+// with the webhook's secret and retried with exponential backoff until they
+// succeed or run out of attempts.
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jonesrussell/gosources/internal/logger"
+	"github.com/jonesrussell/gosources/internal/models"
+	"github.com/jonesrussell/gosources/internal/repository"
+)
+
+// Request headers sent with every delivery.
+const (
+	HeaderEvent     = "X-Gosources-Event"
+	HeaderDelivery  = "X-Gosources-Delivery"
+	HeaderTimestamp = "X-Gosources-Timestamp"
+	HeaderSignature = "X-Gosources-Signature"
+)
+
+const (
+	// MaxAttempts is the number of tries before a delivery is marked failed.
+	MaxAttempts = 10
+
+	initialBackoff = 5 * time.Second
+	maxBackoff     = time.Hour
+
+	requestTimeout = 10 * time.Second
+	leaseDuration  = time.Minute
+	pollInterval   = 2 * time.Second
+	claimBatchSize = 20
+	eventBatchSize = 100
+
+	secretBytes = 32
+)
+
+// Dispatcher queues deliveries for the events in the event log and sends
+// them. The log is written in the same transaction as the change, and the
+// store remembers how far it has been read, so no change goes unnotified
+// when the server stops or several servers share a database.
+type Dispatcher struct {
+	store    repository.WebhookStore
+	eventLog repository.EventLog
+	client   *http.Client
+	logger   logger.Logger
+	wake     chan struct{}
+}
+
+func NewDispatcher(store repository.WebhookStore, eventLog repository.EventLog, log logger.Logger) *Dispatcher {
+	return &Dispatcher{
+		store:    store,
+		eventLog: eventLog,
+		client:   &http.Client{Timeout: requestTimeout},
+		logger:   log,
+		wake:     make(chan struct{}, 1),
+	}
+}
+
+// queueEvents queues deliveries for the logged events after the store's
+// cursor, a batch at a time, until it has caught up with the log.
+func (d *Dispatcher) queueEvents(ctx context.Context) {
+	for ctx.Err() == nil {
+		cursor, err := d.store.EventCursor(ctx)
+		if err != nil {
+			d.logger.Error("Failed to read webhook event cursor", logger.Error(err))
+			return
+		}
+
+		events, err := d.eventLog.Since(ctx, cursor, eventBatchSize)
+		if err != nil {
+			d.logger.Error("Failed to read event log", logger.Error(err))
+			return
+		}
+		if len(events) == 0 {
+			return
+		}
+
+		deliveries, err := d.deliveries(ctx, events)
+		if err != nil {
+			d.logger.Error("Failed to build webhook deliveries", logger.Error(err))
+			return
+		}
+
+		last := events[len(events)-1].Sequence
+		queueErr := d.store.QueueEvents(ctx, cursor, last, deliveries)
+		switch {
+		case errors.Is(queueErr, repository.ErrConflict):
+			// Another server queued these events; carry on from its cursor.
+			continue
+		case queueErr != nil:
+			d.logger.Error("Failed to queue webhook deliveries",
+				logger.Int64("after_event_id", cursor),
+				logger.Error(queueErr),
+			)
+			return
+		}
+	}
+}
+
+// deliveries returns a delivery of each event to every enabled webhook
+// subscribed to it.
+func (d *Dispatcher) deliveries(ctx context.Context, events []models.SourceEvent) ([]models.WebhookDelivery, error) {
+	webhooks, err := d.store.List(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("list webhooks: %w", err)
+	}
+
+	var deliveries []models.WebhookDelivery
+	for i := range events {
+		event := &events[i]
+
+		var payload []byte
+		for j := range webhooks {
+			if !webhooks[j].Subscribes(event.Type) {
+				continue
+			}
+			if payload == nil {
+				if payload, err = json.Marshal(event); err != nil {
+					return nil, fmt.Errorf("marshal event %d: %w", event.Sequence, err)
+				}
+			}
+			deliveries = append(deliveries, models.WebhookDelivery{
+				WebhookID: webhooks[j].ID,
+				Event:     event.Type,
+				Payload:   payload,
+			})
+		}
+	}
+
+	return deliveries, nil
+}
+
+// Ping queues a ping event for one webhook, regardless of its subscriptions.
+func (d *Dispatcher) Ping(ctx context.Context, webhook *models.Webhook) (*models.WebhookDelivery, error) {
+	payload, err := json.Marshal(map[string]any{
+		"id":          uuid.New().String(),
+		"event":       models.EventPing,
+		"webhook_id":  webhook.ID,
+		"occurred_at": time.Now().UTC(),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("marshal ping: %w", err)
+	}
+
+	deliveries := []models.WebhookDelivery{{
+		WebhookID: webhook.ID,
+		Event:     models.EventPing,
+		Payload:   payload,
+	}}
+	if enqueueErr := d.store.Enqueue(ctx, deliveries); enqueueErr != nil {
+		return nil, fmt.Errorf("enqueue ping: %w", enqueueErr)
+	}
+
+	d.Notify()
+
+	return &deliveries[0], nil
+}
+
+// Notify wakes Run, for example when new events are logged. It never blocks;
+// a wake-up that is already pending covers this one.
+func (d *Dispatcher) Notify() {
+	select {
+	case d.wake <- struct{}{}:
+	default:
+	}
+}
+
+// Run queues deliveries for new events and sends due deliveries until ctx is
+// done, checking every pollInterval and whenever Notify is called.
+func (d *Dispatcher) Run(ctx context.Context) {
+	ticker := time.NewTicker(pollInterval)
+	defer ticker.Stop()
+
+	for {
+		d.queueEvents(ctx)
+		d.deliverDue(ctx)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		case <-d.wake:
+		}
+	}
+}
+
+// deliverDue claims and sends batches until nothing is due.
+func (d *Dispatcher) deliverDue(ctx context.Context) {
+	for ctx.Err() == nil {
+		now := time.Now().UTC()
+		deliveries, err := d.store.Claim(ctx, now, now.Add(leaseDuration), claimBatchSize)
+		if err != nil {
+			d.logger.Error("Failed to claim webhook deliveries", logger.Error(err))
+			return
+		}
+		if len(deliveries) == 0 {
+			return
+		}
+
+		var wg sync.WaitGroup
+		for i := range deliveries {
+			wg.Go(func() {
+				d.attempt(ctx, &deliveries[i])
+			})
+		}
+		wg.Wait()
+	}
+}
+
+// attempt sends one delivery and records the outcome.
+func (d *Dispatcher) attempt(ctx context.Context, delivery *models.WebhookDelivery) {
+	log := d.logger.With(
+		logger.String("webhook_id", delivery.WebhookID),
+		logger.String("delivery_id", delivery.ID),
+		logger.String("event", string(delivery.Event)),
+	)
+
+	webhook, err := d.store.GetByID(ctx, delivery.WebhookID)
+	switch {
+	case errors.Is(err, repository.ErrNotFound):
+		return
+	case err != nil:
+		log.Error("Failed to load webhook", logger.Error(err))
+		return
+	}
+
+	delivery.Attempts++
+
+	if !webhook.Enabled {
+		delivery.Status = models.DeliveryFailed
+		delivery.LastError = "webhook disabled"
+	} else {
+		status, sendErr := d.send(ctx, webhook, delivery)
+		delivery.ResponseStatus = status
+		switch {
+		case sendErr == nil:
+			delivery.Status = models.DeliverySucceeded
+			delivery.LastError = ""
+		case delivery.Attempts >= MaxAttempts:
+			delivery.Status = models.DeliveryFailed
+			delivery.LastError = sendErr.Error()
+		default:
+			delivery.NextAttemptAt = time.Now().UTC().Add(Backoff(delivery.Attempts))
+			delivery.LastError = sendErr.Error()
+		}
+	}
+
+	if saveErr := d.store.SaveDelivery(ctx, delivery); saveErr != nil && !errors.Is(saveErr, repository.ErrNotFound) {
+		log.Error("Failed to save webhook delivery", logger.Error(saveErr))
+		return
+	}
+
+	switch delivery.Status {
+	case models.DeliverySucceeded:
+		log.Debug("Webhook delivered", logger.Int("attempts", delivery.Attempts))
+	case models.DeliveryFailed:
+		log.Warn("Webhook delivery failed permanently",
+			logger.Int("attempts", delivery.Attempts),
+			logger.String("error", delivery.LastError),
+		)
+	case models.DeliveryPending:
+		log.Debug("Webhook delivery will be retried",
+			logger.Int("attempts", delivery.Attempts),
+			logger.Time("next_attempt_at", delivery.NextAttemptAt),
+			logger.String("error", delivery.LastError),
+		)
+	}
+}
+
+// send posts the payload and returns the response status. Any non-2xx
+// response is an error.
+func (d *Dispatcher) send(ctx context.Context, webhook *models.Webhook, delivery *models.WebhookDelivery) (int, error) {
+	timestamp := time.Now().Unix()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, webhook.URL, bytes.NewReader(delivery.Payload))
+	if err != nil {
+		return 0, fmt.Errorf("create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "gosources-webhook")
+	req.Header.Set(HeaderEvent, string(delivery.Event))
+	req.Header.Set(HeaderDelivery, delivery.ID)
+	req.Header.Set(HeaderTimestamp, strconv.FormatInt(timestamp, 10))
+	req.Header.Set(HeaderSignature, Sign(webhook.Secret, timestamp, delivery.Payload))
+
+	resp, err := d.client.Do(req)
+	if err != nil {
+		return 0, fmt.Errorf("send request: %w", err)
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 1<<16))
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return resp.StatusCode, fmt.Errorf("unexpected status %d", resp.StatusCode)
+	}
+
+	return resp.StatusCode, nil
+}
+
+// Sign returns the X-Gosources-Signature value for a payload: "sha256=" and
+// the hex HMAC-SHA256, keyed with the secret, of the timestamp, a dot and the
+// body.
+func Sign(secret string, timestamp int64, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(strconv.FormatInt(timestamp, 10)))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// Backoff returns the delay before the retry that follows the given attempt:
+// 5s, 10s, 20s and so on, capped at an hour.
+func Backoff(attempt int) time.Duration {
+	delay := initialBackoff
+	for i := 1; i < attempt && delay < maxBackoff; i++ {
+		delay *= 2
+	}
+	return min(delay, maxBackoff)
+}
+
+// NewSecret returns a random signing secret.
+func NewSecret() (string, error) {
+	b := make([]byte, secretBytes)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("generate secret: %w", err)
+	}
+	return hex.EncodeToString(b), nil
+}
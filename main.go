@@ -9,12 +9,21 @@ import (
 	"os/signal"
 	"syscall"
 	"time"
+	_ "time/tzdata" // Source schedules name IANA time zones; the runtime image has no zoneinfo
 
 	"github.com/jonesrussell/gosources/internal/api"
+	"github.com/jonesrussell/gosources/internal/auth"
 	"github.com/jonesrussell/gosources/internal/config"
 	"github.com/jonesrussell/gosources/internal/database"
+	"github.com/jonesrussell/gosources/internal/events"
+	"github.com/jonesrussell/gosources/internal/health"
 	"github.com/jonesrussell/gosources/internal/logger"
+	"github.com/jonesrussell/gosources/internal/metrics"
+	"github.com/jonesrussell/gosources/internal/models"
 	"github.com/jonesrussell/gosources/internal/repository"
+	"github.com/jonesrussell/gosources/internal/sourcefile"
+	"github.com/jonesrussell/gosources/internal/tracing"
+	"github.com/jonesrussell/gosources/internal/webhook"
 )
 
 var (
@@ -22,6 +31,14 @@ var (
 	defaultShutdownTimeout = 10
 )
 
+const (
+	// eventPollInterval is how often the event log is checked for changes
+	// when the database cannot notify us.
+	eventPollInterval = 500 * time.Millisecond
+	// eventListenPollInterval is the fallback check alongside LISTEN/NOTIFY.
+	eventListenPollInterval = 30 * time.Second
+)
+
 func main() {
 	var configPath string
 	flag.StringVar(&configPath, "config", "config.yml", "Path to configuration file")
@@ -57,28 +74,182 @@ func main() {
 		logger.String("service", "gosources"),
 		logger.String("version", version),
 	)
+	// Used by logger.FromContext outside requests, e.g. by the sync worker
+	logger.SetDefault(appLogger)
 
-	// Initialize database
-	db, err := database.New(cfg, appLogger)
+	switch command := flag.Arg(0); command {
+	case "":
+	case "migrate":
+		code := runMigrate(cfg, appLogger, flag.Args()[1:])
+		_ = appLogger.Sync()
+		os.Exit(code)
+	case "sync":
+		code := runSync(cfg, appLogger, flag.Args()[1:])
+		_ = appLogger.Sync()
+		os.Exit(code)
+	case "apikey":
+		code := runAPIKey(cfg, appLogger, flag.Args()[1:])
+		_ = appLogger.Sync()
+		os.Exit(code)
+	case "user":
+		code := runUser(cfg, appLogger, flag.Args()[1:])
+		_ = appLogger.Sync()
+		os.Exit(code)
+	default:
+		appLogger.Error("Unknown command",
+			logger.String("command", command),
+		)
+		os.Exit(2)
+	}
+
+	// Trace requests and queries, continuing traces started by callers
+	shutdownTracing, err := tracing.Setup(context.Background(), cfg.Tracing, version)
 	if err != nil {
-		appLogger.Error("Failed to connect to database",
+		appLogger.Error("Failed to set up tracing",
 			logger.Error(err),
 		)
 		os.Exit(1)
 	}
-	defer func() {
-		if closeErr := db.Close(); closeErr != nil {
-			appLogger.Error("Failed to close database",
-				logger.Error(err),
+	if cfg.Tracing.Exporter != config.ExporterNone {
+		appLogger.Info("Exporting traces",
+			logger.String("exporter", cfg.Tracing.Exporter),
+			logger.String("endpoint", cfg.Tracing.Endpoint),
+		)
+	}
+
+	// Background workers stop when the server shuts down
+	workerCtx, stopWorkers := context.WithCancel(context.Background())
+	defer stopWorkers()
+
+	// Initialize storage
+	var sourceStore repository.SourceStore
+	var webhookStore repository.WebhookStore
+	var eventLog repository.EventLog
+	var apiKeyStore repository.APIKeyStore
+	var userStore repository.UserStore
+	var cityStore repository.CityStore
+	var db *database.DB
+	if cfg.Database.Driver == config.DriverMemory {
+		appLogger.Warn("Using in-memory storage; sources will not persist across restarts")
+		memoryEvents := repository.NewMemoryEventLog(appLogger)
+		eventLog = memoryEvents
+		memorySources := repository.NewMemorySourceStore(memoryEvents, appLogger)
+		sourceStore = memorySources
+		cityStore = repository.NewMemoryCityStore(memorySources, appLogger)
+		webhookStore = repository.NewMemoryWebhookStore(appLogger)
+		memoryKeys := repository.NewMemoryAPIKeyStore(appLogger)
+		apiKeyStore = memoryKeys
+		userStore = repository.NewMemoryUserStore(memoryKeys, appLogger)
+	} else {
+		var dbErr error
+		db, dbErr = database.New(cfg, appLogger)
+		if dbErr != nil {
+			appLogger.Error("Failed to connect to database",
+				logger.Error(dbErr),
 			)
+			os.Exit(1)
 		}
-	}()
+		defer func() {
+			if closeErr := db.Close(); closeErr != nil {
+				appLogger.Error("Failed to close database",
+					logger.Error(closeErr),
+				)
+			}
+		}()
+
+		sourceStore = repository.NewSourceRepository(db.DB(), appLogger)
+		webhookStore = repository.NewWebhookRepository(db.DB(), appLogger)
+		eventLog = repository.NewEventLogRepository(db.DB(), appLogger)
+		apiKeyStore = repository.NewAPIKeyRepository(db.DB(), appLogger)
+		userStore = repository.NewUserRepository(db.DB(), appLogger)
+		cityStore = repository.NewCityRepository(db.DB(), appLogger)
+	}
+
+	if !cfg.Auth.Enabled {
+		appLogger.Warn("API key authentication is disabled; every endpoint is open")
+	} else if cfg.Database.Driver == config.DriverMemory {
+		// Keys cannot be created ahead of time for in-memory storage, so
+		// issue an admin key for this run.
+		key, keyErr := auth.Issue(context.Background(), apiKeyStore, "bootstrap", []models.Scope{models.ScopeAdmin}, nil)
+		if keyErr != nil {
+			appLogger.Error("Failed to issue bootstrap API key",
+				logger.Error(keyErr),
+			)
+			os.Exit(1)
+		}
+		fmt.Fprintf(os.Stderr, "Admin API key for this in-memory run: %s\n", key.Key)
+	}
+
+	// Notify webhooks of the source changes in the event log, which the SQL
+	// triggers and the in-memory store write along with each change
+	dispatcher := webhook.NewDispatcher(webhookStore, eventLog, appLogger)
+	go dispatcher.Run(workerCtx)
 
-	// Initialize repository
-	sourceRepo := repository.NewSourceRepository(db.DB(), appLogger)
+	// Stream changes to SSE clients, woken by NOTIFY on PostgreSQL and by
+	// polling the event log otherwise
+	stream := events.NewStream(eventLog, appLogger)
+	pollInterval := eventPollInterval
+	if db != nil && db.Driver() == config.DriverPostgres {
+		pollInterval = eventListenPollInterval
+		notify := func() {
+			stream.Notify()
+			dispatcher.Notify()
+		}
+		go func() {
+			if listenErr := db.Listen(workerCtx, events.Channel, notify); listenErr != nil {
+				appLogger.Error("Failed to listen for source events",
+					logger.Error(listenErr),
+				)
+			}
+		}()
+	}
+	go stream.Run(workerCtx, pollInterval)
+
+	// Keep sources in line with a directory of files when configured
+	if cfg.Sync.Dir != "" {
+		appLogger.Info("Starting source reconciler",
+			logger.String("dir", cfg.Sync.Dir),
+			logger.Duration("interval", cfg.Sync.Interval),
+			logger.Bool("prune", cfg.Sync.Prune),
+		)
+		reconciler := sourcefile.NewReconciler(sourceStore, cityStore, cfg.Sync.Dir, cfg.Sync.Interval, cfg.Sync.Prune, appLogger)
+		go reconciler.Run(workerCtx)
+	}
+
+	// Expose request, connection pool and source metrics
+	appMetrics := metrics.New(appLogger)
+	appMetrics.RegisterSources(sourceStore)
+	if db != nil {
+		appMetrics.RegisterDB(db.DB(), db.Driver())
+	}
+
+	// Readiness checks; the schema must match the migrations in this binary
+	checker := health.NewChecker()
+	if db != nil {
+		migrator, migratorErr := database.NewMigrator(db)
+		if migratorErr != nil {
+			appLogger.Error("Failed to load migrations",
+				logger.Error(migratorErr),
+			)
+			os.Exit(1)
+		}
+		checker.Add("database", db.Ping)
+		checker.Add("migrations", migrator.CheckCurrent)
+	}
 
 	// Initialize router
-	router := api.NewRouter(sourceRepo, appLogger)
+	router := api.NewRouter(api.Services{
+		Sources:    sourceStore,
+		Webhooks:   webhookStore,
+		Dispatcher: dispatcher,
+		EventLog:   eventLog,
+		Stream:     stream,
+		APIKeys:    apiKeyStore,
+		Users:      userStore,
+		Cities:     cityStore,
+		Metrics:    appMetrics,
+		Health:     checker,
+	}, cfg.Auth.Enabled, appLogger)
 
 	// Create HTTP server
 	srv := &http.Server{
@@ -108,7 +279,19 @@ func main() {
 	signal.Notify(quit, os.Interrupt, syscall.SIGTERM)
 	<-quit
 
-	appLogger.Info("Shutting down server")
+	// Fail readiness first so load balancers stop routing here while
+	// requests are still being served. A second signal skips the wait.
+	appLogger.Info("Shutting down server",
+		logger.Duration("drain_delay", cfg.Server.DrainDelay),
+	)
+	checker.Drain()
+	if cfg.Server.DrainDelay > 0 {
+		select {
+		case <-time.After(cfg.Server.DrainDelay):
+		case <-quit:
+		}
+	}
+	stopWorkers()
 
 	// Graceful shutdown
 	ctx, cancel := context.WithTimeout(context.Background(), time.Duration(defaultShutdownTimeout)*time.Second)
@@ -120,5 +303,11 @@ func main() {
 		)
 	}
 
+	if tracingErr := shutdownTracing(ctx); tracingErr != nil {
+		appLogger.Error("Failed to flush traces",
+			logger.Error(tracingErr),
+		)
+	}
+
 	appLogger.Info("Server exited")
 }
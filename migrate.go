@@ -0,0 +1,72 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+
+	"github.com/jonesrussell/gosources/internal/config"
+	"github.com/jonesrussell/gosources/internal/database"
+	"github.com/jonesrussell/gosources/internal/logger"
+)
+
+const migrateUsage = "usage: gosources [-config path] migrate up | down [steps] | version"
+
+// runMigrate implements the migrate subcommand and returns the exit code.
+func runMigrate(cfg *config.Config, log logger.Logger, args []string) int {
+	if len(args) == 0 {
+		fmt.Fprintln(os.Stderr, migrateUsage)
+		return 2
+	}
+
+	db, err := database.Open(cfg, log)
+	if err != nil {
+		log.Error("Failed to connect to database", logger.Error(err))
+		return 1
+	}
+	defer func() {
+		_ = db.Close()
+	}()
+
+	migrator, err := database.NewMigrator(db)
+	if err != nil {
+		log.Error("Failed to load migrations", logger.Error(err))
+		return 1
+	}
+
+	ctx := context.Background()
+	var version int
+
+	switch args[0] {
+	case "up":
+		version, err = migrator.Up(ctx)
+	case "down":
+		steps := 1
+		if len(args) > 1 {
+			if steps, err = strconv.Atoi(args[1]); err != nil || steps <= 0 {
+				fmt.Fprintln(os.Stderr, migrateUsage)
+				return 2
+			}
+		}
+		version, err = migrator.Down(ctx, steps)
+	case "version":
+		version, err = migrator.Version(ctx)
+	default:
+		fmt.Fprintln(os.Stderr, migrateUsage)
+		return 2
+	}
+
+	if err != nil {
+		log.Error("Migration failed", logger.Error(err))
+		if errors.Is(err, database.ErrSchemaTooNew) {
+			return 3
+		}
+		return 1
+	}
+
+	fmt.Fprintf(os.Stdout, "schema version %d (latest %d)\n", version, migrator.Latest())
+
+	return 0
+}
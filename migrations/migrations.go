@@ -0,0 +1,9 @@
+// Package migrations embeds the SQL schema migrations for each supported
+// database driver. Files are named NNN_description.up.sql with an optional
+// NNN_description.down.sql counterpart.
+package migrations
+
+import "embed"
+
+//go:embed postgres/*.sql sqlite/*.sql
+var FS embed.FS
@@ -0,0 +1,3 @@
+DROP TRIGGER IF EXISTS update_sources_updated_at ON sources;
+DROP FUNCTION IF EXISTS update_updated_at_column();
+DROP TABLE IF EXISTS sources;
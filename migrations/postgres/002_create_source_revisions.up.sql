@@ -0,0 +1,40 @@
+-- Create source_revisions table holding a full snapshot per change
+CREATE TABLE IF NOT EXISTS source_revisions (
+    id BIGSERIAL PRIMARY KEY,
+    source_id VARCHAR(36) NOT NULL,
+    revision INTEGER NOT NULL,
+    action VARCHAR(16) NOT NULL,
+    actor VARCHAR(255) NOT NULL DEFAULT '',
+    snapshot JSONB NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    CONSTRAINT unique_source_revision UNIQUE (source_id, revision)
+);
+
+CREATE INDEX IF NOT EXISTS idx_source_revisions_source_id ON source_revisions(source_id);
+
+-- Seed revision 1 for sources that existed before history was tracked
+INSERT INTO source_revisions (source_id, revision, action, actor, snapshot, created_at)
+SELECT
+    s.id,
+    1,
+    'create',
+    'migration',
+    jsonb_strip_nulls(jsonb_build_object(
+        'id', s.id,
+        'name', s.name,
+        'url', s.url,
+        'article_index', s.article_index,
+        'page_index', s.page_index,
+        'rate_limit', s.rate_limit,
+        'max_depth', s.max_depth,
+        'time', s.time,
+        'selectors', s.selectors,
+        'city_name', s.city_name,
+        'group_id', s.group_id,
+        'enabled', s.enabled,
+        'created_at', to_char(s.created_at, 'YYYY-MM-DD"T"HH24:MI:SS.US"Z"'),
+        'updated_at', to_char(s.updated_at, 'YYYY-MM-DD"T"HH24:MI:SS.US"Z"')
+    )),
+    s.updated_at
+FROM sources s
+WHERE NOT EXISTS (SELECT 1 FROM source_revisions r WHERE r.source_id = s.id);
@@ -0,0 +1,2 @@
+-- Add a version counter for optimistic concurrency control
+ALTER TABLE sources ADD COLUMN IF NOT EXISTS version INTEGER NOT NULL DEFAULT 1;
@@ -0,0 +1,6 @@
+DROP TRIGGER IF EXISTS attribute_source_event ON source_revisions;
+DROP TRIGGER IF EXISTS record_source_event ON sources;
+DROP FUNCTION IF EXISTS attribute_source_event();
+DROP FUNCTION IF EXISTS record_source_event();
+DROP FUNCTION IF EXISTS source_snapshot(sources);
+DROP TABLE IF EXISTS source_events;
@@ -0,0 +1,100 @@
+-- Create source_events, the ordered change log behind GET /api/v1/events.
+-- Rows are written by triggers so changes made by any replica or by direct
+-- SQL are captured, and listeners are woken with NOTIFY source_events.
+CREATE TABLE IF NOT EXISTS source_events (
+    id BIGSERIAL PRIMARY KEY,
+    event VARCHAR(50) NOT NULL,
+    source_id VARCHAR(36) NOT NULL,
+    actor VARCHAR(255),
+    source JSONB,
+    previous JSONB,
+    occurred_at TIMESTAMP NOT NULL DEFAULT (NOW() AT TIME ZONE 'UTC')
+);
+
+CREATE INDEX IF NOT EXISTS idx_source_events_occurred_at ON source_events(occurred_at);
+CREATE INDEX IF NOT EXISTS idx_source_events_source_id ON source_events(source_id);
+
+-- Render a sources row in the API's JSON shape
+CREATE OR REPLACE FUNCTION source_snapshot(s sources)
+RETURNS JSONB AS $$
+    SELECT jsonb_strip_nulls(jsonb_build_object(
+        'id', s.id,
+        'name', s.name,
+        'url', s.url,
+        'article_index', s.article_index,
+        'page_index', s.page_index,
+        'rate_limit', s.rate_limit,
+        'max_depth', s.max_depth,
+        'time', s.time,
+        'selectors', s.selectors,
+        'city_name', s.city_name,
+        'group_id', s.group_id,
+        'enabled', s.enabled,
+        'version', s.version,
+        'created_at', to_char(s.created_at, 'YYYY-MM-DD"T"HH24:MI:SS.US"Z"'),
+        'updated_at', to_char(s.updated_at, 'YYYY-MM-DD"T"HH24:MI:SS.US"Z"')
+    ))
+$$ LANGUAGE sql STABLE;
+
+CREATE OR REPLACE FUNCTION record_source_event()
+RETURNS TRIGGER AS $$
+BEGIN
+    -- Hold a lock until commit so event IDs are assigned in commit order and
+    -- a reader resuming after an ID never misses a late commit.
+    PERFORM pg_advisory_xact_lock(7263540119);
+
+    IF TG_OP = 'INSERT' THEN
+        INSERT INTO source_events (event, source_id, source)
+        VALUES ('source.created', NEW.id, source_snapshot(NEW));
+    ELSIF TG_OP = 'UPDATE' THEN
+        INSERT INTO source_events (event, source_id, source, previous)
+        VALUES ('source.updated', NEW.id, source_snapshot(NEW), source_snapshot(OLD));
+
+        IF NEW.enabled <> OLD.enabled THEN
+            INSERT INTO source_events (event, source_id, source, previous)
+            VALUES (
+                CASE WHEN NEW.enabled THEN 'source.enabled' ELSE 'source.disabled' END,
+                NEW.id, source_snapshot(NEW), source_snapshot(OLD)
+            );
+        END IF;
+    ELSE
+        INSERT INTO source_events (event, source_id, previous)
+        VALUES ('source.deleted', OLD.id, source_snapshot(OLD));
+    END IF;
+
+    PERFORM pg_notify('source_events', '');
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+DROP TRIGGER IF EXISTS record_source_event ON sources;
+CREATE TRIGGER record_source_event
+    AFTER INSERT OR UPDATE OR DELETE ON sources
+    FOR EACH ROW
+    EXECUTE FUNCTION record_source_event();
+
+-- Attribute events to the actor of the revision written in the same
+-- transaction: the source's latest created, updated or deleted event and the
+-- enabled or disabled event that may follow it. Changes made by direct SQL
+-- record no revision and keep a NULL actor.
+CREATE OR REPLACE FUNCTION attribute_source_event()
+RETURNS TRIGGER AS $$
+BEGIN
+    UPDATE source_events
+    SET actor = NEW.actor
+    WHERE source_id = NEW.source_id
+      AND actor IS NULL
+      AND id >= (
+          SELECT MAX(id) FROM source_events
+          WHERE source_id = NEW.source_id
+            AND event IN ('source.created', 'source.updated', 'source.deleted')
+      );
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+DROP TRIGGER IF EXISTS attribute_source_event ON source_revisions;
+CREATE TRIGGER attribute_source_event
+    AFTER INSERT ON source_revisions
+    FOR EACH ROW
+    EXECUTE FUNCTION attribute_source_event();
@@ -0,0 +1,23 @@
+-- Restore the snapshot without the schedule before dropping the column
+CREATE OR REPLACE FUNCTION source_snapshot(s sources)
+RETURNS JSONB AS $$
+    SELECT jsonb_strip_nulls(jsonb_build_object(
+        'id', s.id,
+        'name', s.name,
+        'url', s.url,
+        'article_index', s.article_index,
+        'page_index', s.page_index,
+        'rate_limit', s.rate_limit,
+        'max_depth', s.max_depth,
+        'time', s.time,
+        'selectors', s.selectors,
+        'city_name', s.city_name,
+        'group_id', s.group_id,
+        'enabled', s.enabled,
+        'version', s.version,
+        'created_at', to_char(s.created_at, 'YYYY-MM-DD"T"HH24:MI:SS.US"Z"'),
+        'updated_at', to_char(s.updated_at, 'YYYY-MM-DD"T"HH24:MI:SS.US"Z"')
+    ))
+$$ LANGUAGE sql STABLE;
+
+ALTER TABLE sources DROP COLUMN IF EXISTS schedule;
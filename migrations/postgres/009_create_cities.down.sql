@@ -0,0 +1,2 @@
+ALTER TABLE sources DROP CONSTRAINT IF EXISTS fk_sources_city;
+DROP TABLE IF EXISTS cities;
@@ -0,0 +1,24 @@
+-- Create cities with their gopost settings, one per city_name already in use,
+-- and make sources refer to them
+CREATE TABLE IF NOT EXISTS cities (
+    id VARCHAR(36) PRIMARY KEY,
+    name VARCHAR(255) NOT NULL,
+    display_name VARCHAR(255) NOT NULL,
+    region VARCHAR(255),
+    timezone VARCHAR(64),
+    group_id VARCHAR(36),
+    default_index VARCHAR(255),
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    CONSTRAINT unique_cities_name UNIQUE (name)
+);
+
+INSERT INTO cities (id, name, display_name)
+SELECT gen_random_uuid()::text, city_name, city_name
+FROM sources
+WHERE city_name IS NOT NULL
+GROUP BY city_name
+ON CONFLICT (name) DO NOTHING;
+
+ALTER TABLE sources
+    ADD CONSTRAINT fk_sources_city FOREIGN KEY (city_name) REFERENCES cities(name);
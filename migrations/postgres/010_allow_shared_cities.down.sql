@@ -0,0 +1,3 @@
+-- Restore one source per city. This fails while a city has several sources;
+-- move or delete the extra sources first.
+ALTER TABLE sources ADD CONSTRAINT unique_city_name UNIQUE (city_name) DEFERRABLE INITIALLY DEFERRED;
@@ -0,0 +1,2 @@
+-- Let a city have several sources
+ALTER TABLE sources DROP CONSTRAINT IF EXISTS unique_city_name;
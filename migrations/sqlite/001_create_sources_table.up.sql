@@ -0,0 +1,24 @@
+-- Create sources table
+CREATE TABLE IF NOT EXISTS sources (
+    id VARCHAR(36) PRIMARY KEY,
+    name VARCHAR(255) NOT NULL,
+    url TEXT NOT NULL,
+    article_index VARCHAR(255) NOT NULL,
+    page_index VARCHAR(255) NOT NULL,
+    rate_limit VARCHAR(50) NOT NULL DEFAULT '1s',
+    max_depth INTEGER NOT NULL DEFAULT 2,
+    time TEXT,
+    selectors TEXT NOT NULL,
+    city_name VARCHAR(255),
+    group_id VARCHAR(36),
+    enabled BOOLEAN NOT NULL DEFAULT true,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    CONSTRAINT unique_source_name UNIQUE (name),
+    CONSTRAINT unique_city_name UNIQUE (city_name)
+);
+
+-- Create indexes
+CREATE INDEX IF NOT EXISTS idx_sources_city_name ON sources(city_name);
+CREATE INDEX IF NOT EXISTS idx_sources_enabled ON sources(enabled);
+CREATE INDEX IF NOT EXISTS idx_sources_article_index ON sources(article_index);
@@ -0,0 +1,40 @@
+-- Create source_revisions table holding a full snapshot per change
+CREATE TABLE IF NOT EXISTS source_revisions (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    source_id VARCHAR(36) NOT NULL,
+    revision INTEGER NOT NULL,
+    action VARCHAR(16) NOT NULL,
+    actor VARCHAR(255) NOT NULL DEFAULT '',
+    snapshot TEXT NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    CONSTRAINT unique_source_revision UNIQUE (source_id, revision)
+);
+
+CREATE INDEX IF NOT EXISTS idx_source_revisions_source_id ON source_revisions(source_id);
+
+-- Seed revision 1 for sources that existed before history was tracked
+INSERT INTO source_revisions (source_id, revision, action, actor, snapshot, created_at)
+SELECT
+    s.id,
+    1,
+    'create',
+    'migration',
+    json_object(
+        'id', s.id,
+        'name', s.name,
+        'url', s.url,
+        'article_index', s.article_index,
+        'page_index', s.page_index,
+        'rate_limit', s.rate_limit,
+        'max_depth', s.max_depth,
+        'time', json(COALESCE(CAST(s.time AS TEXT), 'null')),
+        'selectors', json(CAST(s.selectors AS TEXT)),
+        'city_name', s.city_name,
+        'group_id', s.group_id,
+        'enabled', CASE WHEN s.enabled THEN json('true') ELSE json('false') END,
+        'created_at', strftime('%Y-%m-%dT%H:%M:%fZ', s.created_at),
+        'updated_at', strftime('%Y-%m-%dT%H:%M:%fZ', s.updated_at)
+    ),
+    s.updated_at
+FROM sources s
+WHERE NOT EXISTS (SELECT 1 FROM source_revisions r WHERE r.source_id = s.id);
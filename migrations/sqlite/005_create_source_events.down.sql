@@ -0,0 +1,5 @@
+DROP TRIGGER IF EXISTS attribute_source_event;
+DROP TRIGGER IF EXISTS record_source_deleted;
+DROP TRIGGER IF EXISTS record_source_updated;
+DROP TRIGGER IF EXISTS record_source_created;
+DROP TABLE IF EXISTS source_events;
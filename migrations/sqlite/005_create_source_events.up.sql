@@ -0,0 +1,128 @@
+-- Create source_events, the ordered change log behind GET /api/v1/events.
+-- Rows are written by triggers so changes made by direct SQL are captured
+-- too. AUTOINCREMENT keeps IDs from being reused after old events are pruned,
+-- and occurred_at uses the driver's time format so it compares with query
+-- parameters.
+CREATE TABLE IF NOT EXISTS source_events (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    event VARCHAR(50) NOT NULL,
+    source_id VARCHAR(36) NOT NULL,
+    actor VARCHAR(255),
+    source TEXT,
+    previous TEXT,
+    occurred_at TIMESTAMP NOT NULL DEFAULT (strftime('%Y-%m-%d %H:%M:%f+00:00', 'now'))
+);
+
+CREATE INDEX IF NOT EXISTS idx_source_events_occurred_at ON source_events(occurred_at);
+CREATE INDEX IF NOT EXISTS idx_source_events_source_id ON source_events(source_id);
+
+CREATE TRIGGER IF NOT EXISTS record_source_created
+AFTER INSERT ON sources
+BEGIN
+    INSERT INTO source_events (event, source_id, source)
+    VALUES ('source.created', NEW.id, json_object(
+            'id', NEW.id,
+            'name', NEW.name,
+            'url', NEW.url,
+            'article_index', NEW.article_index,
+            'page_index', NEW.page_index,
+            'rate_limit', NEW.rate_limit,
+            'max_depth', NEW.max_depth,
+            'time', json(COALESCE(CAST(NEW.time AS TEXT), 'null')),
+            'selectors', json(CAST(NEW.selectors AS TEXT)),
+            'city_name', NEW.city_name,
+            'group_id', NEW.group_id,
+            'enabled', CASE WHEN NEW.enabled THEN json('true') ELSE json('false') END,
+            'version', NEW.version,
+            'created_at', strftime('%Y-%m-%dT%H:%M:%fZ', NEW.created_at),
+            'updated_at', strftime('%Y-%m-%dT%H:%M:%fZ', NEW.updated_at)
+        ));
+END;
+
+CREATE TRIGGER IF NOT EXISTS record_source_updated
+AFTER UPDATE ON sources
+BEGIN
+    INSERT INTO source_events (event, source_id, source, previous)
+    VALUES ('source.updated', NEW.id, json_object(
+            'id', NEW.id,
+            'name', NEW.name,
+            'url', NEW.url,
+            'article_index', NEW.article_index,
+            'page_index', NEW.page_index,
+            'rate_limit', NEW.rate_limit,
+            'max_depth', NEW.max_depth,
+            'time', json(COALESCE(CAST(NEW.time AS TEXT), 'null')),
+            'selectors', json(CAST(NEW.selectors AS TEXT)),
+            'city_name', NEW.city_name,
+            'group_id', NEW.group_id,
+            'enabled', CASE WHEN NEW.enabled THEN json('true') ELSE json('false') END,
+            'version', NEW.version,
+            'created_at', strftime('%Y-%m-%dT%H:%M:%fZ', NEW.created_at),
+            'updated_at', strftime('%Y-%m-%dT%H:%M:%fZ', NEW.updated_at)
+        ), json_object(
+            'id', OLD.id,
+            'name', OLD.name,
+            'url', OLD.url,
+            'article_index', OLD.article_index,
+            'page_index', OLD.page_index,
+            'rate_limit', OLD.rate_limit,
+            'max_depth', OLD.max_depth,
+            'time', json(COALESCE(CAST(OLD.time AS TEXT), 'null')),
+            'selectors', json(CAST(OLD.selectors AS TEXT)),
+            'city_name', OLD.city_name,
+            'group_id', OLD.group_id,
+            'enabled', CASE WHEN OLD.enabled THEN json('true') ELSE json('false') END,
+            'version', OLD.version,
+            'created_at', strftime('%Y-%m-%dT%H:%M:%fZ', OLD.created_at),
+            'updated_at', strftime('%Y-%m-%dT%H:%M:%fZ', OLD.updated_at)
+        ));
+
+    -- last_insert_rowid() is the source.updated row inserted above
+    INSERT INTO source_events (event, source_id, source, previous)
+    SELECT
+        CASE WHEN NEW.enabled THEN 'source.enabled' ELSE 'source.disabled' END,
+        source_id, source, previous
+    FROM source_events
+    WHERE id = last_insert_rowid() AND NEW.enabled <> OLD.enabled;
+END;
+
+CREATE TRIGGER IF NOT EXISTS record_source_deleted
+AFTER DELETE ON sources
+BEGIN
+    INSERT INTO source_events (event, source_id, previous)
+    VALUES ('source.deleted', OLD.id, json_object(
+            'id', OLD.id,
+            'name', OLD.name,
+            'url', OLD.url,
+            'article_index', OLD.article_index,
+            'page_index', OLD.page_index,
+            'rate_limit', OLD.rate_limit,
+            'max_depth', OLD.max_depth,
+            'time', json(COALESCE(CAST(OLD.time AS TEXT), 'null')),
+            'selectors', json(CAST(OLD.selectors AS TEXT)),
+            'city_name', OLD.city_name,
+            'group_id', OLD.group_id,
+            'enabled', CASE WHEN OLD.enabled THEN json('true') ELSE json('false') END,
+            'version', OLD.version,
+            'created_at', strftime('%Y-%m-%dT%H:%M:%fZ', OLD.created_at),
+            'updated_at', strftime('%Y-%m-%dT%H:%M:%fZ', OLD.updated_at)
+        ));
+END;
+
+-- Attribute events to the actor of the revision written in the same
+-- transaction: the source's latest created, updated or deleted event and the
+-- enabled or disabled event that may follow it. Changes made by direct SQL
+-- record no revision and keep a NULL actor.
+CREATE TRIGGER IF NOT EXISTS attribute_source_event
+AFTER INSERT ON source_revisions
+BEGIN
+    UPDATE source_events
+    SET actor = NEW.actor
+    WHERE source_id = NEW.source_id
+      AND actor IS NULL
+      AND id >= (
+          SELECT MAX(id) FROM source_events
+          WHERE source_id = NEW.source_id
+            AND event IN ('source.created', 'source.updated', 'source.deleted')
+      );
+END;
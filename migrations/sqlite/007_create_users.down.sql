@@ -0,0 +1,23 @@
+-- SQLite cannot drop a column that references another table, so rebuild
+-- api_keys without user_id
+DROP INDEX IF EXISTS idx_api_keys_user_id;
+
+CREATE TABLE api_keys_without_users (
+    id VARCHAR(36) PRIMARY KEY,
+    name VARCHAR(255) NOT NULL,
+    prefix VARCHAR(16) NOT NULL,
+    key_hash VARCHAR(64) NOT NULL,
+    scopes TEXT NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    last_used_at TIMESTAMP,
+    revoked_at TIMESTAMP,
+    CONSTRAINT unique_api_key_hash UNIQUE (key_hash)
+);
+
+INSERT INTO api_keys_without_users (id, name, prefix, key_hash, scopes, created_at, last_used_at, revoked_at)
+SELECT id, name, prefix, key_hash, scopes, created_at, last_used_at, revoked_at FROM api_keys;
+
+DROP TABLE api_keys;
+ALTER TABLE api_keys_without_users RENAME TO api_keys;
+
+DROP TABLE IF EXISTS users;
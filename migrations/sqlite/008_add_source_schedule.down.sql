@@ -0,0 +1,100 @@
+-- Restore the change-log triggers without the schedule, which SQLite
+-- requires before the column can be dropped
+DROP TRIGGER IF EXISTS record_source_created;
+DROP TRIGGER IF EXISTS record_source_updated;
+DROP TRIGGER IF EXISTS record_source_deleted;
+
+CREATE TRIGGER IF NOT EXISTS record_source_created
+AFTER INSERT ON sources
+BEGIN
+    INSERT INTO source_events (event, source_id, source)
+    VALUES ('source.created', NEW.id, json_object(
+            'id', NEW.id,
+            'name', NEW.name,
+            'url', NEW.url,
+            'article_index', NEW.article_index,
+            'page_index', NEW.page_index,
+            'rate_limit', NEW.rate_limit,
+            'max_depth', NEW.max_depth,
+            'time', json(COALESCE(CAST(NEW.time AS TEXT), 'null')),
+            'selectors', json(CAST(NEW.selectors AS TEXT)),
+            'city_name', NEW.city_name,
+            'group_id', NEW.group_id,
+            'enabled', CASE WHEN NEW.enabled THEN json('true') ELSE json('false') END,
+            'version', NEW.version,
+            'created_at', strftime('%Y-%m-%dT%H:%M:%fZ', NEW.created_at),
+            'updated_at', strftime('%Y-%m-%dT%H:%M:%fZ', NEW.updated_at)
+        ));
+END;
+
+CREATE TRIGGER IF NOT EXISTS record_source_updated
+AFTER UPDATE ON sources
+BEGIN
+    INSERT INTO source_events (event, source_id, source, previous)
+    VALUES ('source.updated', NEW.id, json_object(
+            'id', NEW.id,
+            'name', NEW.name,
+            'url', NEW.url,
+            'article_index', NEW.article_index,
+            'page_index', NEW.page_index,
+            'rate_limit', NEW.rate_limit,
+            'max_depth', NEW.max_depth,
+            'time', json(COALESCE(CAST(NEW.time AS TEXT), 'null')),
+            'selectors', json(CAST(NEW.selectors AS TEXT)),
+            'city_name', NEW.city_name,
+            'group_id', NEW.group_id,
+            'enabled', CASE WHEN NEW.enabled THEN json('true') ELSE json('false') END,
+            'version', NEW.version,
+            'created_at', strftime('%Y-%m-%dT%H:%M:%fZ', NEW.created_at),
+            'updated_at', strftime('%Y-%m-%dT%H:%M:%fZ', NEW.updated_at)
+        ), json_object(
+            'id', OLD.id,
+            'name', OLD.name,
+            'url', OLD.url,
+            'article_index', OLD.article_index,
+            'page_index', OLD.page_index,
+            'rate_limit', OLD.rate_limit,
+            'max_depth', OLD.max_depth,
+            'time', json(COALESCE(CAST(OLD.time AS TEXT), 'null')),
+            'selectors', json(CAST(OLD.selectors AS TEXT)),
+            'city_name', OLD.city_name,
+            'group_id', OLD.group_id,
+            'enabled', CASE WHEN OLD.enabled THEN json('true') ELSE json('false') END,
+            'version', OLD.version,
+            'created_at', strftime('%Y-%m-%dT%H:%M:%fZ', OLD.created_at),
+            'updated_at', strftime('%Y-%m-%dT%H:%M:%fZ', OLD.updated_at)
+        ));
+
+    -- last_insert_rowid() is the source.updated row inserted above
+    INSERT INTO source_events (event, source_id, source, previous)
+    SELECT
+        CASE WHEN NEW.enabled THEN 'source.enabled' ELSE 'source.disabled' END,
+        source_id, source, previous
+    FROM source_events
+    WHERE id = last_insert_rowid() AND NEW.enabled <> OLD.enabled;
+END;
+
+CREATE TRIGGER IF NOT EXISTS record_source_deleted
+AFTER DELETE ON sources
+BEGIN
+    INSERT INTO source_events (event, source_id, previous)
+    VALUES ('source.deleted', OLD.id, json_object(
+            'id', OLD.id,
+            'name', OLD.name,
+            'url', OLD.url,
+            'article_index', OLD.article_index,
+            'page_index', OLD.page_index,
+            'rate_limit', OLD.rate_limit,
+            'max_depth', OLD.max_depth,
+            'time', json(COALESCE(CAST(OLD.time AS TEXT), 'null')),
+            'selectors', json(CAST(OLD.selectors AS TEXT)),
+            'city_name', OLD.city_name,
+            'group_id', OLD.group_id,
+            'enabled', CASE WHEN OLD.enabled THEN json('true') ELSE json('false') END,
+            'version', OLD.version,
+            'created_at', strftime('%Y-%m-%dT%H:%M:%fZ', OLD.created_at),
+            'updated_at', strftime('%Y-%m-%dT%H:%M:%fZ', OLD.updated_at)
+        ));
+END;
+
+ALTER TABLE sources DROP COLUMN schedule;
@@ -0,0 +1,134 @@
+-- SQLite cannot drop a foreign key, so rebuild sources without it
+CREATE TABLE sources_without_cities (
+    id VARCHAR(36) PRIMARY KEY,
+    name VARCHAR(255) NOT NULL,
+    url TEXT NOT NULL,
+    article_index VARCHAR(255) NOT NULL,
+    page_index VARCHAR(255) NOT NULL,
+    rate_limit VARCHAR(50) NOT NULL DEFAULT '1s',
+    max_depth INTEGER NOT NULL DEFAULT 2,
+    time TEXT,
+    selectors TEXT NOT NULL,
+    city_name VARCHAR(255),
+    group_id VARCHAR(36),
+    enabled BOOLEAN NOT NULL DEFAULT true,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    version INTEGER NOT NULL DEFAULT 1,
+    schedule TEXT,
+    CONSTRAINT unique_source_name UNIQUE (name),
+    CONSTRAINT unique_city_name UNIQUE (city_name)
+);
+
+INSERT INTO sources_without_cities (id, name, url, article_index, page_index, rate_limit, max_depth, time, selectors,
+    city_name, group_id, enabled, created_at, updated_at, version, schedule)
+SELECT id, name, url, article_index, page_index, rate_limit, max_depth, time, selectors,
+       city_name, group_id, enabled, created_at, updated_at, version, schedule
+FROM sources;
+
+DROP TABLE sources;
+ALTER TABLE sources_without_cities RENAME TO sources;
+
+CREATE INDEX IF NOT EXISTS idx_sources_city_name ON sources(city_name);
+CREATE INDEX IF NOT EXISTS idx_sources_enabled ON sources(enabled);
+CREATE INDEX IF NOT EXISTS idx_sources_article_index ON sources(article_index);
+
+-- Dropping the old table dropped its change log triggers
+CREATE TRIGGER IF NOT EXISTS record_source_created
+AFTER INSERT ON sources
+BEGIN
+    INSERT INTO source_events (event, source_id, source)
+    VALUES ('source.created', NEW.id, json_object(
+            'id', NEW.id,
+            'name', NEW.name,
+            'url', NEW.url,
+            'article_index', NEW.article_index,
+            'page_index', NEW.page_index,
+            'rate_limit', NEW.rate_limit,
+            'max_depth', NEW.max_depth,
+            'time', json(COALESCE(CAST(NEW.time AS TEXT), 'null')),
+            'schedule', json(COALESCE(CAST(NEW.schedule AS TEXT), 'null')),
+            'selectors', json(CAST(NEW.selectors AS TEXT)),
+            'city_name', NEW.city_name,
+            'group_id', NEW.group_id,
+            'enabled', CASE WHEN NEW.enabled THEN json('true') ELSE json('false') END,
+            'version', NEW.version,
+            'created_at', strftime('%Y-%m-%dT%H:%M:%fZ', NEW.created_at),
+            'updated_at', strftime('%Y-%m-%dT%H:%M:%fZ', NEW.updated_at)
+        ));
+END;
+
+CREATE TRIGGER IF NOT EXISTS record_source_updated
+AFTER UPDATE ON sources
+BEGIN
+    INSERT INTO source_events (event, source_id, source, previous)
+    VALUES ('source.updated', NEW.id, json_object(
+            'id', NEW.id,
+            'name', NEW.name,
+            'url', NEW.url,
+            'article_index', NEW.article_index,
+            'page_index', NEW.page_index,
+            'rate_limit', NEW.rate_limit,
+            'max_depth', NEW.max_depth,
+            'time', json(COALESCE(CAST(NEW.time AS TEXT), 'null')),
+            'schedule', json(COALESCE(CAST(NEW.schedule AS TEXT), 'null')),
+            'selectors', json(CAST(NEW.selectors AS TEXT)),
+            'city_name', NEW.city_name,
+            'group_id', NEW.group_id,
+            'enabled', CASE WHEN NEW.enabled THEN json('true') ELSE json('false') END,
+            'version', NEW.version,
+            'created_at', strftime('%Y-%m-%dT%H:%M:%fZ', NEW.created_at),
+            'updated_at', strftime('%Y-%m-%dT%H:%M:%fZ', NEW.updated_at)
+        ), json_object(
+            'id', OLD.id,
+            'name', OLD.name,
+            'url', OLD.url,
+            'article_index', OLD.article_index,
+            'page_index', OLD.page_index,
+            'rate_limit', OLD.rate_limit,
+            'max_depth', OLD.max_depth,
+            'time', json(COALESCE(CAST(OLD.time AS TEXT), 'null')),
+            'schedule', json(COALESCE(CAST(OLD.schedule AS TEXT), 'null')),
+            'selectors', json(CAST(OLD.selectors AS TEXT)),
+            'city_name', OLD.city_name,
+            'group_id', OLD.group_id,
+            'enabled', CASE WHEN OLD.enabled THEN json('true') ELSE json('false') END,
+            'version', OLD.version,
+            'created_at', strftime('%Y-%m-%dT%H:%M:%fZ', OLD.created_at),
+            'updated_at', strftime('%Y-%m-%dT%H:%M:%fZ', OLD.updated_at)
+        ));
+
+    -- last_insert_rowid() is the source.updated row inserted above
+    INSERT INTO source_events (event, source_id, source, previous)
+    SELECT
+        CASE WHEN NEW.enabled THEN 'source.enabled' ELSE 'source.disabled' END,
+        source_id, source, previous
+    FROM source_events
+    WHERE id = last_insert_rowid() AND NEW.enabled <> OLD.enabled;
+END;
+
+CREATE TRIGGER IF NOT EXISTS record_source_deleted
+AFTER DELETE ON sources
+BEGIN
+    INSERT INTO source_events (event, source_id, previous)
+    VALUES ('source.deleted', OLD.id, json_object(
+            'id', OLD.id,
+            'name', OLD.name,
+            'url', OLD.url,
+            'article_index', OLD.article_index,
+            'page_index', OLD.page_index,
+            'rate_limit', OLD.rate_limit,
+            'max_depth', OLD.max_depth,
+            'time', json(COALESCE(CAST(OLD.time AS TEXT), 'null')),
+            'schedule', json(COALESCE(CAST(OLD.schedule AS TEXT), 'null')),
+            'selectors', json(CAST(OLD.selectors AS TEXT)),
+            'city_name', OLD.city_name,
+            'group_id', OLD.group_id,
+            'enabled', CASE WHEN OLD.enabled THEN json('true') ELSE json('false') END,
+            'version', OLD.version,
+            'created_at', strftime('%Y-%m-%dT%H:%M:%fZ', OLD.created_at),
+            'updated_at', strftime('%Y-%m-%dT%H:%M:%fZ', OLD.updated_at)
+        ));
+END;
+
+DROP TABLE IF EXISTS cities;
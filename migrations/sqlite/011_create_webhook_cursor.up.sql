@@ -0,0 +1,11 @@
+-- Record how far the webhook dispatcher has read source_events. Deliveries
+-- are queued from the log in the same transaction that moves the cursor, so
+-- each event is queued once even with several servers running. Events logged
+-- before this migration were already queued and are skipped.
+CREATE TABLE IF NOT EXISTS webhook_event_cursor (
+    id INTEGER PRIMARY KEY CHECK (id = 1),
+    last_event_id BIGINT NOT NULL
+);
+
+INSERT OR IGNORE INTO webhook_event_cursor (id, last_event_id)
+SELECT 1, COALESCE(MAX(id), 0) FROM source_events;
@@ -0,0 +1,134 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/jonesrussell/gosources/internal/config"
+	"github.com/jonesrussell/gosources/internal/database"
+	"github.com/jonesrussell/gosources/internal/logger"
+	"github.com/jonesrussell/gosources/internal/repository"
+	"github.com/jonesrussell/gosources/internal/sourcefile"
+)
+
+const syncUsage = "usage: gosources [-config path] sync -dir path [-apply] [-prune]"
+
+// planSymbols prefixes each line of the printed plan.
+var planSymbols = map[sourcefile.Action]string{
+	sourcefile.ActionCreate:    "+",
+	sourcefile.ActionUpdate:    "~",
+	sourcefile.ActionDelete:    "-",
+	sourcefile.ActionUnchanged: "=",
+	sourcefile.ActionError:     "!",
+}
+
+// runSync implements the sync subcommand and returns the exit code. It prints
+// the plan for reconciling the database with a directory of source files and
+// only applies it with -apply.
+func runSync(cfg *config.Config, log logger.Logger, args []string) int {
+	flags := flag.NewFlagSet("sync", flag.ContinueOnError)
+	dir := flags.String("dir", "", "Directory of source files, one per source")
+	apply := flags.Bool("apply", false, "Apply the plan instead of only printing it")
+	prune := flags.Bool("prune", false, "Delete sources that have no file")
+	if err := flags.Parse(args); err != nil || *dir == "" || flags.NArg() > 0 {
+		fmt.Fprintln(os.Stderr, syncUsage)
+		return 2
+	}
+
+	entries, paths, err := sourcefile.ReadDir(*dir)
+	if err != nil {
+		log.Error("Failed to read source files", logger.Error(err))
+		return 1
+	}
+	if len(entries) == 0 && *prune {
+		log.Error("No source files found; refusing to prune every source",
+			logger.String("dir", *dir),
+		)
+		return 1
+	}
+
+	if cfg.Database.Driver == config.DriverMemory {
+		log.Error("sync needs a persistent database driver")
+		return 1
+	}
+	db, err := database.New(cfg, log)
+	if err != nil {
+		log.Error("Failed to connect to database", logger.Error(err))
+		return 1
+	}
+	defer func() {
+		_ = db.Close()
+	}()
+
+	// Changes are logged by the database; a running server notifies webhooks.
+	store := repository.NewSourceRepository(db.DB(), log)
+	cities := repository.NewCityRepository(db.DB(), log)
+	ctx := repository.WithActor(context.Background(), sourcefile.SyncActor)
+	opts := sourcefile.Options{Mode: sourcefile.ModeUpsert, DryRun: !*apply, Prune: *prune}
+
+	report, err := sourcefile.NewImporter(store, cities, log).Import(ctx, entries, opts)
+	if err != nil {
+		log.Error("Sync failed", logger.Error(err))
+		return 1
+	}
+
+	printPlan(os.Stdout, report, paths)
+
+	if report.Summary[sourcefile.ActionError] > 0 {
+		return 1
+	}
+	return 0
+}
+
+func printPlan(w io.Writer, report *sourcefile.Report, paths []string) {
+	for _, result := range report.Results {
+		if result.Action == sourcefile.ActionSkip {
+			continue
+		}
+
+		line := fmt.Sprintf("%s %-9s %s", planSymbols[result.Action], result.Action, result.Name)
+		if result.Index >= 0 {
+			line += " (" + paths[result.Index] + ")"
+		}
+		if result.Error != "" {
+			line += ": " + result.Error
+		}
+		fmt.Fprintln(w, line)
+
+		for _, change := range result.Changes {
+			fmt.Fprintf(w, "    %s %s: %s -> %s\n",
+				change.Op, change.Path, planValue(change.Old), planValue(change.Value))
+		}
+	}
+
+	verb := "Plan"
+	if !report.DryRun {
+		verb = "Applied"
+	}
+	fmt.Fprintf(w, "%s: %d to create, %d to update, %d to delete, %d unchanged, %d failed.\n",
+		verb,
+		report.Summary[sourcefile.ActionCreate],
+		report.Summary[sourcefile.ActionUpdate],
+		report.Summary[sourcefile.ActionDelete],
+		report.Summary[sourcefile.ActionUnchanged],
+		report.Summary[sourcefile.ActionError],
+	)
+	if report.DryRun {
+		fmt.Fprintln(w, "Run with -apply to make these changes.")
+	}
+}
+
+func planValue(v any) string {
+	if v == nil {
+		return "(none)"
+	}
+	data, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Sprint(v)
+	}
+	return string(data)
+}
@@ -0,0 +1,134 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/jonesrussell/gosources/internal/config"
+	"github.com/jonesrussell/gosources/internal/database"
+	"github.com/jonesrussell/gosources/internal/logger"
+	"github.com/jonesrussell/gosources/internal/models"
+	"github.com/jonesrussell/gosources/internal/repository"
+)
+
+const userUsage = `usage: gosources [-config path] user create -name name -role viewer|editor|admin [-cities city[,city...]]
+       gosources [-config path] user list
+       gosources [-config path] user delete name|id`
+
+// runUser implements the user subcommand and returns the exit code.
+func runUser(cfg *config.Config, log logger.Logger, args []string) int {
+	if len(args) == 0 {
+		fmt.Fprintln(os.Stderr, userUsage)
+		return 2
+	}
+
+	if cfg.Database.Driver == config.DriverMemory {
+		log.Error("user needs a persistent database driver")
+		return 1
+	}
+	db, err := database.New(cfg, log)
+	if err != nil {
+		log.Error("Failed to connect to database", logger.Error(err))
+		return 1
+	}
+	defer func() {
+		_ = db.Close()
+	}()
+
+	store := repository.NewUserRepository(db.DB(), log)
+	ctx := context.Background()
+
+	switch args[0] {
+	case "create":
+		return createUser(ctx, store, args[1:])
+	case "list":
+		users, listErr := store.List(ctx)
+		if listErr != nil {
+			log.Error("Failed to list users", logger.Error(listErr))
+			return 1
+		}
+		printUsers(os.Stdout, users)
+		return 0
+	case "delete":
+		if len(args) != 2 {
+			fmt.Fprintln(os.Stderr, userUsage)
+			return 2
+		}
+		user, findErr := findUser(ctx, store, args[1])
+		if findErr != nil {
+			log.Error("Failed to delete user", logger.Error(findErr))
+			return 1
+		}
+		if deleteErr := store.Delete(ctx, user.ID); deleteErr != nil {
+			log.Error("Failed to delete user", logger.Error(deleteErr))
+			return 1
+		}
+		fmt.Fprintf(os.Stdout, "Deleted %s and their API keys\n", user.Name)
+		return 0
+	default:
+		fmt.Fprintln(os.Stderr, userUsage)
+		return 2
+	}
+}
+
+func createUser(ctx context.Context, store repository.UserStore, args []string) int {
+	flags := flag.NewFlagSet("user create", flag.ContinueOnError)
+	name := flags.String("name", "", "Name of the user")
+	role := flags.String("role", "", "Role: viewer, editor or admin")
+	cities := flags.String("cities", "", "Comma-separated city names the user may access")
+	if err := flags.Parse(args); err != nil || flags.NArg() > 0 {
+		fmt.Fprintln(os.Stderr, userUsage)
+		return 2
+	}
+
+	user := models.User{Name: *name, Role: models.Role(*role), Cities: []string{}}
+	for city := range strings.SplitSeq(*cities, ",") {
+		if city = strings.TrimSpace(city); city != "" {
+			user.Cities = append(user.Cities, city)
+		}
+	}
+
+	if err := user.Validate(); err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to create user: %v\n", err)
+		return 1
+	}
+	if err := store.Create(ctx, &user); err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to create user: %v\n", err)
+		return 1
+	}
+
+	fmt.Fprintf(os.Stdout, "Created %s %s (%s)\n", user.Role, user.Name, user.ID)
+	return 0
+}
+
+// findUser looks a user up by ID or, failing that, by name.
+func findUser(ctx context.Context, store repository.UserStore, nameOrID string) (*models.User, error) {
+	users, err := store.List(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("list users: %w", err)
+	}
+	for i := range users {
+		if users[i].ID == nameOrID || users[i].Name == nameOrID {
+			return &users[i], nil
+		}
+	}
+	return nil, fmt.Errorf("user %s: %w", nameOrID, repository.ErrNotFound)
+}
+
+func printUsers(w io.Writer, users []models.User) {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "ID\tNAME\tROLE\tCITIES")
+	for i := range users {
+		cities := strings.Join(users[i].Cities, ",")
+		if users[i].Role == models.RoleAdmin {
+			cities = "(all)"
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\n", users[i].ID, users[i].Name, users[i].Role, cities)
+	}
+	_ = tw.Flush()
+}